@@ -0,0 +1,200 @@
+package bitcom
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// AIPPrefix is the prefix of the AIP (Author Identity
+// Protocol) protocol used to sign OP_RETURN data.
+//
+// See https://github.com/BitcoinFiles/AUTHOR_IDENTITY_PROTOCOL for more info.
+const AIPPrefix = "15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva"
+
+// AIPBitcoinECDSA is the AIP algorithm signing with a Bitcoin
+// signed message, using the P2PKH address as the signing component.
+const AIPBitcoinECDSA = "BITCOIN_ECDSA"
+
+// bitcoinSignedMessageMagic is prepended to Bitcoin signed messages.
+const bitcoinSignedMessageMagic = "Bitcoin Signed Message:\n"
+
+// AIP contains an author identity signature of the OP_RETURN data
+// preceding it.
+//
+// The data signed is the OP_RETURN opcode followed by all the data
+// parts preceding the pipe separating the AIP protocol. If field
+// indices are provided, only the fields at these indices are signed,
+// with index 0 being the OP_RETURN opcode.
+type AIP struct {
+	Algorithm string
+	Address   string
+	Signature string // base64 encoded
+	Indices   []int
+
+	// data decoded preceding the AIP protocol.
+	data [][]byte
+}
+
+// Parts returns the AIP protocol OP_RETURN data parts:
+//
+//	15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva <algorithm> <address> <signature> [index...]
+func (a *AIP) Parts() [][]byte {
+	parts := [][]byte{
+		[]byte(AIPPrefix),
+		[]byte(a.Algorithm),
+		[]byte(a.Address),
+		[]byte(a.Signature),
+	}
+	for _, idx := range a.Indices {
+		parts = append(parts, []byte(strconv.Itoa(idx)))
+	}
+
+	return parts
+}
+
+// SignAIP signs the OP_RETURN data parts with the private key using
+// the BITCOIN_ECDSA algorithm and returns the data parts with the
+// AIP protocol appended after a pipe.
+func SignAIP(parts [][]byte, key *bec.PrivateKey) ([][]byte, *AIP, error) {
+	hash := bitcoinMessageHash(aipMessage(parts))
+	sig, err := bec.SignCompact(bec.S256(), key, hash, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, err := bscript.NewAddressFromPublicKey(key.PubKey(), true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a := &AIP{
+		Algorithm: AIPBitcoinECDSA,
+		Address:   addr.AddressString,
+		Signature: base64.StdEncoding.EncodeToString(sig),
+		data:      parts,
+	}
+
+	signed := make([][]byte, 0, len(parts)+5)
+	signed = append(signed, parts...)
+	signed = append(signed, []byte(Pipe))
+
+	return append(signed, a.Parts()...), a, nil
+}
+
+// Verify the AIP signature against the data it was decoded with
+// or signed, returning ErrAIPInvalidSignature if it doesn't match.
+func (a *AIP) Verify() error {
+	if len(a.data) == 0 {
+		return ErrAIPNoData
+	}
+
+	return a.VerifyData(a.data)
+}
+
+// VerifyData verifies the AIP signature against the OP_RETURN data
+// parts provided, returning ErrAIPInvalidSignature if it doesn't match.
+func (a *AIP) VerifyData(parts [][]byte) error {
+	if a.Algorithm != AIPBitcoinECDSA {
+		return fmt.Errorf("%w: %s", ErrAIPUnsupportedAlgorithm, a.Algorithm)
+	}
+
+	msg := aipMessage(parts)
+	if len(a.Indices) > 0 {
+		fields := append([][]byte{{bscript.OpRETURN}}, parts...)
+		msg = make([]byte, 0)
+		for _, idx := range a.Indices {
+			if idx < 0 || idx >= len(fields) {
+				return fmt.Errorf("%w: %d", ErrAIPInvalidIndex, idx)
+			}
+			msg = append(msg, fields[idx]...)
+		}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return err
+	}
+
+	pubKey, compressed, err := bec.RecoverCompact(bec.S256(), sig, bitcoinMessageHash(msg))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrAIPInvalidSignature, err)
+	}
+
+	pk := pubKey.SerialiseUncompressed()
+	if compressed {
+		pk = pubKey.SerialiseCompressed()
+	}
+	addr, err := bscript.NewAddressFromPublicKeyHash(crypto.Hash160(pk), true)
+	if err != nil {
+		return err
+	}
+	if addr.AddressString != a.Address {
+		return ErrAIPInvalidSignature
+	}
+
+	return nil
+}
+
+func decodeAIP(parts [][]byte) (*AIP, error) {
+	if len(parts) < 3 {
+		return nil, ErrMissingFields
+	}
+
+	a := &AIP{
+		Algorithm: string(parts[0]),
+		Address:   string(parts[1]),
+		Signature: string(parts[2]),
+	}
+	for _, p := range parts[3:] {
+		idx, err := strconv.Atoi(string(p))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrAIPInvalidIndex, err)
+		}
+		a.Indices = append(a.Indices, idx)
+	}
+
+	return a, nil
+}
+
+// aipMessage returns the OP_RETURN opcode
+// followed by the data parts.
+func aipMessage(parts [][]byte) []byte {
+	return append([]byte{bscript.OpRETURN}, bytes.Join(parts, nil)...)
+}
+
+// bitcoinMessageHash returns the hash signed
+// for a Bitcoin signed message.
+func bitcoinMessageHash(msg []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(varInt(uint64(len(bitcoinSignedMessageMagic))))
+	buf.WriteString(bitcoinSignedMessageMagic)
+	buf.Write(varInt(uint64(len(msg))))
+	buf.Write(msg)
+
+	return crypto.Sha256d(buf.Bytes())
+}
+
+func varInt(i uint64) []byte {
+	switch {
+	case i < 0xfd:
+		return []byte{byte(i)}
+	case i <= 0xffff:
+		b := []byte{0xfd, 0, 0}
+		binary.LittleEndian.PutUint16(b[1:], uint16(i))
+		return b
+	case i <= 0xffffffff:
+		b := []byte{0xfe, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(b[1:], uint32(i))
+		return b
+	}
+	b := []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(b[1:], i)
+	return b
+}
@@ -0,0 +1,68 @@
+package bitcom_test
+
+import (
+	"testing"
+
+	"github.com/libsv/go-bk/wif"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bitcom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAIP(t *testing.T) {
+	w, err := wif.DecodeWIF("L42PyNwEKE4XRaa8PzPh7JZurSAWJmx49nbVfaXYuiQg3RCubwn7") // 1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ
+	require.NoError(t, err)
+
+	parts := bitcom.Encode(
+		&bitcom.B{Data: []byte("Hello, world!"), MediaType: "text/plain"},
+		&bitcom.MAP{Data: map[string]string{"app": "go-bt"}},
+	)
+
+	signed, aip, err := bitcom.SignAIP(parts, w.PrivKey)
+	require.NoError(t, err)
+	assert.Equal(t, bitcom.AIPBitcoinECDSA, aip.Algorithm)
+	assert.Equal(t, "1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ", aip.Address)
+	assert.NoError(t, aip.Verify())
+
+	t.Run("decoded signature is valid", func(t *testing.T) {
+		tx := bt.NewTx()
+		require.NoError(t, tx.AddOpReturnPartsOutput(signed))
+
+		bc, err := bitcom.DecodeScript(tx.Outputs[0].LockingScript)
+		require.NoError(t, err)
+		require.Len(t, bc.AIP, 1)
+		assert.Equal(t, aip.Signature, bc.AIP[0].Signature)
+		assert.NoError(t, bc.AIP[0].Verify())
+	})
+
+	t.Run("tampered data is invalid", func(t *testing.T) {
+		tampered := make([][]byte, len(signed))
+		copy(tampered, signed)
+		tampered[1] = []byte("Goodbye, world!")
+
+		bc, err := bitcom.Decode(tampered)
+		require.NoError(t, err)
+		require.Len(t, bc.AIP, 1)
+		assert.ErrorIs(t, bc.AIP[0].Verify(), bitcom.ErrAIPInvalidSignature)
+	})
+
+	t.Run("signed field indices", func(t *testing.T) {
+		a := *aip
+		a.Indices = []int{0, 1, 2}
+		assert.ErrorIs(t, a.VerifyData(parts), bitcom.ErrAIPInvalidSignature)
+
+		a.Indices = []int{0, 100}
+		assert.ErrorIs(t, a.VerifyData(parts), bitcom.ErrAIPInvalidIndex)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		a := *aip
+		a.Algorithm = "PAYMAIL"
+		assert.ErrorIs(t, a.Verify(), bitcom.ErrAIPUnsupportedAlgorithm)
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		assert.ErrorIs(t, (&bitcom.AIP{Algorithm: bitcom.AIPBitcoinECDSA}).Verify(), bitcom.ErrAIPNoData)
+	})
+}
@@ -0,0 +1,57 @@
+package bitcom
+
+// BPrefix is the prefix of the B:// protocol used
+// to store files.
+//
+// See https://b.bitdb.network for more info.
+const BPrefix = "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"
+
+// BEncodingBinary is the default B:// encoding.
+const BEncodingBinary = "binary"
+
+// B contains a file stored with the B:// protocol.
+type B struct {
+	Data      []byte
+	MediaType string
+	Encoding  string
+	Filename  string
+}
+
+// Parts returns the B:// protocol OP_RETURN data parts:
+//
+//	19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut <data> <media type> <encoding> [filename]
+//
+// If no encoding is set, binary is used.
+func (b *B) Parts() [][]byte {
+	enc := b.Encoding
+	if enc == "" {
+		enc = BEncodingBinary
+	}
+
+	parts := [][]byte{[]byte(BPrefix), b.Data, []byte(b.MediaType), []byte(enc)}
+	if b.Filename != "" {
+		parts = append(parts, []byte(b.Filename))
+	}
+
+	return parts
+}
+
+func decodeB(parts [][]byte) (*B, error) {
+	if len(parts) < 2 {
+		return nil, ErrMissingFields
+	}
+
+	b := &B{
+		Data:      parts[0],
+		MediaType: string(parts[1]),
+		Encoding:  BEncodingBinary,
+	}
+	if len(parts) > 2 {
+		b.Encoding = string(parts[2])
+	}
+	if len(parts) > 3 {
+		b.Filename = string(parts[3])
+	}
+
+	return b, nil
+}
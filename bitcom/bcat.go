@@ -0,0 +1,234 @@
+package bitcom
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// BcatPrefix is the prefix of the Bcat protocol linking
+// the chunks of a file stored across multiple txs.
+//
+// See https://bcat.bico.media for more info.
+const BcatPrefix = "15DHFxWZJT58f9nhyGnsRBqrgwK4W6h4Up"
+
+// BcatPartPrefix is the prefix of the Bcat part
+// protocol storing a chunk of a file.
+const BcatPartPrefix = "1ChDHzdd1H4wSjgGMHyndZm6qxEDGjqpJL"
+
+// DefaultBcatChunkSize is the default maximum
+// size of a file chunk stored in a single tx.
+const DefaultBcatChunkSize = 100000
+
+// Bcat links the txs storing the chunks of a file
+// with the Bcat part protocol, in order.
+type Bcat struct {
+	Info      string
+	MediaType string
+	Charset   string
+	Filename  string
+	Flag      string
+	TxIDs     [][]byte
+}
+
+// Parts returns the Bcat protocol OP_RETURN data parts:
+//
+//	15DHFxWZJT58f9nhyGnsRBqrgwK4W6h4Up <info> <media type> <charset> <filename> <flag> <txid> [txid...]
+//
+// Unset fields are pushed as empty data (OP_0).
+func (b *Bcat) Parts() [][]byte {
+	parts := [][]byte{
+		[]byte(BcatPrefix),
+		[]byte(b.Info),
+		[]byte(b.MediaType),
+		[]byte(b.Charset),
+		[]byte(b.Filename),
+		[]byte(b.Flag),
+	}
+
+	return append(parts, b.TxIDs...)
+}
+
+// BcatPart contains a chunk of a file linked with Bcat.
+type BcatPart struct {
+	Data []byte
+}
+
+// Parts returns the Bcat part protocol OP_RETURN data parts:
+//
+//	1ChDHzdd1H4wSjgGMHyndZm6qxEDGjqpJL <data>
+func (p *BcatPart) Parts() [][]byte {
+	return [][]byte{[]byte(BcatPartPrefix), p.Data}
+}
+
+// BcatArgs contains the file to store with Bcat
+// and how the txs storing it are funded.
+type BcatArgs struct {
+	Data      []byte
+	Info      string
+	MediaType string
+	Charset   string
+	Filename  string
+	ChunkSize int // defaults to DefaultBcatChunkSize
+
+	// UTXOs funds the first tx and any tx the change
+	// of the previous tx doesn't cover.
+	UTXOs bt.UTXOGetterFunc
+	// Unlocker signs the inputs, so it must be able to unlock both
+	// the UTXOs and ChangeScript, as the change is spent by the next tx.
+	Unlocker     bt.UnlockerGetter
+	ChangeScript *bscript.Script
+	FQ           *bt.FeeQuote
+}
+
+// NewBcatTxs builds and signs the txs storing the file with Bcat,
+// returning the chunk txs in order followed by the Bcat tx linking them.
+//
+// Each tx has the OP_RETURN data at vout 0 and its change, if any,
+// at vout 1. Each tx spends the change of the tx before it, calling
+// the UTXOGetterFunc only when the change doesn't cover the next tx,
+// so the txs need to be broadcast in the order they are returned and
+// the unlocker getter must be able to unlock ChangeScript.
+func NewBcatTxs(ctx context.Context, ba *BcatArgs) (bt.Txs, error) {
+	if len(ba.Data) == 0 {
+		return nil, ErrBcatNoData
+	}
+	size := ba.ChunkSize
+	if size <= 0 {
+		size = DefaultBcatChunkSize
+	}
+
+	txs := make(bt.Txs, 0, len(ba.Data)/size+2)
+	bcat := &Bcat{
+		Info:      ba.Info,
+		MediaType: ba.MediaType,
+		Charset:   ba.Charset,
+		Filename:  ba.Filename,
+	}
+
+	var change *bt.UTXO
+	newTx := func(parts [][]byte) (*bt.Tx, error) {
+		tx := bt.NewTx()
+		if err := tx.AddOpReturnPartsOutput(parts); err != nil {
+			return nil, err
+		}
+		if err := tx.Fund(ctx, ba.FQ, chainUTXOs(change, ba.UTXOs)); err != nil {
+			return nil, err
+		}
+		if err := tx.Change(ba.ChangeScript, ba.FQ); err != nil {
+			return nil, err
+		}
+		if err := tx.FillAllInputs(ctx, ba.Unlocker); err != nil {
+			return nil, err
+		}
+
+		change = nil
+		if tx.OutputCount() > 1 {
+			change = &bt.UTXO{
+				TxID:          tx.TxIDBytes(),
+				Vout:          1,
+				LockingScript: tx.Outputs[1].LockingScript,
+				Satoshis:      tx.Outputs[1].Satoshis,
+			}
+		}
+
+		return tx, nil
+	}
+
+	for data := ba.Data; len(data) > 0; {
+		n := size
+		if len(data) < n {
+			n = len(data)
+		}
+
+		tx, err := newTx((&BcatPart{Data: data[:n]}).Parts())
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+		bcat.TxIDs = append(bcat.TxIDs, tx.TxIDBytes())
+		data = data[n:]
+	}
+
+	tx, err := newTx(bcat.Parts())
+	if err != nil {
+		return nil, err
+	}
+
+	return append(txs, tx), nil
+}
+
+// DecodeBcatTxs finds the Bcat tx in the txs provided and
+// rebuilds the file it links from the chunk txs.
+func DecodeBcatTxs(txs bt.Txs) (*Bcat, []byte, error) {
+	var bcat *Bcat
+	parts := make(map[string][]byte)
+	for _, tx := range txs {
+		for _, o := range tx.Outputs {
+			bc, err := DecodeScript(o.LockingScript)
+			if err != nil {
+				continue
+			}
+			if len(bc.Bcat) > 0 && bcat == nil {
+				bcat = bc.Bcat[0]
+			}
+			if len(bc.BcatPart) > 0 {
+				parts[tx.TxID()] = bc.BcatPart[0].Data
+			}
+		}
+	}
+	if bcat == nil {
+		return nil, nil, ErrBcatNotFound
+	}
+
+	var buf bytes.Buffer
+	for _, txID := range bcat.TxIDs {
+		data, ok := parts[fmt.Sprintf("%x", txID)]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %x", ErrBcatPartNotFound, txID)
+		}
+		buf.Write(data)
+	}
+
+	return bcat, buf.Bytes(), nil
+}
+
+func decodeBcat(parts [][]byte) (*Bcat, error) {
+	if len(parts) < 6 {
+		return nil, ErrMissingFields
+	}
+
+	return &Bcat{
+		Info:      string(parts[0]),
+		MediaType: string(parts[1]),
+		Charset:   string(parts[2]),
+		Filename:  string(parts[3]),
+		Flag:      string(parts[4]),
+		TxIDs:     parts[5:],
+	}, nil
+}
+
+func decodeBcatPart(parts [][]byte) (*BcatPart, error) {
+	if len(parts) < 1 {
+		return nil, ErrMissingFields
+	}
+
+	return &BcatPart{Data: parts[0]}, nil
+}
+
+// chainUTXOs returns a UTXOGetterFunc returning the change of
+// the previous tx first, before falling back to the next func.
+func chainUTXOs(change *bt.UTXO, next bt.UTXOGetterFunc) bt.UTXOGetterFunc {
+	return func(ctx context.Context, deficit uint64) ([]*bt.UTXO, error) {
+		if change != nil {
+			u := change
+			change = nil
+			return []*bt.UTXO{u}, nil
+		}
+
+		return next(ctx, deficit)
+	}
+}
@@ -0,0 +1,99 @@
+package bitcom_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/libsv/go-bk/wif"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bitcom"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/unlocker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBcatTxs(t *testing.T) {
+	w, err := wif.DecodeWIF("L42PyNwEKE4XRaa8PzPh7JZurSAWJmx49nbVfaXYuiQg3RCubwn7") // 1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ
+	require.NoError(t, err)
+	s, err := bscript.NewP2PKHFromAddress("1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ")
+	require.NoError(t, err)
+
+	txID, err := hex.DecodeString("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a")
+	require.NoError(t, err)
+
+	utxos := func(utxos ...*bt.UTXO) bt.UTXOGetterFunc {
+		return func(ctx context.Context, deficit uint64) ([]*bt.UTXO, error) {
+			if len(utxos) == 0 {
+				return nil, bt.ErrNoUTXO
+			}
+			u := utxos[0]
+			utxos = utxos[1:]
+			return []*bt.UTXO{u}, nil
+		}
+	}
+
+	data := bytes.Repeat([]byte("Hello, world!"), 100)
+
+	t.Run("file split across chained txs", func(t *testing.T) {
+		txs, err := bitcom.NewBcatTxs(context.Background(), &bitcom.BcatArgs{
+			Data:         data,
+			MediaType:    "text/plain",
+			Charset:      "utf-8",
+			Filename:     "hello.txt",
+			ChunkSize:    500,
+			UTXOs:        utxos(&bt.UTXO{TxID: txID, Vout: 0, LockingScript: s, Satoshis: 10000}),
+			Unlocker:     &unlocker.Getter{PrivateKey: w.PrivKey},
+			ChangeScript: s,
+			FQ:           bt.NewFeeQuote(),
+		})
+		require.NoError(t, err)
+		require.Len(t, txs, 4)
+
+		// each tx spends the change of the tx before it
+		for i := 1; i < len(txs); i++ {
+			require.Equal(t, 1, txs[i].InputCount())
+			assert.Equal(t, txs[i-1].TxIDBytes(), txs[i].Inputs[0].PreviousTxID())
+			assert.Equal(t, uint32(1), txs[i].Inputs[0].PreviousTxOutIndex)
+			assert.NoError(t, interpreter.NewEngine().Execute(
+				interpreter.WithTx(txs[i].Clone(), 0, txs[i-1].Outputs[1]),
+				interpreter.WithForkID(),
+				interpreter.WithAfterGenesis(),
+			))
+		}
+
+		bcat, file, err := bitcom.DecodeBcatTxs(txs)
+		require.NoError(t, err)
+		assert.Equal(t, data, file)
+		assert.Equal(t, "text/plain", bcat.MediaType)
+		assert.Equal(t, "utf-8", bcat.Charset)
+		assert.Equal(t, "hello.txt", bcat.Filename)
+		assert.Len(t, bcat.TxIDs, 3)
+
+		_, _, err = bitcom.DecodeBcatTxs(append(bt.Txs{}, txs[0], txs[2], txs[3]))
+		assert.ErrorIs(t, err, bitcom.ErrBcatPartNotFound)
+
+		_, _, err = bitcom.DecodeBcatTxs(txs[:3])
+		assert.ErrorIs(t, err, bitcom.ErrBcatNotFound)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		_, err := bitcom.NewBcatTxs(context.Background(), &bitcom.BcatArgs{
+			Data:         data,
+			ChunkSize:    500,
+			UTXOs:        utxos(&bt.UTXO{TxID: txID, Vout: 0, LockingScript: s, Satoshis: 1}),
+			Unlocker:     &unlocker.Getter{PrivateKey: w.PrivKey},
+			ChangeScript: s,
+			FQ:           bt.NewFeeQuote(),
+		})
+		assert.ErrorIs(t, err, bt.ErrInsufficientFunds)
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		_, err := bitcom.NewBcatTxs(context.Background(), &bitcom.BcatArgs{})
+		assert.ErrorIs(t, err, bitcom.ErrBcatNoData)
+	})
+}
@@ -0,0 +1,202 @@
+/*
+Package bitcom provides builders and parsers for Bitcom protocols found in
+OP_RETURN data.
+
+The supported protocols are B:// (files), MAP (key/value metadata), AIP
+(author identity signatures) and Bcat (files split across multiple txs).
+Multiple protocols are combined in the same OP_RETURN by separating them
+with a pipe (`|`).
+
+The builders return the OP_RETURN data as [][]byte so that it can be used
+with both `bt.Tx.AddOpReturnPartsOutput` and the enriched inscription args
+used by `bt.Tx.Inscribe`:
+
+	parts := bitcom.Encode(
+		&bitcom.B{Data: []byte("Hello, world!"), MediaType: "text/plain"},
+		&bitcom.MAP{Cmd: bitcom.MAPSet, Data: map[string]string{"app": "example"}},
+	)
+	parts, _, err := bitcom.SignAIP(parts, privKey)
+	if err != nil {}
+	err = tx.AddOpReturnPartsOutput(parts)
+
+See https://bitcom.planaria.network for more info about the protocols.
+*/
+package bitcom
+
+import (
+	"bytes"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// Pipe separates multiple protocols in the same OP_RETURN.
+const Pipe = "|"
+
+// Protocol is a Bitcom protocol which can be encoded
+// as OP_RETURN data parts, starting with its prefix.
+type Protocol interface {
+	Parts() [][]byte
+}
+
+// Bitcom contains the protocols decoded from OP_RETURN
+// data, in the order they appear in.
+type Bitcom struct {
+	B   []*B
+	MAP []*MAP
+	AIP []*AIP
+
+	Bcat     []*Bcat
+	BcatPart []*BcatPart
+}
+
+// Encode the protocols provided into OP_RETURN
+// data parts, separated with pipes.
+func Encode(pp ...Protocol) [][]byte {
+	parts := make([][]byte, 0)
+	for i, p := range pp {
+		if i > 0 {
+			parts = append(parts, []byte(Pipe))
+		}
+		parts = append(parts, p.Parts()...)
+	}
+
+	return parts
+}
+
+// Decode the OP_RETURN data parts into the Bitcom protocols
+// they contain. Protocols with an unknown prefix are skipped.
+func Decode(parts [][]byte) (*Bitcom, error) {
+	bc := &Bitcom{}
+
+	start := 0
+	for _, tape := range splitPipes(parts) {
+		end := start + len(tape)
+		if len(tape) > 0 {
+			switch string(tape[0]) {
+			case BPrefix:
+				b, err := decodeB(tape[1:])
+				if err != nil {
+					return nil, err
+				}
+				bc.B = append(bc.B, b)
+			case MAPPrefix:
+				m, err := decodeMAP(tape[1:])
+				if err != nil {
+					return nil, err
+				}
+				bc.MAP = append(bc.MAP, m)
+			case AIPPrefix:
+				a, err := decodeAIP(tape[1:])
+				if err != nil {
+					return nil, err
+				}
+				// the pipe before the AIP protocol isn't signed
+				if start > 0 {
+					a.data = parts[:start-1]
+				}
+				bc.AIP = append(bc.AIP, a)
+			case BcatPrefix:
+				b, err := decodeBcat(tape[1:])
+				if err != nil {
+					return nil, err
+				}
+				bc.Bcat = append(bc.Bcat, b)
+			case BcatPartPrefix:
+				p, err := decodeBcatPart(tape[1:])
+				if err != nil {
+					return nil, err
+				}
+				bc.BcatPart = append(bc.BcatPart, p)
+			}
+		}
+		start = end + 1 // skip pipe
+	}
+
+	return bc, nil
+}
+
+// DecodeScript decodes the Bitcom protocols found in the OP_RETURN
+// data of the script, such as the ones created with
+// `bt.Tx.AddOpReturnPartsOutput` or `bt.Tx.Inscribe`.
+func DecodeScript(s *bscript.Script) (*Bitcom, error) {
+	parts, err := OpReturnParts(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(parts)
+}
+
+// OpReturnParts returns the data pushed after the first
+// OP_RETURN found in the script.
+func OpReturnParts(s *bscript.Script) ([][]byte, error) {
+	parts, err := bscript.DecodeParts(*s)
+	if err != nil {
+		return nil, err
+	}
+
+	// DecodeParts returns opcodes as parts of 1 byte, so the
+	// opcodes are read from the script alongside the parts
+	// to tell them apart from push data
+	ops := []byte(*s)
+	for i, p := range parts {
+		op := ops[0]
+		ops = ops[opSize(op, p):]
+		if op == bscript.OpRETURN {
+			return pushDataParts(ops, parts[i+1:])
+		}
+	}
+
+	return nil, ErrNoOpReturn
+}
+
+// pushDataParts returns the data of the push data parts,
+// failing on any other opcode read from ops.
+func pushDataParts(ops []byte, parts [][]byte) ([][]byte, error) {
+	data := make([][]byte, 0, len(parts))
+	for _, p := range parts {
+		op := ops[0]
+		if op > bscript.OpPUSHDATA4 {
+			return nil, ErrInvalidPushData
+		}
+		ops = ops[opSize(op, p):]
+		if op == bscript.OpZERO {
+			p = []byte{}
+		}
+		data = append(data, p)
+	}
+
+	return data, nil
+}
+
+// opSize returns the amount of bytes used in the script
+// by the opcode op, decoded by DecodeParts as part p.
+func opSize(op byte, p []byte) int {
+	switch {
+	case op == bscript.OpZERO || op > bscript.OpPUSHDATA4:
+		return 1
+	case op == bscript.OpPUSHDATA1:
+		return 2 + len(p)
+	case op == bscript.OpPUSHDATA2:
+		return 3 + len(p)
+	case op == bscript.OpPUSHDATA4:
+		return 5 + len(p)
+	default:
+		return 1 + len(p)
+	}
+}
+
+// splitPipes splits the parts into the
+// protocols separated by pipes.
+func splitPipes(parts [][]byte) [][][]byte {
+	tapes := [][][]byte{{}}
+	for _, p := range parts {
+		if bytes.Equal(p, []byte(Pipe)) {
+			tapes = append(tapes, [][]byte{})
+			continue
+		}
+		tapes[len(tapes)-1] = append(tapes[len(tapes)-1], p)
+	}
+
+	return tapes
+}
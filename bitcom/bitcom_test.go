@@ -0,0 +1,195 @@
+package bitcom_test
+
+import (
+	"testing"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bitcom"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := map[string]struct {
+		protocols []bitcom.Protocol
+		expBitcom *bitcom.Bitcom
+	}{
+		"B file": {
+			protocols: []bitcom.Protocol{
+				&bitcom.B{Data: []byte("Hello, world!"), MediaType: "text/plain", Filename: "hello.txt"},
+			},
+			expBitcom: &bitcom.Bitcom{
+				B: []*bitcom.B{{
+					Data:      []byte("Hello, world!"),
+					MediaType: "text/plain",
+					Encoding:  bitcom.BEncodingBinary,
+					Filename:  "hello.txt",
+				}},
+			},
+		},
+		"B file and MAP": {
+			protocols: []bitcom.Protocol{
+				&bitcom.B{Data: []byte("{}"), MediaType: "application/json", Encoding: "utf-8"},
+				&bitcom.MAP{Data: map[string]string{"app": "go-bt", "type": "post"}},
+			},
+			expBitcom: &bitcom.Bitcom{
+				B: []*bitcom.B{{
+					Data:      []byte("{}"),
+					MediaType: "application/json",
+					Encoding:  "utf-8",
+				}},
+				MAP: []*bitcom.MAP{{
+					Cmd:  bitcom.MAPSet,
+					Data: map[string]string{"app": "go-bt", "type": "post"},
+				}},
+			},
+		},
+		"MAP other command and SET": {
+			protocols: []bitcom.Protocol{
+				&bitcom.MAP{Cmd: "DELETE", Args: [][]byte{[]byte("app"), []byte("go-bt")}},
+				&bitcom.MAP{Data: map[string]string{"app": "go-bt"}},
+			},
+			expBitcom: &bitcom.Bitcom{
+				MAP: []*bitcom.MAP{{
+					Cmd:  "DELETE",
+					Args: [][]byte{[]byte("app"), []byte("go-bt")},
+				}, {
+					Cmd:  bitcom.MAPSet,
+					Data: map[string]string{"app": "go-bt"},
+				}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bc, err := bitcom.Decode(bitcom.Encode(test.protocols...))
+			require.NoError(t, err)
+			assert.Equal(t, test.expBitcom, bc)
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := map[string]struct {
+		parts  [][]byte
+		expErr error
+	}{
+		"B missing media type": {
+			parts:  [][]byte{[]byte(bitcom.BPrefix), []byte("data")},
+			expErr: bitcom.ErrMissingFields,
+		},
+		"MAP odd pairs": {
+			parts:  [][]byte{[]byte(bitcom.MAPPrefix), []byte(bitcom.MAPSet), []byte("app")},
+			expErr: bitcom.ErrMAPInvalidPairs,
+		},
+		"AIP missing signature": {
+			parts:  [][]byte{[]byte(bitcom.AIPPrefix), []byte(bitcom.AIPBitcoinECDSA)},
+			expErr: bitcom.ErrMissingFields,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := bitcom.Decode(test.parts)
+			assert.ErrorIs(t, err, test.expErr)
+		})
+	}
+}
+
+func TestDecodeScript(t *testing.T) {
+	parts := bitcom.Encode(
+		&bitcom.B{Data: []byte("Hello, world!"), MediaType: "text/plain"},
+		&bitcom.MAP{Data: map[string]string{"app": "go-bt"}},
+	)
+	exp := &bitcom.Bitcom{
+		B: []*bitcom.B{{
+			Data:      []byte("Hello, world!"),
+			MediaType: "text/plain",
+			Encoding:  bitcom.BEncodingBinary,
+		}},
+		MAP: []*bitcom.MAP{{
+			Cmd:  bitcom.MAPSet,
+			Data: map[string]string{"app": "go-bt"},
+		}},
+	}
+
+	t.Run("op return output", func(t *testing.T) {
+		tx := bt.NewTx()
+		require.NoError(t, tx.AddOpReturnPartsOutput(parts))
+
+		bc, err := bitcom.DecodeScript(tx.Outputs[0].LockingScript)
+		require.NoError(t, err)
+		assert.Equal(t, exp, bc)
+	})
+
+	t.Run("enriched inscription", func(t *testing.T) {
+		prefix, err := bscript.NewP2PKHFromAddress("1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ")
+		require.NoError(t, err)
+
+		tx := bt.NewTx()
+		require.NoError(t, tx.Inscribe(&bscript.InscriptionArgs{
+			LockingScriptPrefix: prefix,
+			Data:                []byte("Hello, world!"),
+			ContentType:         "text/plain",
+			EnrichedArgs:        &bscript.EnrichedInscriptionArgs{OpReturnData: parts},
+		}))
+
+		bc, err := bitcom.DecodeScript(tx.Outputs[0].LockingScript)
+		require.NoError(t, err)
+		assert.Equal(t, exp, bc)
+	})
+
+	t.Run("no op return", func(t *testing.T) {
+		s, err := bscript.NewP2PKHFromAddress("1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ")
+		require.NoError(t, err)
+
+		_, err = bitcom.DecodeScript(s)
+		assert.ErrorIs(t, err, bitcom.ErrNoOpReturn)
+	})
+}
+
+func TestOpReturnParts(t *testing.T) {
+	tests := map[string]struct {
+		script   string
+		expParts [][]byte
+		expErr   error
+	}{
+		"push data of OP_RETURN isn't an OP_RETURN": {
+			script:   "016a006a0361626300",
+			expParts: [][]byte{[]byte("abc"), {}},
+		},
+		"pushdata1 after OP_RETURN": {
+			script:   "6a4c03616263",
+			expParts: [][]byte{[]byte("abc")},
+		},
+		"non push data opcode after OP_RETURN": {
+			script: "6a0361626376",
+			expErr: bitcom.ErrInvalidPushData,
+		},
+		"push data past the end of the script": {
+			script: "6a0561626364",
+			expErr: bscript.ErrDataTooSmall,
+		},
+		"no OP_RETURN": {
+			script: "016a76",
+			expErr: bitcom.ErrNoOpReturn,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := bscript.NewFromHexString(test.script)
+			require.NoError(t, err)
+
+			parts, err := bitcom.OpReturnParts(s)
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expParts, parts)
+		})
+	}
+}
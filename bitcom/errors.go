@@ -0,0 +1,26 @@
+package bitcom
+
+import "github.com/pkg/errors"
+
+// Sentinel errors raised when decoding bitcom protocols.
+var (
+	ErrNoOpReturn      = errors.New("no OP_RETURN found in script")
+	ErrInvalidPushData = errors.New("non push data opcode found after OP_RETURN")
+	ErrMissingFields   = errors.New("protocol is missing required fields")
+	ErrMAPInvalidPairs = errors.New("MAP SET needs key value pairs")
+)
+
+// Sentinel errors raised by AIP.
+var (
+	ErrAIPUnsupportedAlgorithm = errors.New("unsupported AIP signing algorithm")
+	ErrAIPInvalidIndex         = errors.New("AIP field index out of range")
+	ErrAIPInvalidSignature     = errors.New("AIP signature doesn't match address")
+	ErrAIPNoData               = errors.New("no data found preceding AIP")
+)
+
+// Sentinel errors raised by Bcat.
+var (
+	ErrBcatNoData       = errors.New("no data to store with bcat")
+	ErrBcatNotFound     = errors.New("no bcat tx found")
+	ErrBcatPartNotFound = errors.New("bcat part tx not found")
+)
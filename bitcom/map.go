@@ -0,0 +1,77 @@
+package bitcom
+
+import "sort"
+
+// MAPPrefix is the prefix of the MAP (Magic Attribute
+// Protocol) protocol used to store key/value metadata.
+//
+// See https://map.sv for more info.
+const MAPPrefix = "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"
+
+// MAPSet is the MAP command to set key/value pairs.
+const MAPSet = "SET"
+
+// MAP contains the key/value metadata set
+// with the MAP protocol.
+//
+// Only the SET command is decoded into Data, the
+// arguments of any other command (ADD, DELETE...)
+// are kept as they are in Args.
+type MAP struct {
+	Cmd  string
+	Data map[string]string
+	Args [][]byte
+}
+
+// Parts returns the MAP protocol OP_RETURN data parts:
+//
+//	1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5 SET <key> <value> [<key> <value>...]
+//
+// The key/value pairs are sorted by key so that
+// the encoding is deterministic. Commands other
+// than SET are followed by Args instead.
+func (m *MAP) Parts() [][]byte {
+	cmd := m.Cmd
+	if cmd == "" {
+		cmd = MAPSet
+	}
+	if cmd != MAPSet {
+		return append([][]byte{[]byte(MAPPrefix), []byte(cmd)}, m.Args...)
+	}
+
+	keys := make([]string, 0, len(m.Data))
+	for k := range m.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := [][]byte{[]byte(MAPPrefix), []byte(cmd)}
+	for _, k := range keys {
+		parts = append(parts, []byte(k), []byte(m.Data[k]))
+	}
+
+	return parts
+}
+
+func decodeMAP(parts [][]byte) (*MAP, error) {
+	if len(parts) < 1 {
+		return nil, ErrMissingFields
+	}
+
+	m := &MAP{Cmd: string(parts[0])}
+	if m.Cmd != MAPSet {
+		m.Args = parts[1:]
+		return m, nil
+	}
+
+	kvs := parts[1:]
+	if len(kvs)%2 != 0 {
+		return nil, ErrMAPInvalidPairs
+	}
+	m.Data = make(map[string]string, len(kvs)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		m.Data[string(kvs[i])] = string(kvs[i+1])
+	}
+
+	return m, nil
+}
@@ -8,13 +8,8 @@ import (
 	"github.com/libsv/go-bk/base58"
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bk/crypto"
-)
 
-const (
-	hashP2PKH        = 0x00
-	hashTestNetP2PKH = 0x6f
-	hashP2SH         = 0x05 // TODO: remove deprecated p2sh stuff
-	hashTestNetP2SH  = 0xc4
+	"github.com/libsv/go-bt/v2/chaincfg"
 )
 
 // An Address struct contains the address string as well as the hash160 hex string of the public key.
@@ -28,7 +23,7 @@ type Address struct {
 // NewAddressFromString takes a string address (P2PKH) and returns a pointer to an Address
 // which contains the address string as well as the public key hash string.
 func NewAddressFromString(addr string) (*Address, error) {
-	pkh, err := addressToPubKeyHashStr(addr)
+	pkh, err := addressToPubKeyHashStr(addr, &chaincfg.MainNet, &chaincfg.TestNet)
 	if err != nil {
 		return nil, err
 	}
@@ -38,53 +33,74 @@ func NewAddressFromString(addr string) (*Address, error) {
 	}, nil
 }
 
-func addressToPubKeyHashStr(address string) (string, error) {
+// NewAddressFromStringWithParams takes a string address (P2PKH) and returns a pointer to an Address
+// which contains the address string as well as the public key hash string.
+// An ErrAddressNetworkMismatch is returned if the address isn't for the network of the params provided.
+func NewAddressFromStringWithParams(addr string, params *chaincfg.Params) (*Address, error) {
+	pkh, err := addressToPubKeyHashStr(addr, params)
+	if err != nil {
+		return nil, err
+	}
+	return &Address{
+		AddressString: addr,
+		PublicKeyHash: pkh,
+	}, nil
+}
+
+func addressToPubKeyHashStr(address string, pp ...*chaincfg.Params) (string, error) {
 	decoded := base58.Decode(address)
 
 	if len(decoded) != 25 {
 		return "", fmt.Errorf("%w for '%s'", ErrInvalidAddressLength, address)
 	}
 
-	switch decoded[0] {
-	case hashP2PKH: // Pubkey hash (P2PKH address)
-		return hex.EncodeToString(decoded[1 : len(decoded)-4]), nil
-
-	case hashTestNetP2PKH: // Testnet pubkey hash (P2PKH address)
-		return hex.EncodeToString(decoded[1 : len(decoded)-4]), nil
+	for _, p := range pp {
+		if decoded[0] == p.PubKeyHashAddrID { // Pubkey hash (P2PKH address)
+			return hex.EncodeToString(decoded[1 : len(decoded)-4]), nil
+		}
+	}
 
-	case hashP2SH: // Script hash (P2SH address)
-		fallthrough
-	case hashTestNetP2SH: // Testnet script hash (P2SH address)
-		fallthrough
-	default:
-		return "", fmt.Errorf("%w %s", ErrUnsupportedAddress, address)
+	if len(pp) == 1 && isPubKeyHashAddrID(decoded[0]) {
+		return "", fmt.Errorf("%w: %s is not a %s address", ErrAddressNetworkMismatch, address, pp[0].Name)
 	}
+
+	// Script hash (P2SH address) and other versions
+	return "", fmt.Errorf("%w %s", ErrUnsupportedAddress, address)
+}
+
+func isPubKeyHashAddrID(b byte) bool {
+	return b == chaincfg.MainNet.PubKeyHashAddrID || b == chaincfg.TestNet.PubKeyHashAddrID
 }
 
 // NewAddressFromPublicKeyString takes a public key string and returns an Address struct pointer.
 // If mainnet parameter is true it will return a mainnet address (starting with a 1).
 // Otherwise, (mainnet is false) it will return a testnet address (starting with an m or n).
 func NewAddressFromPublicKeyString(pubKey string, mainnet bool) (*Address, error) {
+	return NewAddressFromPublicKeyStringWithParams(pubKey, networkParams(mainnet))
+}
+
+// NewAddressFromPublicKeyStringWithParams takes a public key string and returns an Address
+// struct pointer for the network of the params provided.
+func NewAddressFromPublicKeyStringWithParams(pubKey string, params *chaincfg.Params) (*Address, error) {
 	pubKeyBytes, err := hex.DecodeString(pubKey)
 	if err != nil {
 		return nil, err
 	}
-	return NewAddressFromPublicKeyHash(crypto.Hash160(pubKeyBytes), mainnet)
+	return NewAddressFromPublicKeyHashWithParams(crypto.Hash160(pubKeyBytes), params)
 }
 
 // NewAddressFromPublicKeyHash takes a public key hash in bytes and returns an Address struct pointer.
 // If mainnet parameter is true it will return a mainnet address (starting with a 1).
 // Otherwise, (mainnet is false) it will return a testnet address (starting with an m or n).
 func NewAddressFromPublicKeyHash(hash []byte, mainnet bool) (*Address, error) {
+	return NewAddressFromPublicKeyHashWithParams(hash, networkParams(mainnet))
+}
 
-	// regtest := 111
-	// mainnet: 0
-
-	bb := make([]byte, 1)
-	if !mainnet {
-		bb[0] = 111
-	}
-	//nolint: makezero // we need to set up the array with 1
+// NewAddressFromPublicKeyHashWithParams takes a public key hash in bytes and returns an Address
+// struct pointer for the network of the params provided.
+func NewAddressFromPublicKeyHashWithParams(hash []byte, params *chaincfg.Params) (*Address, error) {
+	bb := make([]byte, 0, len(hash)+1)
+	bb = append(bb, params.PubKeyHashAddrID)
 	bb = append(bb, hash...)
 
 	return &Address{
@@ -97,22 +113,22 @@ func NewAddressFromPublicKeyHash(hash []byte, mainnet bool) (*Address, error) {
 // If mainnet parameter is true it will return a mainnet address (starting with a 1).
 // Otherwise, (mainnet is false) it will return a testnet address (starting with an m or n).
 func NewAddressFromPublicKey(pubKey *bec.PublicKey, mainnet bool) (*Address, error) {
-	hash := crypto.Hash160(pubKey.SerialiseCompressed())
+	return NewAddressFromPublicKeyWithParams(pubKey, networkParams(mainnet))
+}
 
-	// regtest := 111
-	// mainnet: 0
+// NewAddressFromPublicKeyWithParams takes a bec public key and returns an Address
+// struct pointer for the network of the params provided.
+func NewAddressFromPublicKeyWithParams(pubKey *bec.PublicKey, params *chaincfg.Params) (*Address, error) {
+	return NewAddressFromPublicKeyHashWithParams(crypto.Hash160(pubKey.SerialiseCompressed()), params)
+}
 
-	bb := make([]byte, 1)
-	if !mainnet {
-		bb[0] = 111
+// networkParams returns the mainnet params if
+// mainnet is true, otherwise the testnet params.
+func networkParams(mainnet bool) *chaincfg.Params {
+	if mainnet {
+		return &chaincfg.MainNet
 	}
-	//nolint: makezero // we need to set up the array with 1
-	bb = append(bb, hash...)
-
-	return &Address{
-		AddressString: Base58EncodeMissingChecksum(bb),
-		PublicKeyHash: hex.EncodeToString(hash),
-	}, nil
+	return &chaincfg.TestNet
 }
 
 // Base58EncodeMissingChecksum appends a checksum to a byte sequence
@@ -6,6 +6,7 @@ import (
 
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/chaincfg"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -113,3 +114,45 @@ func TestBase58EncodeMissingChecksum(t *testing.T) {
 		bscript.Base58EncodeMissingChecksum(input),
 	)
 }
+
+func TestNewAddressWithParams(t *testing.T) {
+	t.Parallel()
+
+	pkh, err := hex.DecodeString("8fe80c75c9560e8b56ed64ea3c26e18d2c52211b")
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		params  *chaincfg.Params
+		expAddr string
+	}{
+		"mainnet": {
+			params:  &chaincfg.MainNet,
+			expAddr: "1E7ucTTWRTahCyViPhxSMor2pj4VGQdFMr",
+		},
+		"testnet": {
+			params:  &chaincfg.TestNet,
+			expAddr: "mtdruWYVEV1wz5yL7GvpBj4MgifCB7yhPd",
+		},
+		"regtest": {
+			params:  &chaincfg.RegTest,
+			expAddr: "mtdruWYVEV1wz5yL7GvpBj4MgifCB7yhPd",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr, err := bscript.NewAddressFromPublicKeyHashWithParams(pkh, test.params)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expAddr, addr.AddressString)
+
+			addr, err = bscript.NewAddressFromStringWithParams(test.expAddr, test.params)
+			assert.NoError(t, err)
+			assert.Equal(t, hex.EncodeToString(pkh), addr.PublicKeyHash)
+		})
+	}
+
+	t.Run("network mismatch", func(t *testing.T) {
+		_, err := bscript.NewAddressFromStringWithParams("1E7ucTTWRTahCyViPhxSMor2pj4VGQdFMr", &chaincfg.TestNet)
+		assert.ErrorIs(t, err, bscript.ErrAddressNetworkMismatch)
+	})
+}
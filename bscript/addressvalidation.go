@@ -66,7 +66,7 @@ func validA58(a58 []byte) (bool, error) {
 	if err := a.set58(a58); err != nil {
 		return false, err
 	}
-	if a[0] != 0 && a[0] != 0x6f {
+	if !isPubKeyHashAddrID(a[0]) {
 		return false, ErrEncodingInvalidVersion
 	}
 
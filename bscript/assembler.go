@@ -0,0 +1,396 @@
+package bscript
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// AssembleArgs are the named values available when assembling a script.
+//
+// Constants and Params hold typed values, which are appended as follows:
+//   - int, int32, int64, uint32, uint64, ScriptNumber and *big.Int as minimal script numbers
+//   - []byte and string as push data
+//   - Script and *Script as raw script
+type AssembleArgs struct {
+	// Constants are referenced in the source by name, for example PUBKEY_LEN.
+	Constants map[string]interface{}
+	// Macros are ASM fragments referenced in the source by name, which are
+	// assembled in place. Macros can reference other macros.
+	Macros map[string]string
+	// Params are the values filled in for $name placeholders.
+	Params map[string]interface{}
+}
+
+// Assemble assembles the ASM source into a script.
+//
+// On top of opcode names, the source can contain:
+//   - decimal numbers, which are pushed as minimal script numbers, so 0 to 16
+//     and -1 become OP_0 to OP_16 and OP_1NEGATE
+//   - quoted strings, "like this" or 'like this', pushed as UTF-8. Double
+//     quoted strings support Go escape sequences
+//   - 0x prefixed hex, pushed as data
+//   - names of constants and macros provided in args
+//   - $name placeholders, filled in from args.Params
+//   - line comments, starting with # or //
+//
+// Labels aren't supported: script has no jump opcodes, control flow being
+// structured with OP_IF, OP_ELSE and OP_ENDIF, so there is nothing for a
+// label to be the target of. A "name:" token is rejected as invalid.
+//
+// For example:
+//
+//	# pay to pubkey hash
+//	OP_DUP OP_HASH160 $pkh OP_EQUALVERIFY OP_CHECKSIG
+func Assemble(src string, args *AssembleArgs) (*Script, error) {
+	if args == nil {
+		args = &AssembleArgs{}
+	}
+
+	a := &assembler{args: args, s: &Script{}}
+	a.param = func(name string) error {
+		v, ok := args.Params[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrASMMissingParam, name)
+		}
+		return a.appendValue(v)
+	}
+	if err := a.assemble(src); err != nil {
+		return nil, err
+	}
+
+	return a.s, nil
+}
+
+// ASMParams returns the names of the $name placeholders in the ASM source,
+// including those in any macros referenced, in order of first appearance.
+func ASMParams(src string, args *AssembleArgs) ([]string, error) {
+	if args == nil {
+		args = &AssembleArgs{}
+	}
+
+	params := make([]string, 0)
+	a := &assembler{args: args}
+	a.param = func(name string) error {
+		for _, p := range params {
+			if p == name {
+				return nil
+			}
+		}
+		params = append(params, name)
+		return nil
+	}
+	if err := a.assemble(src); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// assembler assembles ASM into s, calling param for each $name
+// placeholder. If s is nil, nothing is appended.
+type assembler struct {
+	args   *AssembleArgs
+	s      *Script
+	param  func(name string) error
+	macros []string
+}
+
+func (a *assembler) assemble(src string) error {
+	tokens, err := tokeniseASM(src)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tokens {
+		if err := a.appendToken(t); err != nil {
+			return fmt.Errorf("line %d: %w", t.line, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *assembler) appendToken(t asmToken) error {
+	if t.quoted {
+		return a.appendValue(t.val)
+	}
+
+	switch tok := t.val; {
+	case strings.HasPrefix(tok, "$"):
+		name := tok[1:]
+		if name == "" {
+			return fmt.Errorf("%w: %s", ErrASMInvalidToken, tok)
+		}
+		return a.param(name)
+	case strings.HasPrefix(tok, "0x"):
+		b, err := hex.DecodeString(tok[2:])
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrASMInvalidToken, tok, err)
+		}
+		return a.appendValue(b)
+	case isDecimal(tok):
+		n, ok := new(big.Int).SetString(tok, 10)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrASMInvalidToken, tok)
+		}
+		return a.appendValue(n)
+	}
+
+	tok := t.val
+	if op, ok := opCodeStrings[tok]; ok {
+		if a.s == nil {
+			return nil
+		}
+		if err := a.s.AppendOpcodes(op); err != nil {
+			return fmt.Errorf("%w: %s", ErrASMInvalidToken, err)
+		}
+		return nil
+	}
+	if v, ok := a.args.Constants[tok]; ok {
+		return a.appendValue(v)
+	}
+	if m, ok := a.args.Macros[tok]; ok {
+		for _, name := range a.macros {
+			if name == tok {
+				return fmt.Errorf("%w: %s", ErrASMMacroCycle, strings.Join(append(a.macros, tok), " -> "))
+			}
+		}
+		a.macros = append(a.macros, tok)
+		defer func() { a.macros = a.macros[:len(a.macros)-1] }()
+		if err := a.assemble(m); err != nil {
+			return fmt.Errorf("macro %s: %w", tok, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrASMInvalidToken, tok)
+}
+
+func (a *assembler) appendValue(v interface{}) error {
+	if a.s == nil {
+		return nil
+	}
+
+	switch v := v.(type) {
+	case int:
+		return a.s.AppendInt(int64(v))
+	case int32:
+		return a.s.AppendInt(int64(v))
+	case int64:
+		return a.s.AppendInt(v)
+	case uint32:
+		return a.s.AppendInt(int64(v))
+	case uint64:
+		if v > math.MaxInt64 {
+			return a.appendValue(new(big.Int).SetUint64(v))
+		}
+		return a.s.AppendInt(int64(v))
+	case ScriptNumber:
+		return a.s.AppendInt(int64(v))
+	case *big.Int:
+		if v.IsInt64() {
+			return a.s.AppendInt(v.Int64())
+		}
+		return a.s.AppendPushData(BigScriptNumberBytes(v))
+	case []byte:
+		return a.s.AppendPushData(v)
+	case string:
+		return a.s.AppendPushDataString(v)
+	case Script:
+		*a.s = append(*a.s, v...)
+		return nil
+	case *Script:
+		*a.s = append(*a.s, *v...)
+		return nil
+	}
+
+	return fmt.Errorf("%w: %T", ErrASMInvalidValue, v)
+}
+
+type asmToken struct {
+	val    string
+	quoted bool
+	line   int
+}
+
+// tokeniseASM splits the ASM source into whitespace separated
+// tokens, dropping comments and unquoting strings.
+func tokeniseASM(src string) ([]asmToken, error) {
+	var tokens []asmToken
+	line := 1
+	for i := 0; i < len(src); {
+		switch c := src[i]; {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '#' || strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			end := i + 1
+			for ; end < len(src) && src[end] != c; end++ {
+				if src[end] == '\\' && c == '"' {
+					end++
+				}
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("line %d: %w", line, ErrASMUnterminatedString)
+			}
+
+			val := src[i+1 : end]
+			if c == '"' {
+				var err error
+				if val, err = strconv.Unquote(src[i : end+1]); err != nil {
+					return nil, fmt.Errorf("line %d: %w: %s", line, ErrASMInvalidToken, err)
+				}
+			}
+			tokens = append(tokens, asmToken{val: val, quoted: true, line: line})
+			line += strings.Count(src[i:end], "\n")
+			i = end + 1
+		default:
+			end := i
+			for end < len(src) && !unicode.IsSpace(rune(src[end])) {
+				end++
+			}
+			tokens = append(tokens, asmToken{val: src[i:end], line: line})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ASMMode the format in which a script is disassembled.
+type ASMMode int
+
+// Supported ASMModes.
+const (
+	// ASMModeNode disassembles as the node does, see ToASM.
+	ASMModeNode ASMMode = iota
+	// ASMModeAssembler disassembles into source which can be read back with
+	// Assemble. Pushes of more than one printable ASCII character are rendered
+	// as quoted strings, small integers and other pushes of up to 4 bytes which
+	// are minimally encoded numbers as decimal and anything else as 0x prefixed hex.
+	//
+	// As Assemble always uses the smallest push, scripts containing non
+	// minimal pushes won't assemble back into the exact same bytes.
+	ASMModeAssembler
+)
+
+// ToASMWithMode returns the ASM of the script in the mode provided.
+func (s *Script) ToASMWithMode(mode ASMMode) (string, error) {
+	if mode == ASMModeNode {
+		return s.ToASM()
+	}
+	if s == nil || len(*s) == 0 {
+		return "", nil
+	}
+
+	var asm []string
+	for b := []byte(*s); len(b) > 0; {
+		op, data, n, err := readOp(b)
+		if err != nil {
+			asm = append(asm, "[error]")
+			break
+		}
+		b = b[n:]
+
+		switch {
+		case op == Op0:
+			asm = append(asm, "0")
+		case op == Op1NEGATE:
+			asm = append(asm, "-1")
+		case op >= Op1 && op <= Op16:
+			asm = append(asm, strconv.Itoa(int(op-Op1+1)))
+		case op > OpPUSHDATA4:
+			asm = append(asm, opCodeValues[op])
+		default:
+			asm = append(asm, pushDataASM(data))
+		}
+	}
+
+	return strings.Join(asm, " "), nil
+}
+
+// pushDataASM renders push data for ASMModeAssembler.
+func pushDataASM(data []byte) string {
+	printable := len(data) > 1
+	for _, c := range data {
+		if c < 0x20 || c > 0x7e {
+			printable = false
+			break
+		}
+	}
+	if printable {
+		return strconv.Quote(string(data))
+	}
+
+	if len(data) <= 4 && CheckMinimalEncoding(data) == nil {
+		if n, err := DecodeScriptNumber(data, true); err == nil && (n < -1 || n > 16) {
+			return strconv.FormatInt(int64(n), 10)
+		}
+	}
+
+	return "0x" + hex.EncodeToString(data)
+}
+
+// readOp reads the next opcode from b, returning the opcode, any data it
+// pushes and the number of bytes read.
+func readOp(b []byte) (byte, []byte, int, error) {
+	if len(b) == 0 {
+		return 0, nil, 0, ErrDataTooSmall
+	}
+
+	op := b[0]
+	var l, n int
+	switch {
+	case op >= OpDATA1 && op <= OpDATA75:
+		l, n = int(op), 1
+	case op == OpPUSHDATA1:
+		if len(b) < 2 {
+			return 0, nil, 0, ErrDataTooSmall
+		}
+		l, n = int(b[1]), 2
+	case op == OpPUSHDATA2:
+		if len(b) < 3 {
+			return 0, nil, 0, ErrDataTooSmall
+		}
+		l, n = int(binary.LittleEndian.Uint16(b[1:])), 3
+	case op == OpPUSHDATA4:
+		if len(b) < 5 {
+			return 0, nil, 0, ErrDataTooSmall
+		}
+		l, n = int(binary.LittleEndian.Uint32(b[1:])), 5
+	default:
+		return op, nil, 1, nil
+	}
+
+	if len(b) < n+l {
+		return 0, nil, 0, ErrDataTooSmall
+	}
+
+	return op, b[n : n+l], n + l, nil
+}
@@ -0,0 +1,52 @@
+package bscript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOp(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		b       []byte
+		expOp   byte
+		expData []byte
+		expN    int
+		expErr  error
+	}{
+		"empty": {
+			b:      []byte{},
+			expErr: ErrDataTooSmall,
+		},
+		"opcode": {
+			b:     []byte{OpDUP, OpHASH160},
+			expOp: OpDUP,
+			expN:  1,
+		},
+		"push data": {
+			b:       []byte{OpDATA2, 0x01, 0x02, OpDUP},
+			expOp:   OpDATA2,
+			expData: []byte{0x01, 0x02},
+			expN:    3,
+		},
+		"push data past the end": {
+			b:      []byte{OpPUSHDATA1, 0x03, 0x01},
+			expErr: ErrDataTooSmall,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			op, data, n, err := readOp(test.b)
+			assert.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.expOp, op)
+			assert.Equal(t, test.expData, data)
+			assert.Equal(t, test.expN, n)
+		})
+	}
+}
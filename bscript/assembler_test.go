@@ -0,0 +1,261 @@
+package bscript_test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+func TestAssemble(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		src    string
+		args   *bscript.AssembleArgs
+		expHex string
+		expErr error
+	}{
+		"opcodes": {
+			src:    "OP_DUP OP_HASH160",
+			expHex: "76a9",
+		},
+		"small numbers use opcodes": {
+			src:    "0 -1 1 16",
+			expHex: "004f5160",
+		},
+		"numbers are minimally pushed": {
+			src:    "17 -2 1000 2147483647",
+			expHex: "0111018202e80304ffffff7f",
+		},
+		"numbers bigger than int64": {
+			src:    "18446744073709551616",
+			expHex: "09000000000000000001",
+		},
+		"hex pushes data": {
+			src:    "0x01 0xbeef 0x",
+			expHex: "010102beef00",
+		},
+		"strings": {
+			src:    `"hello world" 'it''s' "tab\t"`,
+			expHex: "0b68656c6c6f20776f726c6402697401730474616209",
+		},
+		"comments and new lines": {
+			src: `# leading comment
+				OP_1 // trailing comment
+				OP_2 # another
+			`,
+			expHex: "5152",
+		},
+		"constants": {
+			src: "PUBKEY_LEN NAME DATA BIG",
+			args: &bscript.AssembleArgs{Constants: map[string]interface{}{
+				"PUBKEY_LEN": 33,
+				"NAME":       "bob",
+				"DATA":       []byte{0xde, 0xad},
+				"BIG":        new(big.Int).Lsh(big.NewInt(1), 64),
+			}},
+			expHex: "012103626f6202dead09000000000000000001",
+		},
+		"macros": {
+			src: "CHECK_EQUAL 5 OP_ADD",
+			args: &bscript.AssembleArgs{Macros: map[string]string{
+				"CHECK_EQUAL": "2 OP_EQUALVERIFY INNER",
+				"INNER":       "OP_DUP",
+			}},
+			expHex: "5288765593",
+		},
+		"params": {
+			src: "OP_DUP OP_HASH160 $pkh OP_EQUALVERIFY OP_CHECKSIG $script $n",
+			args: &bscript.AssembleArgs{Params: map[string]interface{}{
+				"pkh":    make([]byte, 20),
+				"script": bscript.Script{bscript.OpNOP},
+				"n":      int64(-5),
+			}},
+			expHex: "76a9140000000000000000000000000000000000000000" + "88ac" + "61" + "0185",
+		},
+		"missing param": {
+			src:    "OP_DUP $pkh",
+			expErr: bscript.ErrASMMissingParam,
+		},
+		"unsupported param type": {
+			src:    "$f",
+			args:   &bscript.AssembleArgs{Params: map[string]interface{}{"f": 1.5}},
+			expErr: bscript.ErrASMInvalidValue,
+		},
+		"unknown token": {
+			src:    "OP_DUP NOPE",
+			expErr: bscript.ErrASMInvalidToken,
+		},
+		"bare hex is not accepted": {
+			src:    "beef",
+			expErr: bscript.ErrASMInvalidToken,
+		},
+		"invalid hex": {
+			src:    "0xabc",
+			expErr: bscript.ErrASMInvalidToken,
+		},
+		"push data opcode": {
+			src:    "OP_PUSHDATA1",
+			expErr: bscript.ErrASMInvalidToken,
+		},
+		"unterminated string": {
+			src:    `"hello`,
+			expErr: bscript.ErrASMUnterminatedString,
+		},
+		"labels are not supported": {
+			src:    "start: OP_1",
+			expErr: bscript.ErrASMInvalidToken,
+		},
+		"macro cycle": {
+			src: "A",
+			args: &bscript.AssembleArgs{Macros: map[string]string{
+				"A": "OP_1 B",
+				"B": "A",
+			}},
+			expErr: bscript.ErrASMMacroCycle,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := bscript.Assemble(test.src, test.args)
+			if test.expErr != nil {
+				assert.Error(t, err)
+				assert.True(t, errors.Is(err, test.expErr), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expHex, s.String())
+		})
+	}
+}
+
+func TestASMParams(t *testing.T) {
+	t.Parallel()
+
+	params, err := bscript.ASMParams("$a OP_DUP $b MAC $a # $commented", &bscript.AssembleArgs{
+		Macros: map[string]string{"MAC": "$c OP_DROP"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, params)
+}
+
+func TestScript_ToASMWithMode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		hex    string
+		expASM string
+	}{
+		"empty": {
+			hex:    "",
+			expASM: "",
+		},
+		"p2pkh": {
+			hex:    "76a914e2a623699e81b291c0327f408fea765d534baa2a88ac",
+			expASM: "OP_DUP OP_HASH160 0xe2a623699e81b291c0327f408fea765d534baa2a OP_EQUALVERIFY OP_CHECKSIG",
+		},
+		"small integers": {
+			hex:    "004f5160",
+			expASM: "0 -1 1 16",
+		},
+		"numbers": {
+			hex:    "01110182" + "02e803" + "04ffffff7f",
+			expASM: "17 -2 1000 2147483647",
+		},
+		"strings": {
+			hex:    "006a0b68656c6c6f20776f726c64",
+			expASM: `0 OP_RETURN "hello world"`,
+		},
+		"non minimal numbers are hex": {
+			hex:    "01050201000100",
+			expASM: "0x05 0x0100 0x00",
+		},
+		"truncated push": {
+			hex:    "51020a",
+			expASM: "1 [error]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := bscript.NewFromHexString(test.hex)
+			require.NoError(t, err)
+
+			asm, err := s.ToASMWithMode(bscript.ASMModeAssembler)
+			require.NoError(t, err)
+			assert.Equal(t, test.expASM, asm)
+
+			if test.expASM == "" || test.expASM[len(test.expASM)-1] == ']' {
+				return
+			}
+
+			// Assembler output assembles back into the same script.
+			s2, err := bscript.Assemble(asm, nil)
+			require.NoError(t, err)
+			assert.Equal(t, test.hex, s2.String())
+		})
+	}
+
+	t.Run("node mode matches ToASM", func(t *testing.T) {
+		s, err := bscript.NewFromHexString("76a914e2a623699e81b291c0327f408fea765d534baa2a88ac")
+		require.NoError(t, err)
+
+		exp, err := s.ToASM()
+		require.NoError(t, err)
+		asm, err := s.ToASMWithMode(bscript.ASMModeNode)
+		require.NoError(t, err)
+		assert.Equal(t, exp, asm)
+	})
+}
+
+func TestAssemble_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		src    string
+		expASM string
+	}{
+		"p2pkh": {
+			src:    "OP_DUP OP_HASH160 0x" + strings.Repeat("ab", 20) + " OP_EQUALVERIFY OP_CHECKSIG",
+			expASM: "OP_DUP OP_HASH160 0x" + strings.Repeat("ab", 20) + " OP_EQUALVERIFY OP_CHECKSIG",
+		},
+		"numbers": {
+			src:    "0 -1 16 17 -2 1000 2147483647",
+			expASM: "0 -1 16 17 -2 1000 2147483647",
+		},
+		"printable pushes of up to 4 bytes are strings": {
+			src:    `"ab" "12" "abcd" 'x'`,
+			expASM: `"ab" "12" "abcd" 120`,
+		},
+		"strings with escapes": {
+			src:    `"say \"hi\"" OP_DROP`,
+			expASM: `"say \"hi\"" OP_DROP`,
+		},
+		"binary data": {
+			src:    "0xff00ff00ff 0x0000000000",
+			expASM: "0xff00ff00ff 0x0000000000",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := bscript.Assemble(test.src, nil)
+			require.NoError(t, err)
+
+			asm, err := s.ToASMWithMode(bscript.ASMModeAssembler)
+			require.NoError(t, err)
+			assert.Equal(t, test.expASM, asm)
+
+			s2, err := bscript.Assemble(asm, nil)
+			require.NoError(t, err)
+			assert.Equal(t, s.String(), s2.String())
+		})
+	}
+}
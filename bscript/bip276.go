@@ -7,6 +7,8 @@ import (
 	"strconv"
 
 	"github.com/libsv/go-bk/crypto"
+
+	"github.com/libsv/go-bt/v2/chaincfg"
 )
 
 // BIP276 proposes a scheme for encoding typed bitcoin related data in a user-friendly way
@@ -39,7 +41,18 @@ const NetworkMainnet = 1
 // valid for use on the test network.
 const NetworkTestnet = 2
 
-var validBIP276 = regexp.MustCompile(`^(.+?):(\d{2})(\d{2})([0-9A-Fa-f]+)([0-9A-Fa-f]{8})$`)
+// NewBIP276 returns the BIP276 data for the network of the params
+// provided, using the current version.
+func NewBIP276(prefix string, params *chaincfg.Params, data []byte) BIP276 {
+	return BIP276{
+		Prefix:  prefix,
+		Version: CurrentVersion,
+		Network: params.BIP276Network,
+		Data:    data,
+	}
+}
+
+var validBIP276 = regexp.MustCompile(`^(.+?):([0-9A-Fa-f]{2})([0-9A-Fa-f]{2})([0-9A-Fa-f]+)([0-9A-Fa-f]{8})$`)
 
 // EncodeBIP276 is used to encode specific (non-standard) scripts in BIP276 format.
 // See https://github.com/moneybutton/bips/blob/master/bip-0276.mediawiki
@@ -73,16 +86,17 @@ func DecodeBIP276(text string) (*BIP276, error) {
 		Prefix: res[1],
 	}
 
-	version, err := strconv.Atoi(res[2])
+	// the network is encoded before the version
+	network, err := strconv.ParseUint(res[2], 16, 8)
 	if err != nil {
 		return nil, err
 	}
-	s.Version = version
-	network, err := strconv.Atoi(res[3])
+	s.Network = int(network)
+	version, err := strconv.ParseUint(res[3], 16, 8)
 	if err != nil {
 		return nil, err
 	}
-	s.Network = network
+	s.Version = int(version)
 	data, err := hex.DecodeString(res[4])
 	if err != nil {
 		return nil, err
@@ -94,3 +108,17 @@ func DecodeBIP276(text string) (*BIP276, error) {
 
 	return &s, nil
 }
+
+// DecodeBIP276WithParams is used to decode BIP276 formatted data into specific (non-standard) scripts,
+// returning an ErrBIP276NetworkMismatch if the data isn't for the network of the params provided.
+func DecodeBIP276WithParams(text string, params *chaincfg.Params) (*BIP276, error) {
+	s, err := DecodeBIP276(text)
+	if err != nil {
+		return nil, err
+	}
+	if s.Network != params.BIP276Network {
+		return nil, fmt.Errorf("%w: network %d is not %s", ErrBIP276NetworkMismatch, s.Network, params.Name)
+	}
+
+	return s, nil
+}
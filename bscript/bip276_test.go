@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/chaincfg"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -121,9 +122,46 @@ func TestDecodeBIP276(t *testing.T) {
 		assert.Nil(t, script)
 	})
 
+	// The network is encoded before the version, as by EncodeBIP276, and both
+	// are hex. Before, the version was read first and both as decimal, so these
+	// decoded as version 2 network 1, and failed to decode, respectively.
+	t.Run("network before version", func(t *testing.T) {
+		script, err := bscript.DecodeBIP276("bitcoin-script:020166616b65207363726970742577a444")
+		assert.NoError(t, err)
+		assert.Equal(t, bscript.NetworkTestnet, script.Network)
+		assert.Equal(t, 1, script.Version)
+	})
+
+	t.Run("hex network and version", func(t *testing.T) {
+		script, err := bscript.DecodeBIP276("bitcoin-script:0a1f66616b65207363726970749e85b6db")
+		assert.NoError(t, err)
+		assert.Equal(t, 0x0a, script.Network)
+		assert.Equal(t, 0x1f, script.Version)
+		assert.Equal(t, "fake script", string(script.Data))
+	})
+
 	t.Run("valid format, bad checksum", func(t *testing.T) {
 		script, err := bscript.DecodeBIP276("bitcoin-script:010166616b65207363726970746f0cd8")
 		assert.Error(t, err)
 		assert.Nil(t, script)
 	})
 }
+
+func TestBIP276WithParams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("testnet round trip", func(t *testing.T) {
+		s := bscript.EncodeBIP276(bscript.NewBIP276(bscript.PrefixScript, &chaincfg.TestNet, []byte("fake script")))
+
+		script, err := bscript.DecodeBIP276WithParams(s, &chaincfg.TestNet)
+		assert.NoError(t, err)
+		assert.Equal(t, bscript.NetworkTestnet, script.Network)
+		assert.Equal(t, bscript.CurrentVersion, script.Version)
+		assert.Equal(t, "fake script", string(script.Data))
+	})
+
+	t.Run("network mismatch", func(t *testing.T) {
+		_, err := bscript.DecodeBIP276WithParams("bitcoin-script:010166616b65207363726970746f0cd86a", &chaincfg.TestNet)
+		assert.ErrorIs(t, err, bscript.ErrBIP276NetworkMismatch)
+	})
+}
@@ -11,8 +11,9 @@ var (
 
 // Sentinel errors raised by addresses.
 var (
-	ErrInvalidAddressLength = errors.New("invalid address length")
-	ErrUnsupportedAddress   = errors.New("address not supported")
+	ErrInvalidAddressLength   = errors.New("invalid address length")
+	ErrUnsupportedAddress     = errors.New("address not supported")
+	ErrAddressNetworkMismatch = errors.New("address network mismatch")
 )
 
 // Sentinel errors raised by inscriptions.
@@ -28,6 +29,35 @@ var (
 	ErrEncodingInvalidChecksum = errors.New("invalid checksum")
 	ErrEncodingChecksumFailed  = errors.New("checksum failed")
 	ErrTextNoBIP76             = errors.New("text did not match the bip276 format")
+	ErrBIP276NetworkMismatch   = errors.New("bip276 network mismatch")
+	ErrBIP276NotTemplate       = errors.New("bip276 is not a bitcoin-template")
+)
+
+// Sentinel errors raised by script numbers.
+var (
+	ErrScriptNumberTooBig     = errors.New("script number too big")
+	ErrScriptNumberNotMinimal = errors.New("script number not minimally encoded")
+)
+
+// Sentinel errors raised by the assembler.
+var (
+	ErrASMInvalidToken       = errors.New("invalid asm token")
+	ErrASMInvalidValue       = errors.New("unsupported asm value type")
+	ErrASMUnterminatedString = errors.New("unterminated asm string")
+	ErrASMMissingParam       = errors.New("missing asm param")
+	ErrASMMacroCycle         = errors.New("asm macro cycle")
+)
+
+// Sentinel errors raised by templates.
+var (
+	ErrTemplateInvalidParam   = errors.New("invalid template param")
+	ErrTemplateDuplicateParam = errors.New("duplicate template param")
+	ErrTemplateUnknownParam   = errors.New("unknown template param")
+	ErrTemplateUnusedParam    = errors.New("unused template param")
+	ErrTemplateInvalidData    = errors.New("invalid template data")
+	ErrTemplateMissingArg     = errors.New("missing template arg")
+	ErrTemplateInvalidArg     = errors.New("invalid template arg")
+	ErrTemplateMismatch       = errors.New("script does not match template")
 )
 
 // Sentinel errors raised by the package.
@@ -0,0 +1,319 @@
+// Package analysis statically analyses scripts, reporting mistakes without
+// running them against a transaction.
+//
+// Scripts are checked for balanced conditionals, opcode counts and sizes
+// against the interpreter limits, and disabled or illegal opcodes. Each path
+// through the IF/ELSE branches is then executed abstractly, tracking stack
+// depth and values which are known without a transaction, such as pushed
+// constants, to find the maximum stack depth per branch and the branches
+// which can never succeed.
+//
+// SpendingPaths executes a locking script symbolically, treating the items
+// of the unlocking script as unknown inputs, to find what an unlocking script
+// must provide for each path, such as signatures and hash preimages.
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+)
+
+// ScriptKind the kind of script analysed.
+type ScriptKind int
+
+// Supported ScriptKinds.
+const (
+	Unlocking ScriptKind = iota
+	Locking
+)
+
+// String returns the name of the script kind.
+func (k ScriptKind) String() string {
+	if k == Unlocking {
+		return "unlocking"
+	}
+	return "locking"
+}
+
+// Report the result of analysing a script, or a pair of scripts.
+type Report struct {
+	// Scripts the report of each script analysed, unlocking first.
+	Scripts []ScriptReport
+	// Branches every path through the conditionals analysed.
+	Branches []Branch
+	// Issues found, in the order they were found.
+	Issues []Issue
+	// MaxStackDepth the maximum combined depth of the data and alt
+	// stacks over all branches.
+	MaxStackDepth int
+	// RequiredInputs is the maximum number of stack items a locking
+	// script consumes from its unlocking script over all branches,
+	// when analysed without an unlocking script.
+	RequiredInputs int
+	// Unspendable is true if the scripts can never be executed
+	// successfully, either as they break a consensus rule, or
+	// as every branch fails.
+	Unspendable bool
+}
+
+// ScriptReport the static properties of a script.
+type ScriptReport struct {
+	Kind ScriptKind
+	// Size of the script in bytes.
+	Size int
+	// NumOpcodes is the number of opcodes in the script.
+	NumOpcodes int
+	// NumOps is the number of non push opcodes, which
+	// count towards the max ops limit.
+	NumOps int
+	// PushOnly is true if the script contains only push opcodes.
+	PushOnly bool
+}
+
+// Branch a path of execution through the conditionals of the scripts.
+type Branch struct {
+	// Path is the outcome of each decision made, in order of execution:
+	// whether the first block of an OP_IF or OP_NOTIF was executed, or
+	// whether OP_IFDUP duplicated an item which isn't known.
+	Path []bool
+	// MaxStackDepth the maximum combined depth of the data and alt
+	// stacks on this branch.
+	MaxStackDepth int
+	// RequiredInputs is the number of stack items consumed from the
+	// unlocking script on this branch, when analysing a locking script
+	// on its own.
+	RequiredInputs int
+	// Exact is false if the stack depth depends on values which aren't
+	// known without running the script, such as the number of keys of an
+	// OP_CHECKMULTISIG, in which case MaxStackDepth is a best effort.
+	Exact bool
+	// Err is the reason the branch always fails, nil if it can succeed.
+	Err error
+	// Script and OpcodeIdx locate the opcode the branch fails on.
+	Script    ScriptKind
+	OpcodeIdx int
+}
+
+// Issue a problem found with a script.
+type Issue struct {
+	Script    ScriptKind
+	OpcodeIdx int
+	// Err the problem found, errs.Error for broken script rules.
+	Err error
+	// Policy is true if the issue only breaks policy, rather than consensus,
+	// rules and so the script can still be mined.
+	Policy bool
+}
+
+// Error returns the issue as a string.
+func (i Issue) Error() string {
+	return fmt.Sprintf("%s script opcode %d: %s", i.Script, i.OpcodeIdx, i.Err)
+}
+
+// Unwrap returns the underlying error.
+func (i Issue) Unwrap() error {
+	return i.Err
+}
+
+// AnalyzeLockingScript analyses a locking script on its own, treating the
+// stack items provided by the unlocking script as unknown values.
+func AnalyzeLockingScript(s *bscript.Script, oo ...OptionFunc) (*Report, error) {
+	ps, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return AnalyzeParsed(nil, ps, oo...)
+}
+
+// AnalyzeUnlockingScript analyses an unlocking script on its own.
+func AnalyzeUnlockingScript(s *bscript.Script, oo ...OptionFunc) (*Report, error) {
+	ps, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return AnalyzeParsed(ps, nil, oo...)
+}
+
+// AnalyzePair analyses an unlocking script together with the locking script
+// it spends.
+func AnalyzePair(unlocking, locking *bscript.Script, oo ...OptionFunc) (*Report, error) {
+	ups, err := parse(unlocking)
+	if err != nil {
+		return nil, err
+	}
+	lps, err := parse(locking)
+	if err != nil {
+		return nil, err
+	}
+
+	return AnalyzeParsed(ups, lps, oo...)
+}
+
+// AnalyzeParsed analyses parsed scripts. Either script can be nil to analyse
+// the other on its own.
+func AnalyzeParsed(unlocking, locking interpreter.ParsedScript, oo ...OptionFunc) (*Report, error) {
+	o := newOpts(oo)
+	a := &analyzer{opts: o, limits: o.limits(), report: &Report{}}
+	if unlocking != nil {
+		a.scripts = append(a.scripts, script{kind: Unlocking, ps: unlocking})
+	}
+	if locking != nil {
+		a.scripts = append(a.scripts, script{kind: Locking, ps: locking})
+	}
+	if len(a.scripts) == 0 {
+		return nil, ErrNoScripts
+	}
+
+	for _, s := range a.scripts {
+		if err := a.checkScript(s); err != nil {
+			return nil, err
+		}
+	}
+	a.runBranches()
+
+	r := a.report
+	allFail := len(r.Branches) > 0
+	for _, b := range r.Branches {
+		if b.MaxStackDepth > r.MaxStackDepth {
+			r.MaxStackDepth = b.MaxStackDepth
+		}
+		if b.RequiredInputs > r.RequiredInputs {
+			r.RequiredInputs = b.RequiredInputs
+		}
+		if b.Err == nil {
+			allFail = false
+		}
+	}
+	if allFail && !a.truncated && !a.tooDeep {
+		r.Unspendable = true
+	}
+
+	return r, nil
+}
+
+type script struct {
+	kind ScriptKind
+	ps   interpreter.ParsedScript
+}
+
+type analyzer struct {
+	opts      *opts
+	limits    interpreter.Limits
+	scripts   []script
+	report    *Report
+	truncated bool
+	tooDeep   bool
+}
+
+func parse(s *bscript.Script) (interpreter.ParsedScript, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	return (&interpreter.DefaultOpcodeParser{}).Parse(s)
+}
+
+// issue records an issue. Consensus issues make the scripts unspendable.
+func (a *analyzer) issue(s script, idx int, policy bool, err error) {
+	a.report.Issues = append(a.report.Issues, Issue{
+		Script:    s.kind,
+		OpcodeIdx: idx,
+		Err:       err,
+		Policy:    policy,
+	})
+	if !policy {
+		a.report.Unspendable = true
+	}
+}
+
+// checkScript checks the static properties of the script, which
+// apply regardless of the branches executed.
+func (a *analyzer) checkScript(s script) error {
+	b, err := (&interpreter.DefaultOpcodeParser{}).Unparse(s.ps)
+	if err != nil {
+		return err
+	}
+
+	sr := ScriptReport{
+		Kind:       s.kind,
+		Size:       len(*b),
+		NumOpcodes: len(s.ps),
+		PushOnly:   s.ps.IsPushOnly(),
+	}
+	if sr.Size > a.limits.MaxScriptSize {
+		a.issue(s, 0, false, errs.NewError(errs.ErrScriptTooBig,
+			"script size %d is larger than max allowed size %d", sr.Size, a.limits.MaxScriptSize))
+	}
+	if s.kind == Unlocking && !sr.PushOnly {
+		a.issue(s, 0, !a.opts.afterGenesis, errs.NewError(errs.ErrNotPushOnly,
+			"unlocking script is not push only"))
+	}
+
+	// Each entry is the number of OP_ELSE seen for an open conditional.
+	var conds []int
+	for i, pop := range s.ps {
+		op := pop.Value()
+		if op > bscript.Op16 {
+			sr.NumOps++
+			if sr.NumOps == a.limits.MaxOps+1 {
+				a.issue(s, i, false, errs.NewError(errs.ErrTooManyOperations,
+					"exceeded max operation limit of %d", a.limits.MaxOps))
+			}
+		}
+		if len(pop.Data) > a.limits.MaxScriptElementSize {
+			a.issue(s, i, false, errs.NewError(errs.ErrElementTooBig,
+				"element size %d exceeds max allowed size %d", len(pop.Data), a.limits.MaxScriptElementSize))
+		}
+		if !a.opts.afterGenesis {
+			if pop.IsDisabled() {
+				a.issue(s, i, false, errs.NewError(errs.ErrDisabledOpcode,
+					"disabled opcode %s", pop.Name()))
+			}
+			if pop.AlwaysIllegal() {
+				a.issue(s, i, false, errs.NewError(errs.ErrReservedOpcode,
+					"reserved opcode %s", pop.Name()))
+			}
+		}
+
+		switch op {
+		case bscript.OpIF, bscript.OpNOTIF:
+			conds = append(conds, 0)
+		case bscript.OpELSE:
+			if len(conds) == 0 {
+				a.issue(s, i, false, errs.NewError(errs.ErrUnbalancedConditional,
+					"%s with no matching opcode to begin conditional execution", pop.Name()))
+				continue
+			}
+			conds[len(conds)-1]++
+			if a.opts.afterGenesis && conds[len(conds)-1] > 1 {
+				a.issue(s, i, false, errs.NewError(errs.ErrUnbalancedConditional,
+					"more than one %s in conditional", pop.Name()))
+			}
+		case bscript.OpENDIF:
+			if len(conds) == 0 {
+				a.issue(s, i, false, errs.NewError(errs.ErrUnbalancedConditional,
+					"%s with no matching opcode to begin conditional execution", pop.Name()))
+				continue
+			}
+			conds = conds[:len(conds)-1]
+		case bscript.OpRETURN:
+			// Anything after a top level OP_RETURN is never parsed.
+			if len(conds) == 0 {
+				a.report.Scripts = append(a.report.Scripts, sr)
+				return nil
+			}
+		}
+	}
+	if len(conds) > 0 {
+		a.issue(s, len(s.ps)-1, false, errs.NewError(errs.ErrUnbalancedConditional,
+			"end of script reached in conditional execution"))
+	}
+
+	a.report.Scripts = append(a.report.Scripts, sr)
+	return nil
+}
@@ -0,0 +1,415 @@
+package analysis_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/analysis"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+)
+
+func asm(t *testing.T, src string) *bscript.Script {
+	s, err := bscript.Assemble(src, nil)
+	require.NoError(t, err)
+	return s
+}
+
+func TestAnalyzeLockingScript(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		asm            string
+		opts           []analysis.OptionFunc
+		expBranches    int
+		expFailing     int
+		expMaxDepth    int
+		expRequired    int
+		expUnspendable bool
+		expIssues      []errs.ErrorCode
+	}{
+		"p2pkh": {
+			asm:         "OP_DUP OP_HASH160 0x0000000000000000000000000000000000000000 OP_EQUALVERIFY OP_CHECKSIG",
+			expBranches: 1,
+			expMaxDepth: 4,
+			expRequired: 2,
+		},
+		"if else": {
+			asm:         "OP_IF 1 2 3 OP_2DROP OP_ELSE 1 OP_ENDIF",
+			expBranches: 2,
+			expMaxDepth: 3,
+			expRequired: 1,
+		},
+		"nested if": {
+			asm:         "OP_IF OP_IF 1 OP_ELSE 2 OP_ENDIF OP_ELSE 3 OP_ENDIF",
+			expBranches: 3,
+			expMaxDepth: 2,
+			expRequired: 2,
+		},
+		"known condition only takes one branch": {
+			asm:         "0 OP_IF 1 2 3 OP_ELSE 1 OP_ENDIF",
+			expBranches: 1,
+			expMaxDepth: 1,
+		},
+		"false return prefix": {
+			asm:            `0 OP_RETURN "data"`,
+			opts:           []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expBranches:    1,
+			expFailing:     1,
+			expMaxDepth:    1,
+			expUnspendable: true,
+		},
+		"return before genesis": {
+			asm:            `OP_RETURN "data"`,
+			expBranches:    1,
+			expFailing:     1,
+			expUnspendable: true,
+		},
+		"always false verify": {
+			asm:            "OP_DROP 1 2 OP_EQUALVERIFY 1",
+			expBranches:    1,
+			expFailing:     1,
+			expMaxDepth:    2,
+			expRequired:    1,
+			expUnspendable: true,
+		},
+		"only one branch fails": {
+			asm:         "OP_IF 0 OP_VERIFY OP_ENDIF 1",
+			expBranches: 2,
+			expFailing:  1,
+			expMaxDepth: 1,
+			expRequired: 1,
+		},
+		"unbalanced if": {
+			asm:            "OP_IF 1",
+			expBranches:    2,
+			expFailing:     2,
+			expMaxDepth:    1,
+			expRequired:    1,
+			expUnspendable: true,
+			expIssues:      []errs.ErrorCode{errs.ErrUnbalancedConditional},
+		},
+		"endif without if": {
+			asm:            "1 OP_ENDIF",
+			expBranches:    1,
+			expFailing:     1,
+			expMaxDepth:    1,
+			expUnspendable: true,
+			expIssues:      []errs.ErrorCode{errs.ErrUnbalancedConditional},
+		},
+		"two else after genesis": {
+			asm:            "OP_IF 1 OP_ELSE 2 OP_ELSE 3 OP_ENDIF",
+			opts:           []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expBranches:    2,
+			expFailing:     2,
+			expMaxDepth:    1,
+			expRequired:    1,
+			expUnspendable: true,
+			expIssues:      []errs.ErrorCode{errs.ErrUnbalancedConditional},
+		},
+		"disabled opcode before genesis in unexecuted branch": {
+			asm:            "0 OP_IF OP_2MUL OP_ENDIF 1",
+			expBranches:    1,
+			expFailing:     1,
+			expMaxDepth:    1,
+			expUnspendable: true,
+			expIssues:      []errs.ErrorCode{errs.ErrDisabledOpcode},
+		},
+		"disabled opcode after genesis in unexecuted branch": {
+			asm:         "0 OP_IF OP_2MUL OP_ENDIF 1",
+			opts:        []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expBranches: 1,
+			expMaxDepth: 1,
+		},
+		"reserved opcode": {
+			asm:            "OP_IF OP_RESERVED OP_ENDIF 1",
+			expBranches:    2,
+			expFailing:     1,
+			expMaxDepth:    1,
+			expRequired:    1,
+			expUnspendable: false,
+		},
+		"too many ops": {
+			asm:            "1 " + repeat("OP_NOP ", 501),
+			expBranches:    1,
+			expMaxDepth:    1,
+			expUnspendable: true,
+			expIssues:      []errs.ErrorCode{errs.ErrTooManyOperations},
+		},
+		"multisig": {
+			asm:         "2 0x00 0x01 0x02 3 OP_CHECKMULTISIG",
+			expBranches: 1,
+			expMaxDepth: 8,
+			expRequired: 3,
+		},
+		"pick": {
+			asm:         "1 2 3 2 OP_PICK",
+			expBranches: 1,
+			expMaxDepth: 4,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := analysis.AnalyzeLockingScript(asm(t, test.asm), test.opts...)
+			require.NoError(t, err)
+
+			failing := 0
+			for _, b := range r.Branches {
+				if b.Err != nil {
+					failing++
+				}
+			}
+			assert.Len(t, r.Branches, test.expBranches)
+			assert.Equal(t, test.expFailing, failing)
+			assert.Equal(t, test.expMaxDepth, r.MaxStackDepth)
+			assert.Equal(t, test.expRequired, r.RequiredInputs)
+			assert.Equal(t, test.expUnspendable, r.Unspendable)
+
+			codes := make([]errs.ErrorCode, 0)
+			for _, i := range r.Issues {
+				e := &errs.Error{}
+				require.True(t, errors.As(i, e), i)
+				codes = append(codes, e.ErrorCode)
+			}
+			if test.expIssues == nil {
+				test.expIssues = []errs.ErrorCode{}
+			}
+			assert.Equal(t, test.expIssues, codes)
+		})
+	}
+}
+
+func TestAnalyzeLockingScript_Branches(t *testing.T) {
+	t.Parallel()
+
+	r, err := analysis.AnalyzeLockingScript(asm(t, "OP_IF 0 OP_VERIFY OP_ELSE OP_NOTIF 1 2 OP_ENDIF OP_ENDIF 1"))
+	require.NoError(t, err)
+	require.Len(t, r.Branches, 3)
+
+	b := r.Branches[0]
+	assert.Equal(t, []bool{true}, b.Path)
+	assert.True(t, errs.IsErrorCode(b.Err, errs.ErrVerify))
+	assert.Equal(t, analysis.Locking, b.Script)
+	assert.Equal(t, 2, b.OpcodeIdx)
+
+	for _, b := range r.Branches[1:] {
+		assert.NoError(t, b.Err)
+		assert.True(t, b.Exact)
+	}
+	assert.Equal(t, []bool{false, false}, r.Branches[1].Path)
+	assert.Equal(t, 2, r.Branches[1].MaxStackDepth)
+	assert.Equal(t, []bool{false, true}, r.Branches[2].Path)
+	assert.Equal(t, 2, r.Branches[2].RequiredInputs)
+	assert.Equal(t, 3, r.Branches[2].MaxStackDepth)
+}
+
+func TestAnalyzeLockingScript_IfDup(t *testing.T) {
+	t.Parallel()
+
+	r, err := analysis.AnalyzeLockingScript(asm(t, "OP_IFDUP OP_DEPTH OP_DROP"))
+	require.NoError(t, err)
+	require.Len(t, r.Branches, 2)
+
+	assert.Equal(t, []bool{true}, r.Branches[0].Path)
+	assert.Equal(t, 3, r.Branches[0].MaxStackDepth)
+	assert.Equal(t, []bool{false}, r.Branches[1].Path)
+	assert.Equal(t, 2, r.Branches[1].MaxStackDepth)
+	for _, b := range r.Branches {
+		assert.True(t, b.Exact)
+	}
+}
+
+func TestAnalyzeUnlockingScript(t *testing.T) {
+	t.Parallel()
+
+	r, err := analysis.AnalyzeUnlockingScript(asm(t, `0x3044 0x02`))
+	require.NoError(t, err)
+	assert.False(t, r.Unspendable)
+	assert.Empty(t, r.Issues)
+	require.Len(t, r.Scripts, 1)
+	assert.Equal(t, analysis.ScriptReport{Kind: analysis.Unlocking, Size: 5, NumOpcodes: 2, PushOnly: true}, r.Scripts[0])
+	assert.Equal(t, 2, r.MaxStackDepth)
+
+	t.Run("not push only is policy before genesis", func(t *testing.T) {
+		r, err := analysis.AnalyzeUnlockingScript(asm(t, "1 OP_DUP"))
+		require.NoError(t, err)
+		require.Len(t, r.Issues, 1)
+		assert.True(t, errs.IsErrorCode(r.Issues[0], errs.ErrNotPushOnly))
+		assert.True(t, r.Issues[0].Policy)
+		assert.False(t, r.Unspendable)
+	})
+
+	t.Run("not push only is consensus after genesis", func(t *testing.T) {
+		r, err := analysis.AnalyzeUnlockingScript(asm(t, "1 OP_DUP"), analysis.WithAfterGenesis())
+		require.NoError(t, err)
+		require.Len(t, r.Issues, 1)
+		assert.False(t, r.Issues[0].Policy)
+		assert.True(t, r.Unspendable)
+	})
+
+	t.Run("underflow", func(t *testing.T) {
+		r, err := analysis.AnalyzeUnlockingScript(asm(t, "OP_DROP"))
+		require.NoError(t, err)
+		require.Len(t, r.Branches, 1)
+		assert.True(t, errs.IsErrorCode(r.Branches[0].Err, errs.ErrInvalidStackOperation))
+		assert.True(t, r.Unspendable)
+	})
+}
+
+func TestAnalyzePair(t *testing.T) {
+	t.Parallel()
+
+	locking := asm(t, "OP_IF 2 OP_EQUAL OP_ELSE 3 OP_EQUAL OP_ENDIF")
+
+	tests := map[string]struct {
+		unlocking      string
+		expPath        []bool
+		expUnspendable bool
+		expErr         errs.ErrorCode
+	}{
+		"first branch": {
+			unlocking: "2 1",
+			expPath:   []bool{true},
+		},
+		"second branch": {
+			unlocking: "3 0",
+			expPath:   []bool{false},
+		},
+		"wrong value": {
+			unlocking:      "3 1",
+			expPath:        []bool{true},
+			expUnspendable: true,
+			expErr:         errs.ErrEvalFalse,
+		},
+		"missing item": {
+			unlocking:      "1",
+			expPath:        []bool{true},
+			expUnspendable: true,
+			expErr:         errs.ErrInvalidStackOperation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := analysis.AnalyzePair(asm(t, test.unlocking), locking)
+			require.NoError(t, err)
+			require.Len(t, r.Branches, 1)
+			assert.Equal(t, test.expPath, r.Branches[0].Path)
+			assert.Equal(t, test.expUnspendable, r.Unspendable)
+			assert.Zero(t, r.RequiredInputs)
+			if test.expUnspendable {
+				assert.True(t, errs.IsErrorCode(r.Branches[0].Err, test.expErr), r.Branches[0].Err)
+				return
+			}
+			assert.NoError(t, r.Branches[0].Err)
+		})
+	}
+}
+
+func TestAnalyzeParsed(t *testing.T) {
+	t.Parallel()
+
+	_, err := analysis.AnalyzeParsed(nil, nil)
+	assert.True(t, errors.Is(err, analysis.ErrNoScripts))
+
+	ps, err := (&interpreter.DefaultOpcodeParser{}).Parse(asm(t, "OP_IF 1 OP_ELSE 2 OP_ENDIF OP_DROP OP_IF 1 OP_ELSE 2 OP_ENDIF"))
+	require.NoError(t, err)
+
+	r, err := analysis.AnalyzeParsed(nil, ps)
+	require.NoError(t, err)
+	assert.Len(t, r.Branches, 4)
+
+	r, err = analysis.AnalyzeParsed(nil, ps, analysis.WithMaxBranches(3))
+	require.NoError(t, err)
+	assert.Len(t, r.Branches, 3)
+	require.Len(t, r.Issues, 1)
+	assert.True(t, errors.Is(r.Issues[0], analysis.ErrTooManyBranches))
+}
+
+func TestAnalyze_WithConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := interpreter.ConsensusConfig
+	cfg.BeforeGenesis.MaxStackSize = 2
+
+	r, err := analysis.AnalyzeLockingScript(asm(t, "1 2 3 OP_2DROP"), analysis.WithConfig(cfg))
+	require.NoError(t, err)
+	require.Len(t, r.Branches, 1)
+	assert.True(t, errs.IsErrorCode(r.Branches[0].Err, errs.ErrStackOverflow))
+	assert.True(t, r.Unspendable)
+}
+
+func repeat(s string, n int) string {
+	out := ""
+	for i := 0; i < n; i++ {
+		out += s
+	}
+	return out
+}
+
+func TestAnalyzeLockingScript_DeepStack(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		asm            string
+		opts           []analysis.OptionFunc
+		expErr         error
+		expCode        errs.ErrorCode
+		expUnspendable bool
+	}{
+		"pick beyond max stack size": {
+			asm:            "0xffffff7f OP_PICK",
+			expCode:        errs.ErrStackOverflow,
+			expUnspendable: true,
+		},
+		"pick beyond max depth": {
+			asm:    "0xffffff3f OP_PICK",
+			opts:   []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expErr: analysis.ErrStackTooDeep,
+		},
+		"roll of max int64": {
+			asm:            "0xffffffffffffff7f OP_ROLL",
+			opts:           []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expCode:        errs.ErrStackOverflow,
+			expUnspendable: true,
+		},
+		"multisig beyond max depth": {
+			asm:    "0x0d8e8c68 OP_CHECKMULTISIG",
+			opts:   []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expErr: analysis.ErrStackTooDeep,
+		},
+		"pick within configured depth": {
+			asm:  "10 OP_PICK",
+			opts: []analysis.OptionFunc{analysis.WithAfterGenesis(), analysis.WithMaxStackDepth(11)},
+		},
+		"pick beyond configured depth": {
+			asm:    "11 OP_PICK",
+			opts:   []analysis.OptionFunc{analysis.WithAfterGenesis(), analysis.WithMaxStackDepth(11)},
+			expErr: analysis.ErrStackTooDeep,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := analysis.AnalyzeLockingScript(asm(t, test.asm), test.opts...)
+			require.NoError(t, err)
+			require.Len(t, r.Branches, 1)
+			assert.Equal(t, test.expUnspendable, r.Unspendable)
+
+			err = r.Branches[0].Err
+			switch {
+			case test.expErr != nil:
+				assert.True(t, errors.Is(err, test.expErr), err)
+			case test.expCode != 0:
+				assert.True(t, errs.IsErrorCode(err, test.expCode), err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package analysis
+
+import "github.com/pkg/errors"
+
+// Sentinel errors raised by the analyzer.
+var (
+	ErrNoScripts        = errors.New("no scripts to analyse")
+	ErrTooManyBranches  = errors.New("too many branches, analysis is incomplete")
+	ErrInexactStackSize = errors.New("stack size depends on runtime values")
+	ErrUnknownIndex     = errors.New("stack index depends on unlocking script values")
+	ErrStackTooDeep     = errors.New("stack is deeper than the max depth analysed")
+)
@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// ExprKind the kind of a symbolic expression.
+type ExprKind int
+
+// Supported ExprKinds.
+const (
+	// ExprConst is a value known without the unlocking script.
+	ExprConst ExprKind = iota
+	// ExprInput is an item provided by the unlocking script.
+	ExprInput
+	// ExprOp is the result of an opcode applied to other expressions.
+	ExprOp
+)
+
+// Expr a symbolic stack item. Exprs are immutable and may be shared
+// between spending paths.
+type Expr struct {
+	Kind ExprKind
+	// Data the value of an ExprConst.
+	Data []byte
+	// Input the position of an ExprInput on the stack left by the
+	// unlocking script, 0 being the top, which is the last item pushed.
+	Input int
+	// Op the name of the opcode of an ExprOp, and Args its operands in
+	// the order they were pushed.
+	Op   string
+	Args []*Expr
+	// Output is the index of the result, for opcodes which push more
+	// than one item, such as OP_SPLIT.
+	Output int
+}
+
+func constExpr(b []byte) *Expr {
+	return &Expr{Kind: ExprConst, Data: b}
+}
+
+func boolExpr(b bool) *Expr {
+	if b {
+		return constExpr([]byte{1})
+	}
+	return constExpr([]byte{})
+}
+
+func intExpr(n int64) *Expr {
+	return constExpr(bscript.ScriptNumber(n).Bytes())
+}
+
+// IsConst returns true if the value is known without the unlocking script.
+func (e *Expr) IsConst() bool {
+	return e.Kind == ExprConst
+}
+
+// Inputs returns the positions of the unlocking script items the
+// expression depends on, in the order they are first used.
+func (e *Expr) Inputs() []int {
+	var ii []int
+	seen := map[int]bool{}
+	var walk func(e *Expr)
+	walk = func(e *Expr) {
+		switch e.Kind {
+		case ExprInput:
+			if !seen[e.Input] {
+				seen[e.Input] = true
+				ii = append(ii, e.Input)
+			}
+		case ExprOp:
+			for _, a := range e.Args {
+				walk(a)
+			}
+		}
+	}
+	walk(e)
+
+	return ii
+}
+
+// String returns the expression, such as OP_HASH160(input[0]). Constants of
+// up to 4 bytes which are minimally encoded numbers are shown as decimals,
+// all others as hex.
+func (e *Expr) String() string {
+	switch e.Kind {
+	case ExprConst:
+		if len(e.Data) == 0 {
+			return "0"
+		}
+		if len(e.Data) <= 4 && bscript.CheckMinimalEncoding(e.Data) == nil {
+			n, _ := bscript.DecodeScriptNumber(e.Data, true)
+			return fmt.Sprintf("%d", n)
+		}
+		return "0x" + hex.EncodeToString(e.Data)
+	case ExprInput:
+		return fmt.Sprintf("input[%d]", e.Input)
+	}
+
+	args := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		args[i] = a.String()
+	}
+	name := e.Op
+	if e.Output > 0 {
+		name = fmt.Sprintf("%s[%d]", e.Op, e.Output)
+	}
+
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
+
+// MarshalJSON marshals the expression as {"const":"<hex>"}, {"input":n}
+// or {"op":"OP_X","args":[...]}.
+func (e *Expr) MarshalJSON() ([]byte, error) {
+	switch e.Kind {
+	case ExprConst:
+		return json.Marshal(struct {
+			Const string `json:"const"`
+		}{hex.EncodeToString(e.Data)})
+	case ExprInput:
+		return json.Marshal(struct {
+			Input int `json:"input"`
+		}{e.Input})
+	}
+
+	return json.Marshal(struct {
+		Op     string  `json:"op"`
+		Args   []*Expr `json:"args"`
+		Output int     `json:"output,omitempty"`
+	}{e.Op, e.Args, e.Output})
+}
+
+// Constraint an expression which must evaluate to true, or false if False
+// is set, for the spending path to be taken.
+type Constraint struct {
+	Expr  *Expr `json:"expr"`
+	False bool  `json:"false,omitempty"`
+}
+
+// String returns the constraint.
+func (c Constraint) String() string {
+	if c.False {
+		return "not " + c.Expr.String()
+	}
+
+	return c.Expr.String()
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+)
+
+// constants is the value domain of the analyzer, where the values are
+// only known if they were derived from constants.
+type constants struct {
+	// inexact is set if the stack depth depends on unknown values.
+	inexact bool
+}
+
+func (d *constants) clone() domain {
+	c := *d
+	return &c
+}
+
+// apply returns unknown values, without arguments, for anything that
+// can't be folded, as the analyzer doesn't need to know how they were
+// computed.
+func (d *constants) apply(op byte, name string, args []*Expr) []*Expr {
+	if e := foldConst(op, args); e != nil {
+		return []*Expr{e}
+	}
+	return opExprs(op, name, nil)
+}
+
+func (d *constants) assume(*Expr, bool) {}
+
+// unknownIndex carries on with a best effort stack depth.
+func (d *constants) unknownIndex(string) error {
+	d.inexact = true
+	return nil
+}
+
+// machine abstractly executes a single branch of the scripts.
+type machine struct {
+	s *stepper
+
+	// maxRel is the max depth relative to the unknown number of
+	// items pulled from beneath the stack.
+	maxRel    int
+	scriptIdx int
+}
+
+// runBranches executes every branch of the scripts.
+func (a *analyzer) runBranches() {
+	pending := []*machine{{
+		s: newStepper(a.opts, &constants{}, a.scripts[0].kind == Locking),
+	}}
+	started := 1
+	for len(pending) > 0 {
+		m := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		fork := func(f *stepper) {
+			if started >= a.opts.maxBranches {
+				if !a.truncated {
+					a.truncated = true
+					a.issue(a.scripts[m.scriptIdx], m.s.opcodeIdx, true, ErrTooManyBranches)
+				}
+				return
+			}
+			started++
+			pending = append(pending, &machine{s: f, maxRel: m.maxRel, scriptIdx: m.scriptIdx})
+		}
+
+		b := a.run(m, fork)
+		// A branch deeper than analysed may still succeed,
+		// so the scripts can't be reported unspendable.
+		if errors.Is(b.Err, ErrStackTooDeep) {
+			a.tooDeep = true
+		}
+		if !b.Exact && b.Err == nil {
+			a.issue(a.scripts[m.scriptIdx], m.s.opcodeIdx, true, ErrInexactStackSize)
+		}
+		a.report.Branches = append(a.report.Branches, b)
+	}
+}
+
+// run executes the machine until the end of the scripts or failure,
+// calling fork with a copy of its stepper for each decision made on an
+// unknown value, and returns the branch taken.
+func (a *analyzer) run(m *machine, fork func(*stepper)) Branch {
+	s := m.s
+	err := a.runScripts(m, fork)
+	b := Branch{
+		Path:           s.path,
+		MaxStackDepth:  m.maxRel + s.inputs,
+		RequiredInputs: s.inputs,
+		Exact:          !s.d.(*constants).inexact,
+		Err:            err,
+	}
+	if err != nil {
+		b.Script = a.scripts[m.scriptIdx].kind
+		b.OpcodeIdx = s.opcodeIdx
+	}
+
+	return b
+}
+
+func (a *analyzer) runScripts(m *machine, fork func(*stepper)) error {
+	s := m.s
+	check := func() error {
+		depth := len(s.stack) + len(s.alt)
+		if rel := depth - s.inputs; rel > m.maxRel {
+			m.maxRel = rel
+		}
+		if depth > a.limits.MaxStackSize {
+			return errs.NewError(errs.ErrStackOverflow,
+				"combined stack size %d > max allowed %d", depth, a.limits.MaxStackSize)
+		}
+		return nil
+	}
+
+	for ; m.scriptIdx < len(a.scripts); m.scriptIdx++ {
+		if err := s.run(a.scripts[m.scriptIdx].ps, fork, check); err != nil {
+			return err
+		}
+		s.alt = nil
+		s.earlyReturn = false
+		s.opcodeIdx = 0
+	}
+	m.scriptIdx = len(a.scripts) - 1
+	s.opcodeIdx = len(a.scripts[m.scriptIdx].ps) - 1
+
+	// Only the locking script needs to leave a true value on the stack.
+	if a.scripts[m.scriptIdx].kind != Locking {
+		return nil
+	}
+	return s.finish()
+}
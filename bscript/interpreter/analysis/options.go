@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+)
+
+// DefaultMaxBranches is the default maximum number of branches analysed.
+const DefaultMaxBranches = 1024
+
+// DefaultMaxStackDepth is the default maximum stack depth analysed.
+const DefaultMaxStackDepth = 1 << 16
+
+// OptionFunc for setting analysis options.
+type OptionFunc func(o *opts)
+
+type opts struct {
+	afterGenesis bool
+	cfg          *interpreter.Config
+	maxBranches  int
+	maxDepth     int
+}
+
+func newOpts(oo []OptionFunc) *opts {
+	o := &opts{maxBranches: DefaultMaxBranches, maxDepth: DefaultMaxStackDepth}
+	for _, opt := range oo {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithAfterGenesis configure the analysis to apply the after-genesis rules.
+func WithAfterGenesis() OptionFunc {
+	return func(o *opts) {
+		o.afterGenesis = true
+	}
+}
+
+// WithConfig configure the analysis to check against the limits of the
+// config provided, rather than interpreter.ConsensusConfig.
+func WithConfig(cfg interpreter.Config) OptionFunc {
+	return func(o *opts) {
+		o.cfg = &cfg
+	}
+}
+
+// WithMaxBranches configure the maximum number of branches analysed, after
+// which ErrTooManyBranches is reported and the remaining branches skipped,
+// or returned by SpendingPaths.
+func WithMaxBranches(n int) OptionFunc {
+	return func(o *opts) {
+		o.maxBranches = n
+	}
+}
+
+// WithMaxStackDepth configure the maximum stack depth analysed, after which
+// a branch fails with ErrStackTooDeep. The limits after genesis don't bound
+// the stack size, so this bounds the items analysed, for example when a
+// locking script picks an item from deep in the stack.
+func WithMaxStackDepth(n int) OptionFunc {
+	return func(o *opts) {
+		o.maxDepth = n
+	}
+}
+
+// checkDepth fails if a stack of n items exceeds
+// the max stack size, or the max depth analysed.
+func (o *opts) checkDepth(n uint64) error {
+	if max := o.limits().MaxStackSize; n > uint64(max) {
+		return errs.NewError(errs.ErrStackOverflow, "stack size %d > max allowed %d", n, max)
+	}
+	if n > uint64(o.maxDepth) {
+		return errors.Wrapf(ErrStackTooDeep, "stack size %d > max analysed %d", n, o.maxDepth)
+	}
+
+	return nil
+}
+
+func (o *opts) limits() interpreter.Limits {
+	cfg := o.cfg
+	if cfg == nil {
+		cfg = &interpreter.ConsensusConfig
+	}
+	if o.afterGenesis {
+		return cfg.AfterGenesis
+	}
+
+	return cfg.BeforeGenesis
+}
@@ -0,0 +1,585 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+)
+
+// domain computes the values on the stacks of a stepper. Constant values
+// are known to every domain, the others depend on what the domain tracks.
+type domain interface {
+	// clone returns a copy of the domain for a forked path.
+	clone() domain
+	// apply returns the items pushed by an opcode applied to its
+	// arguments, given in the order they were pushed.
+	apply(op byte, name string, args []*Expr) []*Expr
+	// assume records that the path continues as if e is b.
+	assume(e *Expr, b bool)
+	// unknownIndex is called when the number of items an opcode uses isn't
+	// known. If nil is returned, the stepper carries on with a best effort
+	// stack, otherwise the path fails with the error.
+	unknownIndex(what string) error
+}
+
+// condFrame is an open conditional.
+type condFrame struct {
+	exec  bool
+	skip  bool
+	elses int
+}
+
+// stepper executes a single path through scripts, handling the control flow
+// and the shape of the stacks, while the values are computed by its domain.
+type stepper struct {
+	stack []*Expr
+	alt   []*Expr
+	conds []condFrame
+
+	// open is true if items can be pulled from beneath the stack, when
+	// analysing a locking script on its own, inputs being the number of
+	// items pulled.
+	open   bool
+	inputs int
+
+	// path is the outcome of each decision made, in order of execution.
+	path        []bool
+	earlyReturn bool
+	opcodeIdx   int
+
+	o *opts
+	d domain
+}
+
+func newStepper(o *opts, d domain, open bool) *stepper {
+	return &stepper{o: o, d: d, open: open}
+}
+
+func (s *stepper) clone() *stepper {
+	c := *s
+	c.stack = append([]*Expr{}, s.stack...)
+	c.alt = append([]*Expr{}, s.alt...)
+	c.conds = append([]condFrame{}, s.conds...)
+	c.path = append([]bool{}, s.path...)
+	c.d = s.d.clone()
+	return &c
+}
+
+func (s *stepper) executing() bool {
+	for _, c := range s.conds {
+		if !c.exec {
+			return false
+		}
+	}
+	return true
+}
+
+// ensure makes sure there are at least n items on the stack, pulling
+// inputs from beneath it if it is open, the deepest having the highest
+// position.
+func (s *stepper) ensure(n int) error {
+	missing := n - len(s.stack)
+	if missing <= 0 {
+		return nil
+	}
+	if !s.open {
+		return errs.NewError(errs.ErrInvalidStackOperation, "stack underflow")
+	}
+
+	pulled := make([]*Expr, missing, n)
+	for i := range pulled {
+		pulled[i] = &Expr{Kind: ExprInput, Input: s.inputs + missing - 1 - i}
+	}
+	s.stack = append(pulled, s.stack...)
+	s.inputs += missing
+	return nil
+}
+
+// top returns the top item of the stack, which must be ensured.
+func (s *stepper) top() *Expr {
+	return s.stack[len(s.stack)-1]
+}
+
+// pop pops the top item of the stack, which must be ensured.
+func (s *stepper) pop() *Expr {
+	e := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return e
+}
+
+// popN pops n items, returning them in the order they were pushed.
+func (s *stepper) popN(n int) ([]*Expr, error) {
+	if err := s.ensure(n); err != nil {
+		return nil, err
+	}
+	ee := append([]*Expr{}, s.stack[len(s.stack)-n:]...)
+	s.stack = s.stack[:len(s.stack)-n]
+	return ee, nil
+}
+
+func (s *stepper) push(ee ...*Expr) {
+	s.stack = append(s.stack, ee...)
+}
+
+// permute replaces the top n items of the stack with the items at the
+// indices in order, where 0 is the deepest of the n items.
+func (s *stepper) permute(n int, order ...int) error {
+	top, err := s.popN(n)
+	if err != nil {
+		return err
+	}
+	for _, i := range order {
+		s.push(top[i])
+	}
+	return nil
+}
+
+// require fails with err if the expression is known to be false, or
+// else assumes it is true.
+func (s *stepper) require(e *Expr, err error) error {
+	if e.IsConst() {
+		if !asBool(e.Data) {
+			return err
+		}
+		return nil
+	}
+
+	s.d.assume(e, true)
+	return nil
+}
+
+// decide returns the boolean value of the expression if it is known, or
+// else continues as if it is true and returns a fork continuing as if it
+// is false, which is to be passed to resume once its path is recorded.
+func (s *stepper) decide(e *Expr) (bool, *stepper) {
+	if e.IsConst() {
+		return asBool(e.Data), nil
+	}
+
+	f := s.clone()
+	f.d.assume(e, false)
+	s.d.assume(e, true)
+	return true, f
+}
+
+// resume hands the fork to be executed from the opcode after the current.
+func (s *stepper) resume(f *stepper, fork func(*stepper)) {
+	f.opcodeIdx = s.opcodeIdx + 1
+	fork(f)
+}
+
+// run executes the script from the current opcode until the end or failure,
+// calling fork with a copy of the stepper for each decision made on an
+// unknown value, and check, if not nil, after each opcode.
+func (s *stepper) run(ps interpreter.ParsedScript, fork func(*stepper), check func() error) error {
+	for ; s.opcodeIdx < len(ps); s.opcodeIdx++ {
+		done, err := s.step(ps[s.opcodeIdx], fork)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(); err != nil {
+				return err
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	if len(s.conds) > 0 {
+		return errs.NewError(errs.ErrUnbalancedConditional, "end of script reached in conditional execution")
+	}
+	return nil
+}
+
+// finish checks the stack is left with a true value at the end of the scripts.
+func (s *stepper) finish() error {
+	if err := s.ensure(1); err != nil {
+		return errs.NewError(errs.ErrEmptyStack, "stack empty at end of script execution")
+	}
+
+	return s.require(s.pop(), errs.NewError(errs.ErrEvalFalse, "false stack entry at end of script execution"))
+}
+
+// step executes a single opcode, returning true if the script ended early.
+func (s *stepper) step(pop interpreter.ParsedOpcode, fork func(*stepper)) (bool, error) {
+	op := pop.Value()
+	exec := s.executing() && (!s.earlyReturn || op == bscript.OpRETURN)
+
+	switch op {
+	case bscript.OpIF, bscript.OpNOTIF:
+		if !exec {
+			s.conds = append(s.conds, condFrame{skip: true})
+			return false, nil
+		}
+		if err := s.ensure(1); err != nil {
+			return false, err
+		}
+		// The path records whether the first block was executed.
+		first := op == bscript.OpIF
+		b, f := s.decide(s.pop())
+		if f != nil {
+			f.conds = append(f.conds, condFrame{exec: !first})
+			f.path = append(f.path, !first)
+			s.resume(f, fork)
+		}
+		s.conds = append(s.conds, condFrame{exec: b == first})
+		s.path = append(s.path, b == first)
+		return false, nil
+	case bscript.OpELSE:
+		if len(s.conds) == 0 {
+			return false, errs.NewError(errs.ErrUnbalancedConditional, "%s with no matching opcode", pop.Name())
+		}
+		c := &s.conds[len(s.conds)-1]
+		if c.elses++; c.elses > 1 && s.o.afterGenesis {
+			return false, errs.NewError(errs.ErrUnbalancedConditional, "more than one %s in conditional", pop.Name())
+		}
+		if !c.skip {
+			c.exec = !c.exec
+		}
+		return false, nil
+	case bscript.OpENDIF:
+		if len(s.conds) == 0 {
+			return false, errs.NewError(errs.ErrUnbalancedConditional, "%s with no matching opcode", pop.Name())
+		}
+		s.conds = s.conds[:len(s.conds)-1]
+		return false, nil
+	}
+
+	if pop.AlwaysIllegal() && !s.o.afterGenesis {
+		return false, errs.NewError(errs.ErrReservedOpcode, "attempt to execute reserved opcode %s", pop.Name())
+	}
+	if pop.IsDisabled() && !s.o.afterGenesis {
+		return false, errs.NewError(errs.ErrDisabledOpcode, "attempt to execute disabled opcode %s", pop.Name())
+	}
+	if !exec {
+		return false, nil
+	}
+	if pop.IsDisabled() {
+		return false, errs.NewError(errs.ErrDisabledOpcode, "attempt to execute disabled opcode %s", pop.Name())
+	}
+
+	switch {
+	case op == bscript.Op0:
+		s.push(constExpr([]byte{}))
+		return false, nil
+	case op <= bscript.OpPUSHDATA4:
+		s.push(constExpr(pop.Data))
+		return false, nil
+	case op == bscript.Op1NEGATE:
+		s.push(intExpr(-1))
+		return false, nil
+	case op >= bscript.Op1 && op <= bscript.Op16:
+		s.push(intExpr(int64(op - bscript.Op1 + 1)))
+		return false, nil
+	}
+
+	switch op {
+	case bscript.OpRETURN:
+		if !s.o.afterGenesis {
+			return false, errs.NewError(errs.ErrEarlyReturn, "script returned early")
+		}
+		s.earlyReturn = true
+		return len(s.conds) == 0, nil
+	case bscript.OpNOP, bscript.OpNOP1, bscript.OpNOP4, bscript.OpNOP5, bscript.OpNOP6,
+		bscript.OpNOP7, bscript.OpNOP8, bscript.OpNOP9, bscript.OpNOP10, bscript.OpCODESEPARATOR:
+		return false, nil
+	case bscript.OpCHECKLOCKTIMEVERIFY, bscript.OpCHECKSEQUENCEVERIFY:
+		// These are NOPs after genesis, before they check the
+		// top stack item against the transaction, without popping it.
+		if s.o.afterGenesis {
+			return false, nil
+		}
+		if err := s.ensure(1); err != nil {
+			return false, err
+		}
+		s.d.assume(s.d.apply(op, pop.Name(), []*Expr{s.top()})[0], true)
+		return false, nil
+	case bscript.OpVERIFY:
+		if err := s.ensure(1); err != nil {
+			return false, err
+		}
+		return false, s.require(s.pop(), errs.NewError(errs.ErrVerify, "%s always fails", pop.Name()))
+	case bscript.OpTOALTSTACK:
+		if err := s.ensure(1); err != nil {
+			return false, err
+		}
+		s.alt = append(s.alt, s.pop())
+		return false, nil
+	case bscript.OpFROMALTSTACK:
+		if len(s.alt) == 0 {
+			return false, errs.NewError(errs.ErrInvalidStackOperation, "alt stack underflow")
+		}
+		s.push(s.alt[len(s.alt)-1])
+		s.alt = s.alt[:len(s.alt)-1]
+		return false, nil
+	case bscript.OpDROP:
+		return false, s.permute(1)
+	case bscript.Op2DROP:
+		return false, s.permute(2)
+	case bscript.OpDUP:
+		return false, s.permute(1, 0, 0)
+	case bscript.Op2DUP:
+		return false, s.permute(2, 0, 1, 0, 1)
+	case bscript.Op3DUP:
+		return false, s.permute(3, 0, 1, 2, 0, 1, 2)
+	case bscript.OpOVER:
+		return false, s.permute(2, 0, 1, 0)
+	case bscript.Op2OVER:
+		return false, s.permute(4, 0, 1, 2, 3, 0, 1)
+	case bscript.OpSWAP:
+		return false, s.permute(2, 1, 0)
+	case bscript.Op2SWAP:
+		return false, s.permute(4, 2, 3, 0, 1)
+	case bscript.OpROT:
+		return false, s.permute(3, 1, 2, 0)
+	case bscript.Op2ROT:
+		return false, s.permute(6, 2, 3, 4, 5, 0, 1)
+	case bscript.OpNIP:
+		return false, s.permute(2, 1)
+	case bscript.OpTUCK:
+		return false, s.permute(2, 1, 0, 1)
+	case bscript.OpIFDUP:
+		if err := s.ensure(1); err != nil {
+			return false, err
+		}
+		top := s.top()
+		b, f := s.decide(top)
+		if f != nil {
+			f.path = append(f.path, false)
+			s.path = append(s.path, true)
+			s.resume(f, fork)
+		}
+		if b {
+			s.push(top)
+		}
+		return false, nil
+	case bscript.OpDEPTH:
+		// The depth depends on the number of inputs when the stack is open.
+		if s.open {
+			s.push(s.d.apply(op, pop.Name(), nil)...)
+			return false, nil
+		}
+		s.push(intExpr(int64(len(s.stack))))
+		return false, nil
+	case bscript.OpSIZE:
+		if err := s.ensure(1); err != nil {
+			return false, err
+		}
+		s.push(s.d.apply(op, pop.Name(), []*Expr{s.top()})...)
+		return false, nil
+	case bscript.OpPICK, bscript.OpROLL:
+		return false, s.pickRoll(op, pop.Name())
+	case bscript.OpEQUALVERIFY, bscript.OpNUMEQUALVERIFY, bscript.OpCHECKSIGVERIFY:
+		args, err := s.popN(2)
+		if err != nil {
+			return false, err
+		}
+		v := verifyOps[op]
+		e := s.d.apply(v.op, strings.TrimSuffix(pop.Name(), "VERIFY"), args)[0]
+		return false, s.require(e, errs.NewError(v.code, "%s always fails", pop.Name()))
+	case bscript.OpCHECKMULTISIG, bscript.OpCHECKMULTISIGVERIFY:
+		return false, s.checkMultiSig(op == bscript.OpCHECKMULTISIGVERIFY)
+	}
+
+	e, ok := opcodeEffects[op]
+	if !ok {
+		// Everything else is reserved or invalid.
+		return false, errs.NewError(errs.ErrReservedOpcode, "attempt to execute reserved opcode %s", pop.Name())
+	}
+	args, err := s.popN(e[0])
+	if err != nil {
+		return false, err
+	}
+	s.push(s.d.apply(op, pop.Name(), args)...)
+	return false, nil
+}
+
+func (s *stepper) pickRoll(op byte, name string) error {
+	if err := s.ensure(1); err != nil {
+		return err
+	}
+	idx := s.pop()
+	n, ok := constInt(idx)
+	if !ok {
+		if err := s.d.unknownIndex(name); err != nil {
+			return err
+		}
+		// The item picked is unknown, as is the item rolled, so
+		// the stack depth stays the same but values are lost.
+		v := s.d.apply(op, name, []*Expr{idx})[0]
+		if op == bscript.OpPICK {
+			s.push(v)
+			return nil
+		}
+		for i := range s.stack {
+			s.stack[i] = v
+		}
+		return nil
+	}
+	if n < 0 {
+		return errs.NewError(errs.ErrInvalidStackOperation, "%s index %d is negative", name, n)
+	}
+	// The index is bounded before pulling inputs beneath the stack.
+	if err := s.o.checkDepth(uint64(n) + 1); err != nil {
+		return err
+	}
+	if err := s.ensure(int(n) + 1); err != nil {
+		return err
+	}
+
+	i := len(s.stack) - 1 - int(n)
+	v := s.stack[i]
+	if op == bscript.OpROLL {
+		s.stack = append(s.stack[:i], s.stack[i+1:]...)
+	}
+	s.push(v)
+	return nil
+}
+
+func (s *stepper) checkMultiSig(verify bool) error {
+	if err := s.ensure(1); err != nil {
+		return err
+	}
+	nKeys, ok := constInt(s.pop())
+	if !ok {
+		return s.unknownCount(verify, "OP_CHECKMULTISIG pubkey count")
+	}
+	if nKeys < 0 || nKeys > int64(s.o.limits().MaxPubKeysPerMultiSig) {
+		return errs.NewError(errs.ErrInvalidPubKeyCount, "invalid pubkey count %d", nKeys)
+	}
+	// The keys, their count and the signature count.
+	if err := s.o.checkDepth(uint64(nKeys) + 2); err != nil {
+		return err
+	}
+	keys, err := s.popN(int(nKeys))
+	if err != nil {
+		return err
+	}
+	if err = s.ensure(1); err != nil {
+		return err
+	}
+	nSigsExpr := s.pop()
+	nSigs, ok := constInt(nSigsExpr)
+	if !ok {
+		return s.unknownCount(verify, "OP_CHECKMULTISIG signature count")
+	}
+	if nSigs < 0 || nSigs > nKeys {
+		return errs.NewError(errs.ErrInvalidSignatureCount, "invalid signature count %d", nSigs)
+	}
+	// The signatures, plus the extra dummy item.
+	sigs, err := s.popN(int(nSigs) + 1)
+	if err != nil {
+		return err
+	}
+
+	e := boolExpr(true)
+	if nSigs > 0 {
+		args := append(sigs, nSigsExpr)
+		args = append(args, keys...)
+		args = append(args, intExpr(nKeys))
+		e = s.d.apply(bscript.OpCHECKMULTISIG, "OP_CHECKMULTISIG", args)[0]
+	}
+	if verify {
+		return s.require(e, errs.NewError(errs.ErrCheckMultiSigVerify, "OP_CHECKMULTISIGVERIFY always fails"))
+	}
+	s.push(e)
+	return nil
+}
+
+// unknownCount carries on from an OP_CHECKMULTISIG consuming an unknown
+// number of items, if the domain allows it, pushing an unknown result.
+func (s *stepper) unknownCount(verify bool, what string) error {
+	if err := s.d.unknownIndex(what); err != nil {
+		return err
+	}
+	if !verify {
+		s.push(s.d.apply(bscript.OpCHECKMULTISIG, "OP_CHECKMULTISIG", nil)...)
+	}
+	return nil
+}
+
+// asBool returns the boolean value of a stack item, mirroring the interpreter,
+// where any non zero value, other than negative zero, is true.
+func asBool(b []byte) bool {
+	for i := range b {
+		if b[i] != 0 {
+			// Negative 0 is also considered false.
+			if i == len(b)-1 && b[i] == 0x80 {
+				return false
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// constInt returns the value of a constant which is a number.
+func constInt(e *Expr) (int64, bool) {
+	if !e.IsConst() || len(e.Data) > bscript.MaxScriptNumberInt64Len {
+		return 0, false
+	}
+	n, err := bscript.DecodeScriptNumber(e.Data, false)
+	return int64(n), err == nil
+}
+
+// verifyOps are the opcodes which verify the result of another, and the
+// error raised if the result is false.
+var verifyOps = map[byte]struct {
+	op   byte
+	code errs.ErrorCode
+}{
+	bscript.OpEQUALVERIFY:    {bscript.OpEQUAL, errs.ErrEqualVerify},
+	bscript.OpNUMEQUALVERIFY: {bscript.OpNUMEQUAL, errs.ErrNumEqualVerify},
+	bscript.OpCHECKSIGVERIFY: {bscript.OpCHECKSIG, errs.ErrCheckSigVerify},
+}
+
+// opcodeEffects are the number of items popped and pushed by the opcodes
+// which only compute values, without special handling by the stepper.
+var opcodeEffects = map[byte][2]int{
+	bscript.OpCAT:                {2, 1},
+	bscript.OpSPLIT:              {2, 2},
+	bscript.OpNUM2BIN:            {2, 1},
+	bscript.OpBIN2NUM:            {1, 1},
+	bscript.OpINVERT:             {1, 1},
+	bscript.OpAND:                {2, 1},
+	bscript.OpOR:                 {2, 1},
+	bscript.OpXOR:                {2, 1},
+	bscript.OpEQUAL:              {2, 1},
+	bscript.Op1ADD:               {1, 1},
+	bscript.Op1SUB:               {1, 1},
+	bscript.Op2MUL:               {1, 1},
+	bscript.Op2DIV:               {1, 1},
+	bscript.OpNEGATE:             {1, 1},
+	bscript.OpABS:                {1, 1},
+	bscript.OpNOT:                {1, 1},
+	bscript.Op0NOTEQUAL:          {1, 1},
+	bscript.OpADD:                {2, 1},
+	bscript.OpSUB:                {2, 1},
+	bscript.OpMUL:                {2, 1},
+	bscript.OpDIV:                {2, 1},
+	bscript.OpMOD:                {2, 1},
+	bscript.OpLSHIFT:             {2, 1},
+	bscript.OpRSHIFT:             {2, 1},
+	bscript.OpBOOLAND:            {2, 1},
+	bscript.OpBOOLOR:             {2, 1},
+	bscript.OpNUMEQUAL:           {2, 1},
+	bscript.OpNUMNOTEQUAL:        {2, 1},
+	bscript.OpLESSTHAN:           {2, 1},
+	bscript.OpGREATERTHAN:        {2, 1},
+	bscript.OpLESSTHANOREQUAL:    {2, 1},
+	bscript.OpGREATERTHANOREQUAL: {2, 1},
+	bscript.OpMIN:                {2, 1},
+	bscript.OpMAX:                {2, 1},
+	bscript.OpWITHIN:             {3, 1},
+	bscript.OpRIPEMD160:          {1, 1},
+	bscript.OpSHA1:               {1, 1},
+	bscript.OpSHA256:             {1, 1},
+	bscript.OpHASH160:            {1, 1},
+	bscript.OpHASH256:            {1, 1},
+	bscript.OpCHECKSIG:           {2, 1},
+}
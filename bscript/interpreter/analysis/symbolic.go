@@ -0,0 +1,192 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// SpendingPath a way of spending a locking script, found by executing it
+// symbolically with the items of the unlocking script as unknown inputs.
+type SpendingPath struct {
+	// Path is the outcome of each decision made on an input, in order of
+	// execution: whether the first block of an OP_IF or OP_NOTIF was
+	// executed, or whether OP_IFDUP duplicated its item.
+	Path []bool `json:"path"`
+	// Inputs is the number of items the unlocking script must push.
+	Inputs int `json:"inputs"`
+	// Constraints every constraint on the inputs for the path to
+	// be taken and the script to succeed.
+	Constraints []Constraint `json:"constraints"`
+	// Signatures, MultiSigs, Preimages, LockTime and Sequence are the
+	// common constraints recognised, for convenience.
+	Signatures []Signature `json:"signatures,omitempty"`
+	MultiSigs  []MultiSig  `json:"multiSigs,omitempty"`
+	Preimages  []Preimage  `json:"preimages,omitempty"`
+	LockTime   *Expr       `json:"lockTime,omitempty"`
+	Sequence   *Expr       `json:"sequence,omitempty"`
+	// Err is set if the path can never succeed, or couldn't be executed
+	// symbolically, in which case the constraints are incomplete.
+	Err error `json:"-"`
+}
+
+// Signature a signature the unlocking script must provide.
+type Signature struct {
+	// Input is the position of the signature in the unlocking script.
+	Input  int   `json:"input"`
+	PubKey *Expr `json:"pubKey"`
+}
+
+// MultiSig the signatures the unlocking script must provide for
+// an OP_CHECKMULTISIG.
+type MultiSig struct {
+	Required int     `json:"required"`
+	Inputs   []int   `json:"inputs"`
+	PubKeys  []*Expr `json:"pubKeys"`
+}
+
+// Preimage a hash preimage the unlocking script must provide.
+type Preimage struct {
+	Input int `json:"input"`
+	// Hash is the name of the hashing opcode, such as OP_SHA256.
+	Hash   string `json:"hash"`
+	Digest []byte `json:"digest"`
+}
+
+// MarshalJSON marshals the path, including the error as a string.
+func (p SpendingPath) MarshalJSON() ([]byte, error) {
+	type path SpendingPath
+	var e string
+	if p.Err != nil {
+		e = p.Err.Error()
+	}
+
+	return json.Marshal(struct {
+		path
+		Error string `json:"error,omitempty"`
+	}{path(p), e})
+}
+
+// String returns the path as a human readable list of constraints.
+func (p SpendingPath) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "path %v: %d inputs", p.Path, p.Inputs)
+	if p.Err != nil {
+		fmt.Fprintf(&sb, ", fails: %s", p.Err)
+	}
+	for _, c := range p.Constraints {
+		fmt.Fprintf(&sb, "\n  %s", c)
+	}
+
+	return sb.String()
+}
+
+// SpendingPaths executes a locking script symbolically, returning every
+// path through its conditionals with the constraints on the unlocking
+// script to take it. Paths which always fail are returned with Err set.
+//
+// The static rules of the script, such as its size and opcode count, are not
+// checked. Use AnalyzeLockingScript for those.
+func SpendingPaths(s *bscript.Script, oo ...OptionFunc) ([]SpendingPath, error) {
+	ps, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return SpendingPathsParsed(ps, oo...)
+}
+
+// SpendingPathsParsed executes a parsed locking script symbolically,
+// see SpendingPaths. ErrTooManyBranches is returned if the script has
+// more paths than the configured max branches.
+func SpendingPathsParsed(ps interpreter.ParsedScript, oo ...OptionFunc) ([]SpendingPath, error) {
+	o := newOpts(oo)
+	pending := []*stepper{newStepper(o, &symbols{}, true)}
+	var paths []SpendingPath
+	for len(pending) > 0 {
+		s := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		var forked []*stepper
+		err := s.run(ps, func(f *stepper) {
+			forked = append(forked, f)
+		}, nil)
+		if err == nil {
+			err = s.finish()
+		}
+		if len(paths)+len(pending)+len(forked)+1 > o.maxBranches {
+			return nil, ErrTooManyBranches
+		}
+		pending = append(pending, forked...)
+
+		p := SpendingPath{
+			Path:        s.path,
+			Inputs:      s.inputs,
+			Constraints: s.d.(*symbols).constraints,
+			Err:         err,
+		}
+		p.summarise()
+		paths = append(paths, p)
+	}
+
+	return paths, nil
+}
+
+// summarise fills in the common constraints.
+func (p *SpendingPath) summarise() {
+	for _, c := range p.Constraints {
+		if c.False || c.Expr.Kind != ExprOp {
+			continue
+		}
+
+		e := c.Expr
+		switch e.Op {
+		case "OP_CHECKSIG":
+			if sig := e.Args[0]; sig.Kind == ExprInput {
+				p.Signatures = append(p.Signatures, Signature{Input: sig.Input, PubKey: e.Args[1]})
+			}
+		case "OP_CHECKMULTISIG":
+			// The args are the dummy, the signatures, their count,
+			// the public keys, then their count.
+			nKeys, _ := bscript.DecodeScriptNumber(e.Args[len(e.Args)-1].Data, false)
+			keys := e.Args[len(e.Args)-1-int(nKeys) : len(e.Args)-1]
+			sigs := e.Args[1 : len(e.Args)-2-int(nKeys)]
+			ms := MultiSig{Required: len(sigs), PubKeys: keys}
+			for _, s := range sigs {
+				if s.Kind == ExprInput {
+					ms.Inputs = append(ms.Inputs, s.Input)
+				}
+			}
+			p.MultiSigs = append(p.MultiSigs, ms)
+		case "OP_EQUAL", "OP_NUMEQUAL":
+			if pi, ok := preimage(e.Args[0], e.Args[1]); ok {
+				p.Preimages = append(p.Preimages, pi)
+			} else if pi, ok := preimage(e.Args[1], e.Args[0]); ok {
+				p.Preimages = append(p.Preimages, pi)
+			}
+		case "OP_CHECKLOCKTIMEVERIFY":
+			if p.LockTime == nil {
+				p.LockTime = e.Args[0]
+			}
+		case "OP_CHECKSEQUENCEVERIFY":
+			if p.Sequence == nil {
+				p.Sequence = e.Args[0]
+			}
+		}
+	}
+}
+
+func preimage(hash, digest *Expr) (Preimage, bool) {
+	if hash.Kind != ExprOp || digest.Kind != ExprConst || hash.Args[0].Kind != ExprInput {
+		return Preimage{}, false
+	}
+	switch hash.Op {
+	case "OP_RIPEMD160", "OP_SHA1", "OP_SHA256", "OP_HASH160", "OP_HASH256":
+		return Preimage{Input: hash.Args[0].Input, Hash: hash.Op, Digest: digest.Data}, true
+	}
+
+	return Preimage{}, false
+}
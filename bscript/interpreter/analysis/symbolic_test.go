@@ -0,0 +1,267 @@
+package analysis_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter/analysis"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+)
+
+const (
+	testPubKey = "0x02b4632d08485ff1df2db55b9dafd23347d1c47a457072a1e87be26896549a8737"
+	testHash   = "0x0000000000000000000000000000000000000000"
+)
+
+func constraints(p analysis.SpendingPath) []string {
+	cc := make([]string, len(p.Constraints))
+	for i, c := range p.Constraints {
+		cc[i] = c.String()
+	}
+	return cc
+}
+
+func TestSpendingPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		asm            string
+		opts           []analysis.OptionFunc
+		expPaths       [][]bool
+		expInputs      []int
+		expConstraints [][]string
+		expErrs        []errs.ErrorCode
+	}{
+		"p2pkh": {
+			asm:       "OP_DUP OP_HASH160 " + testHash + " OP_EQUALVERIFY OP_CHECKSIG",
+			expPaths:  [][]bool{nil},
+			expInputs: []int{2},
+			expConstraints: [][]string{{
+				"OP_EQUAL(OP_HASH160(input[0]), " + testHash + ")",
+				"OP_CHECKSIG(input[1], input[0])",
+			}},
+		},
+		"p2pk": {
+			asm:            testPubKey + " OP_CHECKSIG",
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{1},
+			expConstraints: [][]string{{"OP_CHECKSIG(input[0], " + testPubKey + ")"}},
+		},
+		"anyone can spend": {
+			asm:            "1",
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{0},
+			expConstraints: [][]string{{}},
+		},
+		"empty": {
+			asm:            "",
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{1},
+			expConstraints: [][]string{{"input[0]"}},
+		},
+		"hash lock or timeout": {
+			asm: "OP_IF OP_SHA256 " + testHash + " OP_EQUALVERIFY OP_ELSE 500000 OP_NOP2 OP_DROP OP_ENDIF " +
+				testPubKey + " OP_CHECKSIG",
+			expPaths:  [][]bool{{true}, {false}},
+			expInputs: []int{3, 2},
+			expConstraints: [][]string{{
+				"input[0]",
+				"OP_EQUAL(OP_SHA256(input[1]), " + testHash + ")",
+				"OP_CHECKSIG(input[2], " + testPubKey + ")",
+			}, {
+				"not input[0]",
+				"OP_CHECKLOCKTIMEVERIFY(500000)",
+				"OP_CHECKSIG(input[1], " + testPubKey + ")",
+			}},
+		},
+		"notif records first block": {
+			asm:            "OP_NOTIF 1 OP_ELSE 0 OP_ENDIF",
+			expPaths:       [][]bool{{false}, {true}},
+			expInputs:      []int{1, 1},
+			expConstraints: [][]string{{"input[0]"}, {"not input[0]"}},
+			expErrs:        []errs.ErrorCode{errs.ErrEvalFalse, 0},
+		},
+		"constants are folded": {
+			asm:            "2 3 OP_ADD 5 OP_NUMEQUALVERIFY OP_SIZE 4 OP_EQUAL",
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{1},
+			expConstraints: [][]string{{"OP_EQUAL(OP_SIZE(input[0]), 4)"}},
+		},
+		"known false fails": {
+			asm:            "1 2 OP_EQUALVERIFY",
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{0},
+			expConstraints: [][]string{{}},
+			expErrs:        []errs.ErrorCode{errs.ErrEqualVerify},
+		},
+		"ifdup": {
+			asm:            "OP_IFDUP OP_DROP",
+			expPaths:       [][]bool{{true}, {false}},
+			expInputs:      []int{1, 2},
+			expConstraints: [][]string{{"input[0]", "input[0]"}, {"not input[0]", "input[1]"}},
+		},
+		"multisig": {
+			asm:       "2 " + testPubKey + " " + testPubKey + " 2 OP_CHECKMULTISIG",
+			expPaths:  [][]bool{nil},
+			expInputs: []int{3},
+			expConstraints: [][]string{{
+				"OP_CHECKMULTISIG(input[2], input[1], input[0], 2, " + testPubKey + ", " + testPubKey + ", 2)",
+			}},
+		},
+		"split has two outputs": {
+			asm:            "OP_SPLIT OP_DROP",
+			opts:           []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{2},
+			expConstraints: [][]string{{"OP_SPLIT(input[1], input[0])"}},
+		},
+		"return after genesis": {
+			asm:            `OP_CHECKSIG OP_RETURN "data"`,
+			opts:           []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{2},
+			expConstraints: [][]string{{"OP_CHECKSIG(input[1], input[0])"}},
+		},
+		"return before genesis": {
+			asm:            `OP_CHECKSIG OP_RETURN "data"`,
+			expPaths:       [][]bool{nil},
+			expInputs:      []int{2},
+			expConstraints: [][]string{{}},
+			expErrs:        []errs.ErrorCode{errs.ErrEarlyReturn},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pp, err := analysis.SpendingPaths(asm(t, test.asm), test.opts...)
+			require.NoError(t, err)
+			require.Len(t, pp, len(test.expPaths))
+
+			for i, p := range pp {
+				assert.Equal(t, test.expPaths[i], p.Path)
+				assert.Equal(t, test.expInputs[i], p.Inputs)
+				assert.Equal(t, test.expConstraints[i], constraints(p))
+				if test.expErrs == nil || test.expErrs[i] == 0 {
+					assert.NoError(t, p.Err)
+					continue
+				}
+				assert.True(t, errs.IsErrorCode(p.Err, test.expErrs[i]), p.Err)
+			}
+		})
+	}
+}
+
+func TestSpendingPaths_Summary(t *testing.T) {
+	t.Parallel()
+
+	pp, err := analysis.SpendingPaths(asm(t, "OP_IF OP_DUP OP_HASH160 "+testHash+" OP_EQUALVERIFY OP_CHECKSIG "+
+		"OP_ELSE 144 OP_NOP3 OP_DROP 1 "+testPubKey+" "+testPubKey+" 2 OP_CHECKMULTISIG OP_ENDIF"))
+	require.NoError(t, err)
+	require.Len(t, pp, 2)
+
+	digest, err := hex.DecodeString(testHash[2:])
+	require.NoError(t, err)
+	p := pp[0]
+	assert.Equal(t, []analysis.Preimage{{Input: 1, Hash: "OP_HASH160", Digest: digest}}, p.Preimages)
+	require.Len(t, p.Signatures, 1)
+	assert.Equal(t, 2, p.Signatures[0].Input)
+	assert.Equal(t, "input[1]", p.Signatures[0].PubKey.String())
+	assert.Nil(t, p.Sequence)
+
+	p = pp[1]
+	require.Len(t, p.MultiSigs, 1)
+	assert.Equal(t, 1, p.MultiSigs[0].Required)
+	assert.Equal(t, []int{1}, p.MultiSigs[0].Inputs)
+	assert.Len(t, p.MultiSigs[0].PubKeys, 2)
+	require.NotNil(t, p.Sequence)
+	assert.Equal(t, "144", p.Sequence.String())
+	assert.Equal(t, 3, p.Inputs)
+	assert.Equal(t, "path [false]: 3 inputs\n  not input[0]\n  OP_CHECKSEQUENCEVERIFY(144)\n  "+
+		"OP_CHECKMULTISIG(input[2], input[1], 1, "+testPubKey+", "+testPubKey+", 2)", p.String())
+
+	bb, err := json.Marshal(pp[1])
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(bb, &out))
+	assert.Equal(t, []interface{}{false}, out["path"])
+	assert.Equal(t, float64(3), out["inputs"])
+	assert.Equal(t, map[string]interface{}{"const": "9000"}, out["sequence"])
+	assert.NotContains(t, out, "error")
+}
+
+func TestSpendingPaths_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unknown pick index", func(t *testing.T) {
+		pp, err := analysis.SpendingPaths(asm(t, "OP_PICK"))
+		require.NoError(t, err)
+		require.Len(t, pp, 1)
+		assert.True(t, errors.Is(pp[0].Err, analysis.ErrUnknownIndex))
+
+		bb, err := json.Marshal(pp[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(bb), `"error":"OP_PICK: stack index depends on unlocking script values"`)
+	})
+
+	t.Run("too many branches", func(t *testing.T) {
+		_, err := analysis.SpendingPaths(asm(t, "OP_IF OP_ENDIF OP_IF OP_ENDIF 1"), analysis.WithMaxBranches(3))
+		assert.True(t, errors.Is(err, analysis.ErrTooManyBranches))
+
+		pp, err := analysis.SpendingPaths(asm(t, "OP_IF OP_ENDIF OP_IF OP_ENDIF 1"), analysis.WithMaxBranches(4))
+		require.NoError(t, err)
+		assert.Len(t, pp, 4)
+	})
+}
+
+func TestSpendingPaths_DeepStack(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		asm     string
+		opts    []analysis.OptionFunc
+		expErr  error
+		expCode errs.ErrorCode
+	}{
+		"pick beyond max stack size": {
+			asm:     "0xffffff7f OP_PICK",
+			expCode: errs.ErrStackOverflow,
+		},
+		"pick beyond max depth": {
+			asm:    "0xffffff3f OP_PICK",
+			opts:   []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expErr: analysis.ErrStackTooDeep,
+		},
+		"roll of max int64": {
+			asm:     "0xffffffffffffff7f OP_ROLL",
+			opts:    []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expCode: errs.ErrStackOverflow,
+		},
+		"multisig beyond max pubkeys": {
+			asm:     "0xffffff7f OP_CHECKMULTISIG",
+			expCode: errs.ErrInvalidPubKeyCount,
+		},
+		"multisig beyond max depth": {
+			asm:    "0x0d8e8c68 OP_CHECKMULTISIG",
+			opts:   []analysis.OptionFunc{analysis.WithAfterGenesis()},
+			expErr: analysis.ErrStackTooDeep,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pp, err := analysis.SpendingPaths(asm(t, test.asm), test.opts...)
+			require.NoError(t, err)
+			require.Len(t, pp, 1)
+			if test.expErr != nil {
+				assert.True(t, errors.Is(pp[0].Err, test.expErr), pp[0].Err)
+				return
+			}
+			assert.True(t, errs.IsErrorCode(pp[0].Err, test.expCode), pp[0].Err)
+		})
+	}
+}
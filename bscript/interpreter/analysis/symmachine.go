@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// symbols is the value domain of SpendingPaths, where the values are
+// expressions of the inputs pushed by the unlocking script.
+type symbols struct {
+	constraints []Constraint
+}
+
+func (d *symbols) clone() domain {
+	return &symbols{constraints: append([]Constraint{}, d.constraints...)}
+}
+
+func (d *symbols) apply(op byte, name string, args []*Expr) []*Expr {
+	return fold(op, name, args)
+}
+
+// assume adds a constraint on the inputs.
+func (d *symbols) assume(e *Expr, b bool) {
+	d.constraints = append(d.constraints, Constraint{Expr: e, False: !b})
+}
+
+// unknownIndex fails the path, as the constraints after it can't be known.
+func (d *symbols) unknownIndex(what string) error {
+	return errors.Wrap(ErrUnknownIndex, what)
+}
+
+// fold applies an opcode to its arguments, computing the result when the
+// arguments are constants and the opcode is simple enough, otherwise
+// returning an expression for each item pushed.
+func fold(op byte, name string, args []*Expr) []*Expr {
+	if e := foldConst(op, args); e != nil {
+		return []*Expr{e}
+	}
+	return opExprs(op, name, args)
+}
+
+// opExprs returns an expression for each item pushed by the opcode.
+func opExprs(op byte, name string, args []*Expr) []*Expr {
+	pushes := 1
+	if e, ok := opcodeEffects[op]; ok {
+		pushes = e[1]
+	}
+	ee := make([]*Expr, pushes)
+	for i := range ee {
+		ee[i] = &Expr{Kind: ExprOp, Op: name, Args: args, Output: i}
+	}
+	return ee
+}
+
+func foldConst(op byte, args []*Expr) *Expr {
+	switch op {
+	case bscript.OpCHECKSIG:
+		// An empty signature is never valid.
+		if args[0].IsConst() && len(args[0].Data) == 0 {
+			return boolExpr(false)
+		}
+		return nil
+	case bscript.OpSIZE:
+		if args[0].IsConst() {
+			return intExpr(int64(len(args[0].Data)))
+		}
+		return nil
+	}
+
+	for _, a := range args {
+		if !a.IsConst() {
+			return nil
+		}
+	}
+	switch op {
+	case bscript.OpEQUAL:
+		return boolExpr(bytes.Equal(args[0].Data, args[1].Data))
+	case bscript.OpCAT:
+		return constExpr(append(append([]byte{}, args[0].Data...), args[1].Data...))
+	}
+
+	// Only fold numbers which can't overflow.
+	nn := make([]int64, len(args))
+	for i, a := range args {
+		n, ok := constInt(a)
+		if !ok || len(a.Data) > 4 {
+			return nil
+		}
+		nn[i] = n
+	}
+	switch op {
+	case bscript.Op1ADD:
+		return intExpr(nn[0] + 1)
+	case bscript.Op1SUB:
+		return intExpr(nn[0] - 1)
+	case bscript.OpNEGATE:
+		return intExpr(-nn[0])
+	case bscript.OpABS:
+		if nn[0] < 0 {
+			return intExpr(-nn[0])
+		}
+		return intExpr(nn[0])
+	case bscript.OpNOT:
+		return boolExpr(nn[0] == 0)
+	case bscript.Op0NOTEQUAL:
+		return boolExpr(nn[0] != 0)
+	case bscript.OpADD:
+		return intExpr(nn[0] + nn[1])
+	case bscript.OpSUB:
+		return intExpr(nn[0] - nn[1])
+	case bscript.OpBOOLAND:
+		return boolExpr(nn[0] != 0 && nn[1] != 0)
+	case bscript.OpBOOLOR:
+		return boolExpr(nn[0] != 0 || nn[1] != 0)
+	case bscript.OpNUMEQUAL:
+		return boolExpr(nn[0] == nn[1])
+	case bscript.OpNUMNOTEQUAL:
+		return boolExpr(nn[0] != nn[1])
+	case bscript.OpLESSTHAN:
+		return boolExpr(nn[0] < nn[1])
+	case bscript.OpGREATERTHAN:
+		return boolExpr(nn[0] > nn[1])
+	case bscript.OpLESSTHANOREQUAL:
+		return boolExpr(nn[0] <= nn[1])
+	case bscript.OpGREATERTHANOREQUAL:
+		return boolExpr(nn[0] >= nn[1])
+	case bscript.OpMIN:
+		if nn[1] < nn[0] {
+			return intExpr(nn[1])
+		}
+		return intExpr(nn[0])
+	case bscript.OpMAX:
+		if nn[1] > nn[0] {
+			return intExpr(nn[1])
+		}
+		return intExpr(nn[0])
+	case bscript.OpWITHIN:
+		return boolExpr(nn[1] <= nn[0] && nn[0] < nn[2])
+	}
+
+	return nil
+}
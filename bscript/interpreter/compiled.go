@@ -0,0 +1,49 @@
+package interpreter
+
+import (
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// CompiledScript is a locking script parsed once, to be executed any number of
+// times with WithCompiledLockingScript without parsing it on each execution,
+// for example when verifying many outputs locked with the same template.
+//
+// A CompiledScript is immutable and safe to share across concurrent executions.
+type CompiledScript struct {
+	script     bscript.Script
+	parsed     ParsedScript
+	requiresTx bool
+}
+
+// Compile parses the locking script into a CompiledScript. The script is
+// copied, so it can be modified afterwards without affecting the result.
+func Compile(lockingScript *bscript.Script) (*CompiledScript, error) {
+	c := &CompiledScript{script: append(bscript.Script{}, *lockingScript...)}
+	parsed, err := (&DefaultOpcodeParser{}).Parse(&c.script)
+	if err != nil {
+		return nil, err
+	}
+	c.parsed = parsed
+
+	for i := range parsed {
+		if parsed[i].RequiresTx() {
+			c.requiresTx = true
+			break
+		}
+	}
+
+	return c, nil
+}
+
+// Script returns a copy of the locking script.
+func (c *CompiledScript) Script() *bscript.Script {
+	s := append(bscript.Script{}, c.script...)
+	return &s
+}
+
+// ParsedScript returns a copy of the parsed locking script.
+func (c *CompiledScript) ParsedScript() ParsedScript {
+	// The script parsed when compiled, so parsing its copy can't fail.
+	parsed, _ := (&DefaultOpcodeParser{}).Parse(c.Script())
+	return parsed
+}
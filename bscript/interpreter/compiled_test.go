@@ -0,0 +1,235 @@
+package interpreter
+
+import (
+	"crypto/sha256"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	lscript, err := bscript.NewFromASM("OP_1 OP_ADD OP_3 OP_EQUAL")
+	require.NoError(t, err)
+	c, err := Compile(lscript)
+	require.NoError(t, err)
+
+	// Modifying the script doesn't affect the compiled script.
+	(*lscript)[0] = bscript.Op2
+	asm, err := c.Script().ToASM()
+	require.NoError(t, err)
+	assert.Equal(t, "OP_TRUE OP_ADD OP_3 OP_EQUAL", asm)
+	assert.Len(t, c.ParsedScript(), 4)
+
+	_, err = Compile(bscript.NewFromBytes([]byte{bscript.OpDATA2, 0x01}))
+	assert.True(t, errs.IsErrorCode(err, errs.ErrMalformedPush), err)
+}
+
+func TestWithCompiledLockingScript(t *testing.T) {
+	t.Parallel()
+
+	tx, err := bt.NewTxFromString(txHex1)
+	require.NoError(t, err)
+	prevTx, err := bt.NewTxFromString(prevTxHex1)
+	require.NoError(t, err)
+	prevOutput := prevTx.OutputIdx(int(tx.Inputs[0].PreviousTxOutIndex))
+
+	compiled, err := Compile(prevOutput.LockingScript)
+	require.NoError(t, err)
+	other, err := bscript.NewFromASM("OP_1")
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromASM("OP_2")
+	require.NoError(t, err)
+	addScript, err := bscript.NewFromASM("OP_1 OP_ADD OP_3 OP_EQUAL")
+	require.NoError(t, err)
+	compiledAdd, err := Compile(addScript)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		oo      []ExecutionOptionFunc
+		expCode errs.ErrorCode
+		expErr  bool
+	}{
+		"tx": {
+			oo: []ExecutionOptionFunc{WithTx(tx, 0, prevOutput), WithCompiledLockingScript(compiled)},
+		},
+		"scripts without locking script": {
+			oo: []ExecutionOptionFunc{WithScripts(nil, uscript), WithCompiledLockingScript(compiledAdd)},
+		},
+		"matching locking script": {
+			oo: []ExecutionOptionFunc{WithScripts(addScript, uscript), WithCompiledLockingScript(compiledAdd)},
+		},
+		"locking script mismatch": {
+			oo:      []ExecutionOptionFunc{WithScripts(other, uscript), WithCompiledLockingScript(compiledAdd)},
+			expErr:  true,
+			expCode: errs.ErrInvalidParams,
+		},
+		"previous output mismatch": {
+			oo:      []ExecutionOptionFunc{WithTx(tx, 0, prevOutput), WithCompiledLockingScript(compiledAdd)},
+			expErr:  true,
+			expCode: errs.ErrInvalidParams,
+		},
+		"checksig without tx": {
+			oo: []ExecutionOptionFunc{
+				WithScripts(nil, tx.Inputs[0].UnlockingScript),
+				WithCompiledLockingScript(compiled),
+			},
+			expErr:  true,
+			expCode: errs.ErrInvalidParams,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NewEngine().Execute(append(test.oo, WithForkID(), WithAfterGenesis())...)
+			if test.expErr {
+				assert.True(t, errs.IsErrorCode(err, test.expCode), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWithCompiledLockingScript_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	lscript, uscript := hashLockScripts(t)
+	compiled, err := Compile(lscript)
+	require.NoError(t, err)
+	wrong, err := bscript.NewFromASM("00")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				u := uscript
+				if (i+j)%2 == 1 {
+					u = wrong
+				}
+				err := NewEngine().Execute(
+					WithScripts(nil, u),
+					WithCompiledLockingScript(compiled),
+					WithAfterGenesis(),
+				)
+				assert.Equal(t, u == wrong, err != nil, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, lscript.String(), compiled.Script().String())
+}
+
+// hashLockScripts returns a hash lock locking script, with a large data
+// push dropped as tokens carry, and an unlocking script spending it.
+func hashLockScripts(tb testing.TB) (*bscript.Script, *bscript.Script) {
+	preimage := []byte("preimage")
+	hash := sha256.Sum256(preimage)
+
+	lscript := &bscript.Script{}
+	for i := 0; i < 16; i++ {
+		require.NoError(tb, lscript.AppendPushData(make([]byte, 64)))
+		require.NoError(tb, lscript.AppendOpcodes(bscript.OpDROP))
+	}
+	require.NoError(tb, lscript.AppendOpcodes(bscript.OpSHA256))
+	require.NoError(tb, lscript.AppendPushData(hash[:]))
+	require.NoError(tb, lscript.AppendOpcodes(bscript.OpEQUAL))
+
+	uscript := &bscript.Script{}
+	require.NoError(tb, uscript.AppendPushData(preimage))
+
+	return lscript, uscript
+}
+
+func BenchmarkExecute(b *testing.B) {
+	lscript, uscript := hashLockScripts(b)
+	compiled, err := Compile(lscript)
+	require.NoError(b, err)
+
+	benchmarks := map[string][]ExecutionOptionFunc{
+		"parsed":   {WithScripts(lscript, uscript), WithAfterGenesis()},
+		"compiled": {WithScripts(nil, uscript), WithCompiledLockingScript(compiled), WithAfterGenesis()},
+	}
+
+	for name, oo := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			e := NewEngine()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := e.Execute(oo...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+" parallel", func(b *testing.B) {
+			e := NewEngine()
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := e.Execute(oo...); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkThreadPool isolates the reuse of pooled threads, executing with
+// threads released back to the pool after each execution against fresh
+// threads which are never released.
+func BenchmarkThreadPool(b *testing.B) {
+	tx, err := bt.NewTxFromString(txHex1)
+	require.NoError(b, err)
+	prevTx, err := bt.NewTxFromString(prevTxHex1)
+	require.NoError(b, err)
+	prevOutput := prevTx.OutputIdx(int(tx.Inputs[0].PreviousTxOutIndex))
+	lscript, uscript := hashLockScripts(b)
+
+	scripts := map[string][]ExecutionOptionFunc{
+		"p2pkh":     {WithTx(tx, 0, prevOutput), WithForkID(), WithAfterGenesis()},
+		"hash lock": {WithScripts(lscript, uscript), WithAfterGenesis()},
+	}
+
+	for name, oo := range scripts {
+		for _, pooled := range []bool{true, false} {
+			bname := name + " unpooled"
+			if pooled {
+				bname = name + " pooled"
+			}
+			b.Run(bname, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					opts := &execOpts{}
+					for _, o := range oo {
+						o(opts)
+					}
+					t, err := createThread(opts)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if err := t.execute(); err != nil {
+						b.Fatal(err)
+					}
+					if pooled {
+						t.release()
+					}
+				}
+			})
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package interpreter
 
-import "math"
+import (
+	"math"
+	"time"
+)
 
 type config interface {
 	AfterGenesis() bool
@@ -10,6 +13,7 @@ type config interface {
 	MaxScriptElementSize() int
 	MaxScriptNumberLength() int
 	MaxPubKeysPerMultiSig() int
+	MaxStackMemoryUsage() int64
 }
 
 // Limits applied to transactions before genesis
@@ -22,61 +26,133 @@ const (
 	MaxPubKeysPerMultiSigBeforeGenesis = 20
 )
 
-type beforeGenesisConfig struct{}
-type afterGenesisConfig struct{}
+// Limits applied to transactions after genesis
+const (
+	MaxScriptNumberLengthAfterGenesis       = 750 * 1000        // 750 * 1Kb
+	MaxScriptNumberLengthPolicyAfterGenesis = 250 * 1000        // 250 * 1Kb
+	MaxScriptSizePolicyAfterGenesis         = 500 * 1000        // 500 * 1Kb
+	MaxStackMemoryUsagePolicyAfterGenesis   = 100 * 1000 * 1000 // 100 * 1Mb
+)
 
-func (a *afterGenesisConfig) AfterGenesis() bool {
-	return true
+// Limits contains the limits applied when executing scripts.
+type Limits struct {
+	MaxOps                int
+	MaxStackSize          int
+	MaxScriptSize         int
+	MaxScriptElementSize  int
+	MaxScriptNumberLength int
+	MaxPubKeysPerMultiSig int
+
+	// MaxStackMemoryUsage is the maximum amount of bytes used by the
+	// data and alt stacks combined, with each item using its size
+	// plus an overhead of 32 bytes.
+	MaxStackMemoryUsage int64
 }
 
-func (b *beforeGenesisConfig) AfterGenesis() bool {
-	return false
+// Config contains the limits applied to utxos created
+// before and after genesis.
+type Config struct {
+	BeforeGenesis Limits
+	AfterGenesis  Limits
 }
 
-func (a *afterGenesisConfig) MaxStackSize() int {
-	return math.MaxInt32
+// ConsensusConfig contains the limits enforced by consensus, and
+// is used by default if no config is provided.
+var ConsensusConfig = Config{
+	BeforeGenesis: beforeGenesisLimits,
+	AfterGenesis: Limits{
+		MaxOps:                math.MaxInt32,
+		MaxStackSize:          math.MaxInt32,
+		MaxScriptSize:         math.MaxInt32,
+		MaxScriptElementSize:  math.MaxInt32,
+		MaxScriptNumberLength: MaxScriptNumberLengthAfterGenesis,
+		MaxPubKeysPerMultiSig: math.MaxInt32,
+		MaxStackMemoryUsage:   math.MaxInt64,
+	},
 }
 
-func (b *beforeGenesisConfig) MaxStackSize() int {
-	return MaxStackSizeBeforeGenesis
+// DefaultPolicyConfig contains the limits enforced by default
+// by nodes when accepting transactions.
+var DefaultPolicyConfig = Config{
+	BeforeGenesis: beforeGenesisLimits,
+	AfterGenesis: Limits{
+		MaxOps:                math.MaxInt32,
+		MaxStackSize:          math.MaxInt32,
+		MaxScriptSize:         MaxScriptSizePolicyAfterGenesis,
+		MaxScriptElementSize:  math.MaxInt32,
+		MaxScriptNumberLength: MaxScriptNumberLengthPolicyAfterGenesis,
+		MaxPubKeysPerMultiSig: math.MaxInt32,
+		MaxStackMemoryUsage:   MaxStackMemoryUsagePolicyAfterGenesis,
+	},
 }
 
-func (a *afterGenesisConfig) MaxScriptSize() int {
-	return math.MaxInt32
+var beforeGenesisLimits = Limits{
+	MaxOps:                MaxOpsBeforeGenesis,
+	MaxStackSize:          MaxStackSizeBeforeGenesis,
+	MaxScriptSize:         MaxScriptSizeBeforeGenesis,
+	MaxScriptElementSize:  MaxScriptElementSizeBeforeGenesis,
+	MaxScriptNumberLength: MaxScriptNumberLengthBeforeGenesis,
+	MaxPubKeysPerMultiSig: MaxPubKeysPerMultiSigBeforeGenesis,
+	MaxStackMemoryUsage:   math.MaxInt64,
 }
 
-func (b *beforeGenesisConfig) MaxScriptSize() int {
-	return MaxScriptSizeBeforeGenesis
+// Budget bounds the work done by an execution. Zero
+// values are not enforced.
+type Budget struct {
+	// MaxOpcodes is the maximum amount of opcodes executed,
+	// including pushes and opcodes in unexecuted branches.
+	MaxOpcodes int
+
+	// MaxDuration is the maximum wall time of the execution.
+	MaxDuration time.Duration
+}
+
+// limitsConfig applies the limits of a Config
+// before or after genesis.
+type limitsConfig struct {
+	limits       Limits
+	afterGenesis bool
+}
+
+func newLimitsConfig(c *Config, afterGenesis bool) *limitsConfig {
+	if c == nil {
+		c = &ConsensusConfig
+	}
+	if afterGenesis {
+		return &limitsConfig{limits: c.AfterGenesis, afterGenesis: true}
+	}
+
+	return &limitsConfig{limits: c.BeforeGenesis}
 }
 
-func (a *afterGenesisConfig) MaxScriptElementSize() int {
-	return math.MaxInt32
+func (l *limitsConfig) AfterGenesis() bool {
+	return l.afterGenesis
 }
 
-func (b *beforeGenesisConfig) MaxScriptElementSize() int {
-	return MaxScriptElementSizeBeforeGenesis
+func (l *limitsConfig) MaxStackSize() int {
+	return l.limits.MaxStackSize
 }
 
-func (a *afterGenesisConfig) MaxScriptNumberLength() int {
-	return 750 * 1000 // 750 * 1Kb
+func (l *limitsConfig) MaxScriptSize() int {
+	return l.limits.MaxScriptSize
 }
 
-func (b *beforeGenesisConfig) MaxScriptNumberLength() int {
-	return MaxScriptNumberLengthBeforeGenesis
+func (l *limitsConfig) MaxScriptElementSize() int {
+	return l.limits.MaxScriptElementSize
 }
 
-func (a *afterGenesisConfig) MaxOps() int {
-	return math.MaxInt32
+func (l *limitsConfig) MaxScriptNumberLength() int {
+	return l.limits.MaxScriptNumberLength
 }
 
-func (b *beforeGenesisConfig) MaxOps() int {
-	return MaxOpsBeforeGenesis
+func (l *limitsConfig) MaxOps() int {
+	return l.limits.MaxOps
 }
 
-func (a *afterGenesisConfig) MaxPubKeysPerMultiSig() int {
-	return math.MaxInt32
+func (l *limitsConfig) MaxPubKeysPerMultiSig() int {
+	return l.limits.MaxPubKeysPerMultiSig
 }
 
-func (b *beforeGenesisConfig) MaxPubKeysPerMultiSig() int {
-	return MaxPubKeysPerMultiSigBeforeGenesis
+func (l *limitsConfig) MaxStackMemoryUsage() int64 {
+	return l.limits.MaxStackMemoryUsage
 }
@@ -0,0 +1,216 @@
+// Package coverage reports which opcodes and IF/ELSE branches of a locking
+// script were executed across a number of executions, such as those of the
+// test suite of a contract.
+//
+// Collect coverage by executing with a Collector as the debugger, then
+// write its report as text or HTML:
+//
+//	c, err := coverage.NewCollector(lockingScript)
+//	for _, unlockingScript := range unlockingScripts {
+//	    _ = interpreter.NewEngine().Execute(
+//	        interpreter.WithScripts(lockingScript, unlockingScript),
+//	        interpreter.WithAfterGenesis(),
+//	        interpreter.WithDebugger(c),
+//	    )
+//	}
+//	err = c.Report().WriteHTML(f)
+package coverage
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/debug"
+)
+
+// condTrue is the value of an executing branch in the interpreter.State CondStack.
+const condTrue = 1
+
+// Collector an interpreter.Debugger which collects the coverage of a locking
+// script over every execution it is provided to. Executions of other locking
+// scripts are ignored. It is safe to use across concurrent executions.
+type Collector struct {
+	debug.DefaultDebugger
+
+	script interpreter.ParsedScript
+	raw    []byte
+
+	mu       sync.Mutex
+	runs     int
+	skipped  int
+	hits     []int
+	branches map[int]*Branch
+}
+
+// NewCollector returns a collector of the coverage of the locking script, to be
+// provided to its executions with interpreter.WithDebugger.
+func NewCollector(lockingScript *bscript.Script) (*Collector, error) {
+	if lockingScript == nil || len(*lockingScript) == 0 {
+		return nil, ErrNoScript
+	}
+
+	ps, err := (&interpreter.DefaultOpcodeParser{}).Parse(lockingScript)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Collector{
+		DefaultDebugger: debug.NewDebugger(),
+		script:          ps,
+		raw:             append([]byte{}, *lockingScript...),
+		hits:            make([]int, len(ps)),
+		branches:        make(map[int]*Branch),
+	}
+	for i, op := range ps {
+		if v := op.Value(); v == bscript.OpIF || v == bscript.OpNOTIF {
+			c.branches[i] = &Branch{OpcodeIdx: i, Opcode: op.Name()}
+		}
+	}
+
+	c.AttachBeforeExecute(c.beforeExecute)
+	c.AttachBeforeExecuteOpcode(c.beforeExecuteOpcode)
+	c.AttachAfterExecuteOpcode(c.afterExecuteOpcode)
+
+	return c, nil
+}
+
+// Report returns the coverage collected so far.
+func (c *Collector) Report() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := &Report{
+		Runs:    c.runs,
+		Skipped: c.skipped,
+		script:  c.raw,
+	}
+	for i, op := range c.script {
+		r.Opcodes = append(r.Opcodes, Opcode{OpcodeIdx: i, Opcode: op.Name(), ASM: asm(op), Hits: c.hits[i]})
+		if b, ok := c.branches[i]; ok {
+			r.Branches = append(r.Branches, *b)
+		}
+	}
+
+	return r
+}
+
+// Reset clears the coverage collected so far.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.runs, c.skipped = 0, 0
+	c.hits = make([]int, len(c.script))
+	for _, b := range c.branches {
+		b.True, b.False = 0, 0
+	}
+}
+
+func (c *Collector) beforeExecute(state *interpreter.State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.matches(state) {
+		c.runs++
+		return
+	}
+	c.skipped++
+}
+
+func (c *Collector) beforeExecuteOpcode(state *interpreter.State) {
+	if !c.locking(state) {
+		return
+	}
+
+	// Conditionals are processed in non-executing branches too, so an
+	// ELSE or ENDIF is covered if the branch enclosing its IF executes.
+	cond := state.CondStack
+	if v := state.Opcode().Value(); (v == bscript.OpELSE || v == bscript.OpENDIF) && len(cond) > 0 {
+		cond = cond[:len(cond)-1]
+	}
+	if !executing(state, cond) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hits[state.OpcodeIdx]++
+}
+
+func (c *Collector) afterExecuteOpcode(state *interpreter.State) {
+	if !c.locking(state) {
+		return
+	}
+	b, ok := c.branches[state.OpcodeIdx]
+	if !ok || len(state.CondStack) == 0 {
+		return
+	}
+
+	// The IF pushed whether its first branch executes onto the CondStack.
+	n := len(state.CondStack) - 1
+	if !executing(state, state.CondStack[:n]) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state.CondStack[n] == condTrue {
+		b.True++
+		return
+	}
+	b.False++
+}
+
+// matches returns whether the execution is of the locking script.
+func (c *Collector) matches(state *interpreter.State) bool {
+	if len(state.Scripts) < 2 {
+		return false
+	}
+	s, err := (&interpreter.DefaultOpcodeParser{}).Unparse(state.Scripts[1])
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(*s, c.raw)
+}
+
+// locking returns whether the state is at an opcode of the locking script. Only
+// the opcode is compared, as comparing the whole script at every opcode would
+// be costly, with executions of other scripts detected in beforeExecute.
+func (c *Collector) locking(state *interpreter.State) bool {
+	if state.ScriptIdx != 1 || len(state.Scripts[1]) != len(c.script) {
+		return false
+	}
+	op, exp := state.Opcode(), c.script[state.OpcodeIdx]
+
+	return op.Value() == exp.Value() && bytes.Equal(op.Data, exp.Data)
+}
+
+// executing returns whether the branch of the conditional stack is executing.
+func executing(state *interpreter.State, cond []int) bool {
+	if state.Genesis.EarlyReturn {
+		return false
+	}
+	for _, v := range cond {
+		if v != condTrue {
+			return false
+		}
+	}
+
+	return true
+}
+
+func asm(op interpreter.ParsedOpcode) string {
+	s, err := (&interpreter.DefaultOpcodeParser{}).Unparse(interpreter.ParsedScript{op})
+	if err != nil {
+		return op.Name()
+	}
+	a, err := s.ToASM()
+	if err != nil || a == "" {
+		return op.Name()
+	}
+
+	return a
+}
@@ -0,0 +1,185 @@
+package coverage_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/coverage"
+)
+
+const lockingASM = "OP_IF OP_IF OP_1 OP_ELSE OP_0 OP_ENDIF OP_ELSE OP_1 OP_ENDIF"
+
+func collect(t *testing.T, locking string, unlocking ...string) *coverage.Collector {
+	lscript, err := bscript.NewFromASM(locking)
+	require.NoError(t, err)
+	c, err := coverage.NewCollector(lscript)
+	require.NoError(t, err)
+
+	for _, u := range unlocking {
+		execute(t, c, lscript, u)
+	}
+
+	return c
+}
+
+func execute(t *testing.T, c *coverage.Collector, lscript *bscript.Script, unlocking string) {
+	uscript, err := bscript.NewFromASM(unlocking)
+	require.NoError(t, err)
+	_ = interpreter.NewEngine().Execute(
+		interpreter.WithScripts(lscript, uscript),
+		interpreter.WithAfterGenesis(),
+		interpreter.WithDebugger(c),
+	)
+}
+
+func hits(r *coverage.Report) []int {
+	hh := make([]int, len(r.Opcodes))
+	for i, o := range r.Opcodes {
+		hh[i] = o.Hits
+	}
+	return hh
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		unlocking   []string
+		expHits     []int
+		expBranches []coverage.Branch
+		expSummary  coverage.Summary
+	}{
+		"no runs": {
+			expHits: []int{0, 0, 0, 0, 0, 0, 0, 0, 0},
+			expBranches: []coverage.Branch{
+				{OpcodeIdx: 0, Opcode: "OP_IF"},
+				{OpcodeIdx: 1, Opcode: "OP_IF"},
+			},
+			expSummary: coverage.Summary{Opcodes: 9, Branches: 4},
+		},
+		"first branches": {
+			unlocking: []string{"OP_1 OP_1"},
+			expHits:   []int{1, 1, 1, 1, 0, 1, 1, 0, 1},
+			expBranches: []coverage.Branch{
+				{OpcodeIdx: 0, Opcode: "OP_IF", True: 1},
+				{OpcodeIdx: 1, Opcode: "OP_IF", True: 1},
+			},
+			expSummary: coverage.Summary{Opcodes: 9, CoveredOpcodes: 7, Branches: 4, CoveredBranches: 2},
+		},
+		"nested branch not executed": {
+			unlocking: []string{"OP_0"},
+			expHits:   []int{1, 0, 0, 0, 0, 0, 1, 1, 1},
+			expBranches: []coverage.Branch{
+				{OpcodeIdx: 0, Opcode: "OP_IF", False: 1},
+				{OpcodeIdx: 1, Opcode: "OP_IF"},
+			},
+			expSummary: coverage.Summary{Opcodes: 9, CoveredOpcodes: 4, Branches: 4, CoveredBranches: 1},
+		},
+		"merged runs": {
+			unlocking: []string{"OP_1 OP_1", "OP_0", "OP_0 OP_1"},
+			expHits:   []int{3, 2, 1, 2, 1, 2, 3, 1, 3},
+			expBranches: []coverage.Branch{
+				{OpcodeIdx: 0, Opcode: "OP_IF", True: 2, False: 1},
+				{OpcodeIdx: 1, Opcode: "OP_IF", True: 1, False: 1},
+			},
+			expSummary: coverage.Summary{Opcodes: 9, CoveredOpcodes: 9, Branches: 4, CoveredBranches: 4},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := collect(t, lockingASM, test.unlocking...).Report()
+			assert.Equal(t, len(test.unlocking), r.Runs)
+			assert.Equal(t, test.expHits, hits(r))
+			assert.Equal(t, test.expBranches, r.Branches)
+			assert.Equal(t, test.expSummary, r.Summary())
+		})
+	}
+}
+
+func TestCollector_OtherScripts(t *testing.T) {
+	t.Parallel()
+
+	c := collect(t, lockingASM, "OP_0")
+
+	other, err := bscript.NewFromASM("OP_1 OP_VERIFY OP_1")
+	require.NoError(t, err)
+	execute(t, c, other, "OP_0")
+
+	r := c.Report()
+	assert.Equal(t, 1, r.Runs)
+	assert.Equal(t, 1, r.Skipped)
+	assert.Equal(t, []int{1, 0, 0, 0, 0, 0, 1, 1, 1}, hits(r))
+
+	c.Reset()
+	r = c.Report()
+	assert.Equal(t, 0, r.Runs)
+	assert.Equal(t, []int{0, 0, 0, 0, 0, 0, 0, 0, 0}, hits(r))
+}
+
+func TestCollector_EarlyReturn(t *testing.T) {
+	t.Parallel()
+
+	r := collect(t, "OP_RETURN OP_VERIFY", "OP_1").Report()
+	assert.Equal(t, []int{1, 0}, hits(r))
+}
+
+func TestNewCollector_NoScript(t *testing.T) {
+	t.Parallel()
+
+	_, err := coverage.NewCollector(&bscript.Script{})
+	assert.True(t, errors.Is(err, coverage.ErrNoScript))
+}
+
+func TestReport_Merge(t *testing.T) {
+	t.Parallel()
+
+	r := collect(t, lockingASM, "OP_1 OP_1").Report()
+	require.NoError(t, r.Merge(collect(t, lockingASM, "OP_0", "OP_0 OP_1").Report()))
+	assert.Equal(t, 3, r.Runs)
+	assert.Equal(t, []int{3, 2, 1, 2, 1, 2, 3, 1, 3}, hits(r))
+	assert.Equal(t, 4, r.Summary().CoveredBranches)
+
+	err := r.Merge(collect(t, "OP_1").Report())
+	assert.True(t, errors.Is(err, coverage.ErrScriptMismatch))
+}
+
+func TestReport_WriteText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, collect(t, lockingASM, "OP_1 OP_1", "OP_0").Report().WriteText(&buf))
+
+	assert.Equal(t, `coverage: 8/9 opcodes (88.9%), 3/4 branches (75.0%) over 2 runs
+
+    0       2  OP_IF  [true: 1, false: 1]
+    1       1    OP_IF  [true: 1, false: 0]  <- partial
+    2       1      OP_TRUE
+    3       1    OP_ELSE
+    4       0      OP_FALSE  <- uncovered
+    5       1    OP_ENDIF
+    6       2  OP_ELSE
+    7       1    OP_TRUE
+    8       2  OP_ENDIF
+`, buf.String())
+}
+
+func TestReport_WriteHTML(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, collect(t, lockingASM, "OP_1 OP_1", "OP_0").Report().WriteHTML(&buf))
+
+	html := buf.String()
+	assert.Contains(t, html, "8/9 opcodes (88.9%)")
+	assert.Contains(t, html, `<span class="partial" title="opcode 1: executed 1 times, true 1, false 0">  OP_IF</span>`)
+	assert.Contains(t, html, `<span class="uncovered" title="opcode 4: executed 0 times">    OP_FALSE</span>`)
+	assert.Equal(t, 9, strings.Count(html, `<span class="hits">`))
+}
@@ -0,0 +1,9 @@
+package coverage
+
+import "github.com/pkg/errors"
+
+// Sentinel errors raised by coverage collection.
+var (
+	ErrNoScript       = errors.New("no locking script provided")
+	ErrScriptMismatch = errors.New("reports are of different locking scripts")
+)
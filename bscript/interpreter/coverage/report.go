@@ -0,0 +1,231 @@
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Report the coverage of a locking script.
+type Report struct {
+	// Runs is the number of executions of the locking script, and
+	// Skipped the number of executions of other locking scripts.
+	Runs    int
+	Skipped int
+	// Opcodes is the coverage of each opcode of the script.
+	Opcodes []Opcode
+	// Branches is the coverage of each OP_IF and OP_NOTIF of the script.
+	Branches []Branch
+
+	script []byte
+}
+
+// Opcode the coverage of an opcode.
+type Opcode struct {
+	OpcodeIdx int
+	Opcode    string
+	ASM       string
+	// Hits is the number of times the opcode executed.
+	Hits int
+}
+
+// Branch the coverage of the branches of an OP_IF or OP_NOTIF.
+type Branch struct {
+	OpcodeIdx int
+	Opcode    string
+	// True is the number of times the first branch executed, and False
+	// the number of times the OP_ELSE branch, if any, executed.
+	True  int
+	False int
+}
+
+// Covered returns whether both branches executed.
+func (b Branch) Covered() bool {
+	return b.True > 0 && b.False > 0
+}
+
+// Summary the totals of a report.
+type Summary struct {
+	Opcodes         int
+	CoveredOpcodes  int
+	Branches        int
+	CoveredBranches int
+}
+
+// OpcodePercent returns the percentage of opcodes covered.
+func (s Summary) OpcodePercent() float64 {
+	return percent(s.CoveredOpcodes, s.Opcodes)
+}
+
+// BranchPercent returns the percentage of branches covered.
+func (s Summary) BranchPercent() float64 {
+	return percent(s.CoveredBranches, s.Branches)
+}
+
+// Summary returns the totals of the report, counting the two
+// branches of each OP_IF and OP_NOTIF separately.
+func (r *Report) Summary() Summary {
+	s := Summary{Opcodes: len(r.Opcodes), Branches: 2 * len(r.Branches)}
+	for _, o := range r.Opcodes {
+		if o.Hits > 0 {
+			s.CoveredOpcodes++
+		}
+	}
+	for _, b := range r.Branches {
+		if b.True > 0 {
+			s.CoveredBranches++
+		}
+		if b.False > 0 {
+			s.CoveredBranches++
+		}
+	}
+
+	return s
+}
+
+// Merge adds the coverage of the other report, which must be
+// of the same locking script, to this report.
+func (r *Report) Merge(other *Report) error {
+	if !bytes.Equal(r.script, other.script) {
+		return ErrScriptMismatch
+	}
+
+	r.Runs += other.Runs
+	r.Skipped += other.Skipped
+	for i := range r.Opcodes {
+		r.Opcodes[i].Hits += other.Opcodes[i].Hits
+	}
+	for i := range r.Branches {
+		r.Branches[i].True += other.Branches[i].True
+		r.Branches[i].False += other.Branches[i].False
+	}
+
+	return nil
+}
+
+// WriteText writes the report as text, a line per opcode with the number
+// of times it executed, and the times each branch executed for conditionals.
+func (r *Report) WriteText(w io.Writer) error {
+	s := r.Summary()
+	if _, err := fmt.Fprintf(w, "coverage: %d/%d opcodes (%.1f%%), %d/%d branches (%.1f%%) over %d runs\n\n",
+		s.CoveredOpcodes, s.Opcodes, s.OpcodePercent(),
+		s.CoveredBranches, s.Branches, s.BranchPercent(), r.Runs); err != nil {
+		return err
+	}
+
+	for _, l := range r.lines() {
+		text := fmt.Sprintf("%5d  %6d  %s%s", l.OpcodeIdx, l.Hits, strings.Repeat("  ", l.Depth), l.ASM)
+		if l.Branch != nil {
+			text += fmt.Sprintf("  [true: %d, false: %d]", l.Branch.True, l.Branch.False)
+		}
+		if l.Class != classCovered {
+			text += "  <- " + l.Class
+		}
+		if _, err := fmt.Fprintln(w, text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteHTML writes the report as a HTML page, annotating the ASM of the
+// script with the coverage of each opcode.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, struct {
+		Summary Summary
+		Runs    int
+		Lines   []line
+	}{r.Summary(), r.Runs, r.lines()})
+}
+
+// The classes of the coverage of a line.
+const (
+	classCovered   = "covered"
+	classUncovered = "uncovered"
+	classPartial   = "partial"
+)
+
+// line an opcode of a report, laid out for output.
+type line struct {
+	Opcode
+	Branch *Branch
+	Depth  int
+	Class  string
+}
+
+// lines lays out the opcodes of the report, indented by conditional depth.
+func (r *Report) lines() []line {
+	branches := make(map[int]*Branch, len(r.Branches))
+	for i := range r.Branches {
+		branches[r.Branches[i].OpcodeIdx] = &r.Branches[i]
+	}
+
+	ll := make([]line, 0, len(r.Opcodes))
+	var depth int
+	for _, o := range r.Opcodes {
+		l := line{Opcode: o, Branch: branches[o.OpcodeIdx], Class: classCovered}
+
+		switch o.Opcode {
+		case "OP_ELSE", "OP_ENDIF":
+			if depth > 0 {
+				depth--
+			}
+		}
+		l.Depth = depth
+		switch {
+		case l.Branch != nil:
+			depth++
+		case o.Opcode == "OP_ELSE":
+			depth++
+		}
+
+		switch {
+		case o.Hits == 0:
+			l.Class = classUncovered
+		case l.Branch != nil && !l.Branch.Covered():
+			l.Class = classPartial
+		}
+		ll = append(ll, l)
+	}
+
+	return ll
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+
+	return 100 * float64(n) / float64(total)
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Funcs(template.FuncMap{
+	"indent": func(n int) string { return strings.Repeat("  ", n) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Script coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { font-size: 14px; line-height: 1.4; }
+.hits { color: #888; display: inline-block; width: 5em; text-align: right; margin-right: 1em; }
+.covered { background: #d4f4d4; }
+.uncovered { background: #f8d0d0; }
+.partial { background: #f8f0c0; }
+</style>
+</head>
+<body>
+<h1>Script coverage</h1>
+<p>{{.Summary.CoveredOpcodes}}/{{.Summary.Opcodes}} opcodes ({{printf "%.1f" .Summary.OpcodePercent}}%),
+{{.Summary.CoveredBranches}}/{{.Summary.Branches}} branches ({{printf "%.1f" .Summary.BranchPercent}}%)
+over {{.Runs}} runs.</p>
+<pre>
+{{range .Lines}}<span class="hits">{{.Hits}}</span><span class="{{.Class}}" title="opcode {{.OpcodeIdx}}: executed {{.Hits}} times{{with .Branch}}, true {{.True}}, false {{.False}}{{end}}">{{indent .Depth}}{{.ASM}}</span>
+{{end}}</pre>
+</body>
+</html>
+`))
@@ -0,0 +1,13 @@
+package dap
+
+import "github.com/pkg/errors"
+
+// Sentinel errors raised by the server.
+var (
+	ErrInvalidMessage     = errors.New("invalid message")
+	ErrUnsupportedRequest = errors.New("unsupported request")
+	ErrNoProgram          = errors.New("no program to debug")
+	ErrNotLaunched        = errors.New("no program launched")
+	ErrNotPaused          = errors.New("execution is not paused")
+	ErrInvalidInput       = errors.New("invalid input index")
+)
@@ -0,0 +1,167 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// message is the base of all protocol messages, covering the fields of
+// requests, responses and events used by the server.
+type message struct {
+	Seq     int             `json:"seq"`
+	Type    string          `json:"type"`
+	Command string          `json:"command,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Args    json.RawMessage `json:"arguments,omitempty"`
+
+	RequestSeq int         `json:"request_seq,omitempty"`
+	Success    *bool       `json:"success,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+// conn reads and writes messages framed with a Content-Length header.
+type conn struct {
+	r *textproto.Reader
+
+	mu  sync.Mutex
+	w   io.Writer
+	seq int
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{r: textproto.NewReader(bufio.NewReader(r)), w: w}
+}
+
+func (c *conn) read() (*message, error) {
+	hdr, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(hdr.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, errors.Wrap(ErrInvalidMessage, "bad Content-Length")
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(c.r.R, b); err != nil {
+		return nil, err
+	}
+	var m message
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+
+	return &m, nil
+}
+
+func (c *conn) write(m *message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	m.Seq = c.seq
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(b), b)
+
+	return err
+}
+
+func (c *conn) respond(req *message, body interface{}) error {
+	ok := true
+	return c.write(&message{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: &ok, Body: body})
+}
+
+func (c *conn) respondErr(req *message, err error) error {
+	ok := false
+	return c.write(&message{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: &ok, Message: err.Error()})
+}
+
+func (c *conn) event(event string, body interface{}) error {
+	return c.write(&message{Type: "event", Event: event, Body: body})
+}
+
+// The argument and body types of the requests and events supported.
+type (
+	launchArgs struct {
+		// Program is the path to the ASM source of the locking script.
+		Program string `json:"program"`
+		// UnlockingProgram is the path to the ASM source of the
+		// unlocking script, if not provided by Tx.
+		UnlockingProgram string `json:"unlockingProgram"`
+		// Tx is the hex of a transaction spending the locking script,
+		// at InputIndex, of an output of Satoshis.
+		Tx           string `json:"tx"`
+		InputIndex   int    `json:"inputIndex"`
+		Satoshis     uint64 `json:"satoshis"`
+		AfterGenesis bool   `json:"afterGenesis"`
+		StopOnEntry  bool   `json:"stopOnEntry"`
+	}
+
+	source struct {
+		Name string `json:"name,omitempty"`
+		Path string `json:"path,omitempty"`
+	}
+
+	sourceBreakpoint struct {
+		Line int `json:"line"`
+	}
+
+	setBreakpointsArgs struct {
+		Source      source             `json:"source"`
+		Breakpoints []sourceBreakpoint `json:"breakpoints"`
+	}
+
+	breakpoint struct {
+		Verified bool   `json:"verified"`
+		Line     int    `json:"line,omitempty"`
+		Source   source `json:"source"`
+	}
+
+	thread struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	stackFrame struct {
+		ID     int     `json:"id"`
+		Name   string  `json:"name"`
+		Source *source `json:"source,omitempty"`
+		Line   int     `json:"line"`
+		Column int     `json:"column"`
+	}
+
+	scope struct {
+		Name               string `json:"name"`
+		VariablesReference int    `json:"variablesReference"`
+		Expensive          bool   `json:"expensive"`
+	}
+
+	variablesArgs struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+
+	variable struct {
+		Name               string `json:"name"`
+		Value              string `json:"value"`
+		VariablesReference int    `json:"variablesReference"`
+	}
+
+	stoppedEvent struct {
+		Reason            string `json:"reason"`
+		Description       string `json:"description,omitempty"`
+		Text              string `json:"text,omitempty"`
+		ThreadID          int    `json:"threadId"`
+		AllThreadsStopped bool   `json:"allThreadsStopped"`
+	}
+)
@@ -0,0 +1,509 @@
+// Package dap implements a Debug Adapter Protocol server for debugging script
+// execution from editors such as VS Code.
+//
+// A session launches the execution of a locking script, assembled from an ASM
+// source file, and its unlocking script, under a debug.DefaultDebugger. The
+// execution pauses before each step for which a breakpoint is hit, or while
+// stepping, and the data, alt and condition stacks are shown as variables.
+//
+// The launch arguments are:
+//
+//	{
+//	    "program": "path/to/locking.asm",
+//	    "unlockingProgram": "path/to/unlocking.asm",
+//	    "tx": "<hex of a spending tx, optional>",
+//	    "inputIndex": 0,
+//	    "satoshis": 1000,
+//	    "afterGenesis": true,
+//	    "stopOnEntry": true
+//	}
+//
+// Each line of the ASM sources is assembled separately, so a token
+// can't span lines.
+package dap
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/debug"
+)
+
+// threadID the id of the single thread of execution debugged.
+const threadID = 1
+
+// The variable references of the stacks.
+const (
+	dataStackRef = iota + 1
+	altStackRef
+	condStackRef
+)
+
+type stepMode int
+
+const (
+	modeContinue stepMode = iota
+	modeStepIn
+	modeStepOver
+)
+
+// Server a Debug Adapter Protocol server for a single debug session.
+type Server struct {
+	conn *conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	resume chan stepMode
+	done   chan struct{}
+
+	mu          sync.Mutex
+	launch      *launchArgs
+	sources     []*sourceMap
+	breakpoints map[string]map[int]bool
+	state       *interpreter.State
+	started     bool
+	stopped     bool
+
+	// Only accessed from the execution.
+	mode       stepMode
+	stepDepth  int
+	stepScript int
+	entered    bool
+}
+
+// NewServer returns a server for a session over the provided reader and
+// writer, such as stdin and stdout.
+func NewServer(r io.Reader, w io.Writer) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		conn:        newConn(r, w),
+		ctx:         ctx,
+		cancel:      cancel,
+		resume:      make(chan stepMode),
+		done:        make(chan struct{}),
+		breakpoints: make(map[string]map[int]bool),
+	}
+}
+
+// ListenAndServe listens on the TCP address, such as localhost:4711, and
+// serves a session for each connection.
+func ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer func() {
+				_ = c.Close()
+			}()
+			_ = NewServer(c, c).Serve()
+		}()
+	}
+}
+
+// Serve handles requests until the client disconnects, or the reader
+// is closed.
+func (s *Server) Serve() error {
+	defer s.cancel()
+
+	for {
+		req, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if req.Type != "request" {
+			continue
+		}
+
+		disconnect, err := s.handle(req)
+		if err != nil {
+			err = s.conn.respondErr(req, err)
+		}
+		if err != nil {
+			return err
+		}
+		if disconnect {
+			return nil
+		}
+	}
+}
+
+// handle handles a request, returning an error to be sent to the client.
+func (s *Server) handle(req *message) (bool, error) {
+	switch req.Command {
+	case "initialize":
+		if err := s.conn.respond(req, map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+		}); err != nil {
+			return false, err
+		}
+		return false, s.conn.event("initialized", nil)
+	case "launch":
+		var args launchArgs
+		if err := json.Unmarshal(req.Args, &args); err != nil {
+			return false, err
+		}
+		if err := s.load(&args); err != nil {
+			return false, err
+		}
+		return false, s.conn.respond(req, nil)
+	case "setBreakpoints":
+		var args setBreakpointsArgs
+		if err := json.Unmarshal(req.Args, &args); err != nil {
+			return false, err
+		}
+		bps, err := s.setBreakpoints(&args)
+		if err != nil {
+			return false, err
+		}
+		return false, s.conn.respond(req, map[string]interface{}{"breakpoints": bps})
+	case "configurationDone":
+		opts, err := s.start()
+		if err != nil {
+			return false, err
+		}
+		if err := s.conn.respond(req, nil); err != nil {
+			return false, err
+		}
+		if opts != nil {
+			go s.execute(opts)
+		}
+		return false, nil
+	case "threads":
+		return false, s.conn.respond(req, map[string]interface{}{
+			"threads": []thread{{ID: threadID, Name: "script"}},
+		})
+	case "stackTrace":
+		frames := s.stackTrace()
+		return false, s.conn.respond(req, map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)})
+	case "scopes":
+		return false, s.conn.respond(req, map[string]interface{}{"scopes": []scope{
+			{Name: "Data Stack", VariablesReference: dataStackRef},
+			{Name: "Alt Stack", VariablesReference: altStackRef},
+			{Name: "Condition Stack", VariablesReference: condStackRef},
+		}})
+	case "variables":
+		var args variablesArgs
+		if err := json.Unmarshal(req.Args, &args); err != nil {
+			return false, err
+		}
+		return false, s.conn.respond(req, map[string]interface{}{"variables": s.variables(args.VariablesReference)})
+	case "continue":
+		if err := s.step(modeContinue); err != nil {
+			return false, err
+		}
+		return false, s.conn.respond(req, map[string]interface{}{"allThreadsContinued": true})
+	case "next", "stepIn":
+		mode := modeStepIn
+		if req.Command == "next" {
+			mode = modeStepOver
+		}
+		if err := s.step(mode); err != nil {
+			return false, err
+		}
+		return false, s.conn.respond(req, nil)
+	case "disconnect", "terminate":
+		s.cancel()
+		if s.isStarted() {
+			<-s.done
+		}
+		return true, s.conn.respond(req, nil)
+	}
+
+	return false, errors.Wrap(ErrUnsupportedRequest, req.Command)
+}
+
+// load assembles the sources of the launch.
+func (s *Server) load(args *launchArgs) error {
+	if args.Program == "" {
+		return ErrNoProgram
+	}
+
+	lsm, err := loadSource(args.Program)
+	if err != nil {
+		return err
+	}
+	var usm *sourceMap
+	if args.UnlockingProgram != "" {
+		if usm, err = loadSource(args.UnlockingProgram); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.launch = args
+	s.sources = []*sourceMap{usm, lsm}
+
+	return nil
+}
+
+func (s *Server) setBreakpoints(args *setBreakpointsArgs) ([]breakpoint, error) {
+	path := filepath.Clean(args.Source.Path)
+	sm, err := loadSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[int]bool)
+	bps := make([]breakpoint, len(args.Breakpoints))
+	for i, b := range args.Breakpoints {
+		bps[i] = breakpoint{Line: b.Line, Source: args.Source}
+		if sm.opcodeAt(b.Line) >= 0 {
+			bps[i].Verified = true
+			lines[b.Line] = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakpoints[path] = lines
+
+	return bps, nil
+}
+
+// start prepares the execution, returning the options to execute with,
+// or nil if it has already started.
+func (s *Server) start() ([]interpreter.ExecutionOptionFunc, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.launch == nil {
+		return nil, ErrNotLaunched
+	}
+	if s.started {
+		return nil, nil
+	}
+
+	opts, err := s.executionOpts()
+	if err != nil {
+		return nil, err
+	}
+	s.started = true
+	s.mode = modeContinue
+	if s.launch.StopOnEntry {
+		s.mode = modeStepIn
+	}
+
+	return opts, nil
+}
+
+func (s *Server) executionOpts() ([]interpreter.ExecutionOptionFunc, error) {
+	var unlocking *bscript.Script
+	if s.sources[0] != nil {
+		unlocking = s.sources[0].script
+	}
+	locking := s.sources[1].script
+
+	opts := []interpreter.ExecutionOptionFunc{interpreter.WithScripts(locking, unlocking)}
+	if s.launch.Tx != "" {
+		tx, err := bt.NewTxFromString(s.launch.Tx)
+		if err != nil {
+			return nil, err
+		}
+		if s.launch.InputIndex < 0 || s.launch.InputIndex >= tx.InputCount() {
+			return nil, errors.Wrapf(ErrInvalidInput, "%d", s.launch.InputIndex)
+		}
+		if unlocking != nil {
+			tx.Inputs[s.launch.InputIndex].UnlockingScript = unlocking
+		}
+		opts = []interpreter.ExecutionOptionFunc{
+			interpreter.WithTx(tx, s.launch.InputIndex, &bt.Output{LockingScript: locking, Satoshis: s.launch.Satoshis}),
+			interpreter.WithForkID(),
+		}
+	}
+	if s.launch.AfterGenesis {
+		opts = append(opts, interpreter.WithAfterGenesis())
+	}
+
+	return opts, nil
+}
+
+func (s *Server) execute(opts []interpreter.ExecutionOptionFunc) {
+	defer close(s.done)
+
+	debugger := debug.NewDebugger()
+	debugger.AttachBeforeStep(s.beforeStep)
+	debugger.AttachAfterError(func(state *interpreter.State, err error) {
+		s.pause(state, stoppedEvent{Reason: "exception", Description: "Script failed", Text: err.Error()})
+	})
+
+	err := interpreter.NewContextEngine().ExecuteContext(s.ctx, append(opts, interpreter.WithDebugger(debugger))...)
+
+	result, code := "script execution succeeded\n", 0
+	if err != nil {
+		result, code = fmt.Sprintf("script execution failed: %s\n", err), 1
+	}
+	_ = s.conn.event("output", map[string]interface{}{"category": "console", "output": result})
+	_ = s.conn.event("exited", map[string]interface{}{"exitCode": code})
+	_ = s.conn.event("terminated", nil)
+}
+
+// beforeStep pauses the execution if stepping or a breakpoint is hit.
+func (s *Server) beforeStep(state *interpreter.State) {
+	entry := !s.entered
+	s.entered = true
+
+	switch {
+	case s.mode == modeStepIn:
+	case s.mode == modeStepOver && (state.ScriptIdx != s.stepScript || len(state.CondStack) <= s.stepDepth):
+	case s.breakpointHit(state):
+		s.pause(state, stoppedEvent{Reason: "breakpoint"})
+		return
+	default:
+		return
+	}
+
+	reason := "step"
+	if entry {
+		reason = "entry"
+	}
+	s.pause(state, stoppedEvent{Reason: reason})
+}
+
+func (s *Server) breakpointHit(state *interpreter.State) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sm := s.sourceMap(state.ScriptIdx)
+	if sm == nil {
+		return false
+	}
+	line := sm.line(state.OpcodeIdx)
+
+	return s.breakpoints[filepath.Clean(sm.path)][line] && sm.opcodeAt(line) == state.OpcodeIdx
+}
+
+// pause blocks the execution until the client resumes it, or disconnects.
+func (s *Server) pause(state *interpreter.State, ev stoppedEvent) {
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.state = state
+	s.stopped = true
+	s.mu.Unlock()
+
+	ev.ThreadID = threadID
+	ev.AllThreadsStopped = true
+	_ = s.conn.event("stopped", ev)
+
+	select {
+	case mode := <-s.resume:
+		s.mode = mode
+		s.stepScript = state.ScriptIdx
+		s.stepDepth = len(state.CondStack)
+	case <-s.ctx.Done():
+	}
+}
+
+// step resumes the paused execution.
+func (s *Server) step(mode stepMode) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.mu.Unlock()
+		return ErrNotPaused
+	}
+	s.stopped = false
+	s.mu.Unlock()
+
+	s.resume <- mode
+	return nil
+}
+
+func (s *Server) isStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+// sourceMap returns the source of the script, if it has one. Must be
+// called with the lock held.
+func (s *Server) sourceMap(scriptIdx int) *sourceMap {
+	if scriptIdx < 0 || scriptIdx >= len(s.sources) {
+		return nil
+	}
+
+	return s.sources[scriptIdx]
+}
+
+func (s *Server) stackTrace() []stackFrame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil || !s.stopped {
+		return []stackFrame{}
+	}
+
+	frame := stackFrame{
+		ID:     1,
+		Name:   fmt.Sprintf("script %d opcode %d", s.state.ScriptIdx, s.state.OpcodeIdx),
+		Column: 1,
+	}
+	if s.state.ScriptIdx < len(s.state.Scripts) && s.state.OpcodeIdx < len(s.state.Scripts[s.state.ScriptIdx]) {
+		frame.Name = s.state.Opcode().Name()
+	}
+	if sm := s.sourceMap(s.state.ScriptIdx); sm != nil {
+		frame.Source = &source{Name: filepath.Base(sm.path), Path: sm.path}
+		frame.Line = sm.line(s.state.OpcodeIdx)
+	}
+
+	return []stackFrame{frame}
+}
+
+// variables returns the items of a stack, the top first.
+func (s *Server) variables(ref int) []variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vv := []variable{}
+	if s.state == nil || !s.stopped {
+		return vv
+	}
+
+	switch ref {
+	case dataStackRef, altStackRef:
+		stack := s.state.DataStack
+		if ref == altStackRef {
+			stack = s.state.AltStack
+		}
+		for i := len(stack) - 1; i >= 0; i-- {
+			vv = append(vv, variable{
+				Name:  fmt.Sprintf("%d", len(stack)-1-i),
+				Value: "0x" + hex.EncodeToString(stack[i]),
+			})
+		}
+	case condStackRef:
+		names := []string{"false", "true", "skip"}
+		for i := len(s.state.CondStack) - 1; i >= 0; i-- {
+			v := fmt.Sprintf("%d", s.state.CondStack[i])
+			if c := s.state.CondStack[i]; c >= 0 && c < len(names) {
+				v = names[c]
+			}
+			vv = append(vv, variable{Name: fmt.Sprintf("%d", len(s.state.CondStack)-1-i), Value: v})
+		}
+	}
+
+	return vv
+}
@@ -0,0 +1,303 @@
+package dap_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter/debug/dap"
+)
+
+const lockingASM = `# add the unlocking values
+OP_ADD
+5 OP_EQUALVERIFY
+OP_1 OP_IF
+  OP_1
+OP_ELSE
+  OP_0
+OP_ENDIF
+OP_NOP
+`
+
+type client struct {
+	t       *testing.T
+	w       io.WriteCloser
+	msgs    chan map[string]interface{}
+	pending []map[string]interface{}
+	seq     int
+	done    chan error
+}
+
+func newClient(t *testing.T) *client {
+	sr, cw := io.Pipe()
+	cr, sw := io.Pipe()
+
+	c := &client{t: t, w: cw, msgs: make(chan map[string]interface{}, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- dap.NewServer(sr, sw).Serve()
+		_ = sw.Close()
+	}()
+	go func() {
+		r := textproto.NewReader(bufio.NewReader(cr))
+		for {
+			hdr, err := r.ReadMIMEHeader()
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+			n, _ := strconv.Atoi(hdr.Get("Content-Length"))
+			b := make([]byte, n)
+			if _, err := io.ReadFull(r.R, b); err != nil {
+				close(c.msgs)
+				return
+			}
+			var m map[string]interface{}
+			require.NoError(t, json.Unmarshal(b, &m))
+			c.msgs <- m
+		}
+	}()
+
+	return c
+}
+
+func (c *client) send(cmd string, args interface{}) {
+	c.seq++
+	b, err := json.Marshal(map[string]interface{}{"seq": c.seq, "type": "request", "command": cmd, "arguments": args})
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	require.NoError(c.t, err)
+}
+
+// expect returns the next event, or response to the command, with the name.
+func (c *client) expect(typ, name string) map[string]interface{} {
+	key := map[string]string{"event": "event", "response": "command"}[typ]
+	for i, m := range c.pending {
+		if m["type"] == typ && m[key] == name {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return m
+		}
+	}
+
+	for {
+		select {
+		case m, ok := <-c.msgs:
+			require.True(c.t, ok, "connection closed waiting for %s %s", typ, name)
+			if m["type"] == typ && m[key] == name {
+				return m
+			}
+			c.pending = append(c.pending, m)
+		case <-time.After(5 * time.Second):
+			require.FailNow(c.t, "timed out waiting for "+typ+" "+name)
+		}
+	}
+}
+
+func (c *client) request(cmd string, args interface{}) map[string]interface{} {
+	c.send(cmd, args)
+	resp := c.expect("response", cmd)
+	require.Equal(c.t, true, resp["success"], resp["message"])
+	body, _ := resp["body"].(map[string]interface{})
+	return body
+}
+
+func (c *client) launch(args map[string]interface{}, breakpoints map[string][]int) {
+	c.request("initialize", map[string]interface{}{"adapterID": "bscript"})
+	c.expect("event", "initialized")
+	c.request("launch", args)
+	for path, lines := range breakpoints {
+		bps := make([]map[string]interface{}, len(lines))
+		for i, l := range lines {
+			bps[i] = map[string]interface{}{"line": l}
+		}
+		c.request("setBreakpoints", map[string]interface{}{"source": map[string]interface{}{"path": path}, "breakpoints": bps})
+	}
+	c.request("configurationDone", nil)
+}
+
+func (c *client) stopped(reason string) map[string]interface{} {
+	ev := c.expect("event", "stopped")
+	body := ev["body"].(map[string]interface{})
+	require.Equal(c.t, reason, body["reason"])
+	return body
+}
+
+func (c *client) frame() map[string]interface{} {
+	body := c.request("stackTrace", map[string]interface{}{"threadId": 1})
+	frames := body["stackFrames"].([]interface{})
+	require.Len(c.t, frames, 1)
+	return frames[0].(map[string]interface{})
+}
+
+func (c *client) variables(ref int) []string {
+	body := c.request("variables", map[string]interface{}{"variablesReference": ref})
+	vv := make([]string, 0)
+	for _, v := range body["variables"].([]interface{}) {
+		vv = append(vv, v.(map[string]interface{})["value"].(string))
+	}
+	return vv
+}
+
+func (c *client) close() {
+	c.request("disconnect", nil)
+	require.NoError(c.t, <-c.done)
+	_ = c.w.Close()
+}
+
+func writeSources(t *testing.T, unlocking string) (string, string) {
+	dir := t.TempDir()
+	lpath := filepath.Join(dir, "locking.asm")
+	upath := filepath.Join(dir, "unlocking.asm")
+	require.NoError(t, os.WriteFile(lpath, []byte(lockingASM), 0o600))
+	require.NoError(t, os.WriteFile(upath, []byte(unlocking), 0o600))
+	return lpath, upath
+}
+
+func TestServer_Breakpoints(t *testing.T) {
+	t.Parallel()
+
+	lpath, upath := writeSources(t, "2\n3\n")
+	c := newClient(t)
+
+	c.request("initialize", map[string]interface{}{"adapterID": "bscript"})
+	c.expect("event", "initialized")
+	c.request("launch", map[string]interface{}{"program": lpath, "unlockingProgram": upath, "afterGenesis": true})
+	body := c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": lpath},
+		"breakpoints": []map[string]interface{}{{"line": 3}, {"line": 1}},
+	})
+	bps := body["breakpoints"].([]interface{})
+	require.Len(t, bps, 2)
+	assert.Equal(t, true, bps[0].(map[string]interface{})["verified"])
+	assert.Equal(t, false, bps[1].(map[string]interface{})["verified"])
+	c.request("configurationDone", nil)
+
+	c.stopped("breakpoint")
+	frame := c.frame()
+	assert.Equal(t, "OP_5", frame["name"])
+	assert.Equal(t, float64(3), frame["line"])
+	assert.Equal(t, lpath, frame["source"].(map[string]interface{})["path"])
+
+	scopes := c.request("scopes", map[string]interface{}{"frameId": 1})["scopes"].([]interface{})
+	require.Len(t, scopes, 3)
+	ref := int(scopes[0].(map[string]interface{})["variablesReference"].(float64))
+	assert.Equal(t, []string{"0x05"}, c.variables(ref))
+
+	c.request("continue", map[string]interface{}{"threadId": 1})
+	exited := c.expect("event", "exited")
+	assert.Equal(t, float64(0), exited["body"].(map[string]interface{})["exitCode"])
+	c.expect("event", "terminated")
+
+	c.close()
+}
+
+func TestServer_Stepping(t *testing.T) {
+	t.Parallel()
+
+	lpath, upath := writeSources(t, "2\n3\n")
+	c := newClient(t)
+	c.launch(map[string]interface{}{
+		"program": lpath, "unlockingProgram": upath, "afterGenesis": true, "stopOnEntry": true,
+	}, map[string][]int{lpath: {4}})
+
+	c.stopped("entry")
+	frame := c.frame()
+	assert.Equal(t, "OP_2", frame["name"])
+	assert.Equal(t, upath, frame["source"].(map[string]interface{})["path"])
+	assert.Equal(t, float64(1), frame["line"])
+
+	c.request("stepIn", map[string]interface{}{"threadId": 1})
+	c.stopped("step")
+	assert.Equal(t, float64(2), c.frame()["line"])
+
+	c.request("continue", map[string]interface{}{"threadId": 1})
+	c.stopped("breakpoint")
+	assert.Equal(t, "OP_1", c.frame()["name"])
+
+	c.request("stepIn", map[string]interface{}{"threadId": 1})
+	c.stopped("step")
+	assert.Equal(t, "OP_IF", c.frame()["name"])
+
+	c.request("stepIn", map[string]interface{}{"threadId": 1})
+	c.stopped("step")
+	frame = c.frame()
+	assert.Equal(t, "OP_1", frame["name"])
+	assert.Equal(t, float64(5), frame["line"])
+	assert.Equal(t, []string{"true"}, c.variables(3))
+
+	// Debug again, stepping over the OP_IF block instead.
+	c.close()
+
+	c = newClient(t)
+	c.launch(map[string]interface{}{
+		"program": lpath, "unlockingProgram": upath, "afterGenesis": true,
+	}, map[string][]int{lpath: {4}})
+	c.stopped("breakpoint")
+	c.request("stepIn", map[string]interface{}{"threadId": 1})
+	c.stopped("step")
+	assert.Equal(t, "OP_IF", c.frame()["name"])
+
+	c.request("next", map[string]interface{}{"threadId": 1})
+	c.stopped("step")
+	frame = c.frame()
+	assert.Equal(t, "OP_NOP", frame["name"])
+	assert.Equal(t, float64(9), frame["line"])
+	assert.Empty(t, c.variables(3))
+
+	c.close()
+}
+
+func TestServer_Exception(t *testing.T) {
+	t.Parallel()
+
+	lpath, upath := writeSources(t, "2\n2\n")
+	c := newClient(t)
+	c.launch(map[string]interface{}{"program": lpath, "unlockingProgram": upath, "afterGenesis": true}, nil)
+
+	body := c.stopped("exception")
+	assert.Contains(t, body["text"], "OP_EQUALVERIFY failed")
+
+	c.request("continue", map[string]interface{}{"threadId": 1})
+	output := c.expect("event", "output")
+	assert.Contains(t, output["body"].(map[string]interface{})["output"], "script execution failed")
+	exited := c.expect("event", "exited")
+	assert.Equal(t, float64(1), exited["body"].(map[string]interface{})["exitCode"])
+
+	c.close()
+}
+
+func TestServer_Errors(t *testing.T) {
+	t.Parallel()
+
+	lpath, _ := writeSources(t, "")
+	c := newClient(t)
+	c.request("initialize", nil)
+
+	tests := map[string]struct {
+		cmd  string
+		args interface{}
+	}{
+		"launch without program":     {cmd: "launch", args: map[string]interface{}{}},
+		"launch missing file":        {cmd: "launch", args: map[string]interface{}{"program": lpath + ".missing"}},
+		"configuration not launched": {cmd: "configurationDone"},
+		"continue not paused":        {cmd: "continue"},
+		"unsupported":                {cmd: "evaluate"},
+	}
+	for name, test := range tests {
+		c.send(test.cmd, test.args)
+		resp := c.expect("response", test.cmd)
+		assert.Equal(t, false, resp["success"], name)
+		assert.NotEmpty(t, resp["message"], name)
+	}
+
+	c.close()
+}
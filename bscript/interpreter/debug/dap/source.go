@@ -0,0 +1,91 @@
+package dap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// sourceMap maps the opcodes of a script assembled from an ASM source file
+// to the lines they were assembled from.
+type sourceMap struct {
+	path   string
+	script *bscript.Script
+	// lines is the 1 based line of each parsed opcode.
+	lines []int
+}
+
+// loadSource assembles the ASM source file at path.
+func loadSource(path string) (*sourceMap, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return assembleSource(path, string(b))
+}
+
+// assembleSource assembles the source a line at a time, so each opcode can
+// be traced back to its line. Tokens therefore can't span lines.
+func assembleSource(path, src string) (*sourceMap, error) {
+	sm := &sourceMap{path: path, script: &bscript.Script{}}
+
+	// ends is the byte offset in the script at which each line ends.
+	var ends []int
+	for i, line := range strings.Split(src, "\n") {
+		s, err := bscript.Assemble(line, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s:%d", path, i+1)
+		}
+		*sm.script = append(*sm.script, *s...)
+		ends = append(ends, len(*sm.script))
+	}
+
+	p := &interpreter.DefaultOpcodeParser{}
+	ps, err := p.Parse(sm.script)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	var off, line int
+	for _, op := range ps {
+		for line < len(ends)-1 && off >= ends[line] {
+			line++
+		}
+		sm.lines = append(sm.lines, line+1)
+
+		b, err := p.Unparse(interpreter.ParsedScript{op})
+		if err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+		off += len(*b)
+	}
+
+	return sm, nil
+}
+
+// line returns the line of the opcode at the index, or 0 if unknown.
+func (sm *sourceMap) line(opcodeIdx int) int {
+	if sm == nil || opcodeIdx < 0 || opcodeIdx >= len(sm.lines) {
+		return 0
+	}
+
+	return sm.lines[opcodeIdx]
+}
+
+// opcodeAt returns the index of the first opcode on the line,
+// or -1 if there are none.
+func (sm *sourceMap) opcodeAt(line int) int {
+	for i, l := range sm.lines {
+		if l == line {
+			return i
+		}
+	}
+
+	return -1
+}
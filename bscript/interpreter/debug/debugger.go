@@ -37,6 +37,8 @@ type DefaultDebugger interface {
 	AttachBeforeStackPop(ThreadStateFunc)
 	AttachAfterStackPop(StackFunc)
 
+	History() *History
+
 	interpreter.Debugger
 }
 
@@ -61,6 +63,8 @@ type debugger struct {
 
 	beforeStackPopFns []ThreadStateFunc
 	afterStackPopFns  []StackFunc
+
+	history *History
 }
 
 // NewDebugger returns an empty debugger which is to be configured with the `Attach`
@@ -82,7 +86,7 @@ func NewDebugger(oo ...DebuggerOptionFunc) DefaultDebugger {
 		o(opts)
 	}
 
-	return &debugger{
+	d := &debugger{
 		beforeExecuteFns: make([]ThreadStateFunc, 0),
 		afterExecuteFns:  make([]ThreadStateFunc, 0),
 
@@ -100,7 +104,24 @@ func NewDebugger(oo ...DebuggerOptionFunc) DefaultDebugger {
 
 		beforeStackPopFns: make([]ThreadStateFunc, 0),
 		afterStackPopFns:  make([]StackFunc, 0),
+
+		history: &History{},
+	}
+
+	if opts.rewind {
+		d.AttachBeforeExecute(d.history.reset)
+		d.AttachBeforeStep(d.history.record)
+		d.AttachAfterExecute(d.history.record)
+		d.AttachAfterError(d.history.recordErr)
 	}
+
+	return d
+}
+
+// History returns the states recorded during the last execution, which is
+// empty unless the debugger was configured WithRewind.
+func (d *debugger) History() *History {
+	return d.history
 }
 
 // AttachBeforeExecute attach the provided function to be executed before
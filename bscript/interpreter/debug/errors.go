@@ -0,0 +1,9 @@
+package debug
+
+import "github.com/pkg/errors"
+
+// Sentinel errors raised by the rewind history.
+var (
+	ErrFrameOutOfRange   = errors.New("frame out of range")
+	ErrOpcodeNotExecuted = errors.New("opcode was not executed")
+)
@@ -0,0 +1,180 @@
+package debug
+
+import (
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// Breakpoint reports whether execution should stop at the provided state.
+type Breakpoint func(state *interpreter.State) bool
+
+// BreakOnOpcode break before any opcode with the provided value is executed.
+func BreakOnOpcode(op byte) Breakpoint {
+	return func(state *interpreter.State) bool {
+		return !state.IsFinished && state.Opcode().Value() == op
+	}
+}
+
+// BreakAt break before the opcode at the provided index of the provided
+// script is executed.
+func BreakAt(scriptIdx, opcodeIdx int) Breakpoint {
+	return func(state *interpreter.State) bool {
+		return !state.IsFinished && state.ScriptIdx == scriptIdx && state.OpcodeIdx == opcodeIdx
+	}
+}
+
+// BreakOnScript break before the first opcode of the script with the
+// provided index is executed.
+func BreakOnScript(scriptIdx int) Breakpoint {
+	return BreakAt(scriptIdx, 0)
+}
+
+// BreakOnStack break when the provided condition on the data stack, where
+// the last item is the top of the stack, is true.
+func BreakOnStack(fn func(stack [][]byte) bool) Breakpoint {
+	return func(state *interpreter.State) bool {
+		return fn(state.DataStack)
+	}
+}
+
+// History the states recorded during an execution by a debugger configured
+// WithRewind, which can be stepped through in either direction.
+//
+// There is a frame for the state before each step, and a final frame for
+// the state once execution has finished. Any frame can be re-executed from
+// by providing its state to the engine with interpreter.WithState.
+type History struct {
+	states      []*interpreter.State
+	pos         int
+	breakpoints []Breakpoint
+	err         error
+}
+
+func (h *History) reset(*interpreter.State) {
+	h.states = nil
+	h.pos = 0
+	h.err = nil
+}
+
+func (h *History) record(state *interpreter.State) {
+	h.states = append(h.states, state)
+}
+
+func (h *History) recordErr(_ *interpreter.State, err error) {
+	h.err = err
+}
+
+// Len returns the number of frames recorded.
+func (h *History) Len() int {
+	return len(h.states)
+}
+
+// Pos returns the index of the current frame.
+func (h *History) Pos() int {
+	return h.pos
+}
+
+// State returns the state of the current frame, or nil if nothing
+// was recorded.
+func (h *History) State() *interpreter.State {
+	if len(h.states) == 0 {
+		return nil
+	}
+
+	return h.states[h.pos]
+}
+
+// Err returns the error the execution failed with, if any.
+func (h *History) Err() error {
+	return h.err
+}
+
+// StepForward moves to the next frame, returning false if already at
+// the last frame.
+func (h *History) StepForward() bool {
+	if h.pos >= len(h.states)-1 {
+		return false
+	}
+	h.pos++
+
+	return true
+}
+
+// StepBack moves to the previous frame, returning false if already at
+// the first frame.
+func (h *History) StepBack() bool {
+	if h.pos == 0 {
+		return false
+	}
+	h.pos--
+
+	return true
+}
+
+// Jump moves to the frame with the provided index.
+func (h *History) Jump(frame int) error {
+	if frame < 0 || frame >= len(h.states) {
+		return ErrFrameOutOfRange
+	}
+	h.pos = frame
+
+	return nil
+}
+
+// JumpToOpcode moves to the frame before the opcode at the provided
+// index of the provided script was executed.
+func (h *History) JumpToOpcode(scriptIdx, opcodeIdx int) error {
+	at := BreakAt(scriptIdx, opcodeIdx)
+	for i, state := range h.states {
+		if at(state) {
+			h.pos = i
+			return nil
+		}
+	}
+
+	return ErrOpcodeNotExecuted
+}
+
+// AddBreakpoint adds a breakpoint, to be stopped at by Continue and
+// ReverseContinue.
+func (h *History) AddBreakpoint(b Breakpoint) {
+	h.breakpoints = append(h.breakpoints, b)
+}
+
+// ClearBreakpoints removes all breakpoints.
+func (h *History) ClearBreakpoints() {
+	h.breakpoints = nil
+}
+
+// Continue moves forward to the next frame which hits a breakpoint,
+// returning false and stopping at the last frame if none is hit.
+func (h *History) Continue() bool {
+	for h.StepForward() {
+		if h.hit() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReverseContinue moves back to the previous frame which hits a breakpoint,
+// returning false and stopping at the first frame if none is hit.
+func (h *History) ReverseContinue() bool {
+	for h.StepBack() {
+		if h.hit() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *History) hit() bool {
+	for _, b := range h.breakpoints {
+		if b(h.states[h.pos]) {
+			return true
+		}
+	}
+
+	return false
+}
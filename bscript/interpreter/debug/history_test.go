@@ -0,0 +1,177 @@
+package debug_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/debug"
+)
+
+func executeWithRewind(t *testing.T, lockingScriptHex, unlockingScriptHex string) (*debug.History, error) {
+	lscript, err := bscript.NewFromHexString(lockingScriptHex)
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromHexString(unlockingScriptHex)
+	require.NoError(t, err)
+
+	debugger := debug.NewDebugger(debug.WithRewind())
+	err = interpreter.NewEngine().Execute(
+		interpreter.WithScripts(lscript, uscript),
+		interpreter.WithAfterGenesis(),
+		interpreter.WithDebugger(debugger),
+	)
+
+	return debugger.History(), err
+}
+
+func TestHistory_Navigation(t *testing.T) {
+	t.Parallel()
+
+	h, err := executeWithRewind(t, "5253958852529387", "5456")
+	require.NoError(t, err)
+	assert.NoError(t, h.Err())
+
+	// A frame before each of the 10 opcodes, and one once finished.
+	require.Equal(t, 11, h.Len())
+	assert.Equal(t, 0, h.Pos())
+	assert.Equal(t, "OP_4", h.State().Opcode().Name())
+	assert.False(t, h.StepBack())
+
+	assert.True(t, h.StepForward())
+	assert.Equal(t, "OP_6", h.State().Opcode().Name())
+	assert.Equal(t, [][]byte{{4}}, h.State().DataStack)
+	assert.True(t, h.StepBack())
+	assert.Equal(t, 0, h.Pos())
+
+	require.NoError(t, h.Jump(10))
+	assert.True(t, h.State().IsFinished)
+	assert.Equal(t, [][]byte{{1}}, h.State().DataStack)
+	assert.False(t, h.StepForward())
+
+	require.NoError(t, h.JumpToOpcode(1, 2))
+	assert.Equal(t, 4, h.Pos())
+	assert.Equal(t, "OP_MUL", h.State().Opcode().Name())
+	assert.Equal(t, [][]byte{{4}, {6}, {2}, {3}}, h.State().DataStack)
+
+	assert.True(t, errors.Is(h.Jump(11), debug.ErrFrameOutOfRange))
+	assert.True(t, errors.Is(h.Jump(-1), debug.ErrFrameOutOfRange))
+	assert.True(t, errors.Is(h.JumpToOpcode(1, 8), debug.ErrOpcodeNotExecuted))
+	assert.Equal(t, 4, h.Pos())
+}
+
+func TestHistory_Breakpoints(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		breakpoint debug.Breakpoint
+		expForward []int
+		expReverse int
+	}{
+		"opcode": {
+			breakpoint: debug.BreakOnOpcode(bscript.Op2),
+			expForward: []int{2, 6, 7},
+			expReverse: 7,
+		},
+		"position": {
+			breakpoint: debug.BreakAt(1, 4),
+			expForward: []int{6},
+			expReverse: 6,
+		},
+		"script": {
+			breakpoint: debug.BreakOnScript(1),
+			expForward: []int{2},
+			expReverse: 2,
+		},
+		"stack": {
+			breakpoint: debug.BreakOnStack(func(stack [][]byte) bool {
+				return len(stack) == 3
+			}),
+			expForward: []int{3, 5, 8},
+			expReverse: 8,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			h, err := executeWithRewind(t, "5253958852529387", "5456")
+			require.NoError(t, err)
+			h.AddBreakpoint(test.breakpoint)
+
+			hits := make([]int, 0)
+			for h.Continue() {
+				hits = append(hits, h.Pos())
+			}
+			assert.Equal(t, test.expForward, hits)
+			assert.Equal(t, h.Len()-1, h.Pos())
+
+			require.True(t, h.ReverseContinue())
+			assert.Equal(t, test.expReverse, h.Pos())
+
+			h.ClearBreakpoints()
+			assert.False(t, h.ReverseContinue())
+			assert.Equal(t, 0, h.Pos())
+		})
+	}
+}
+
+func TestHistory_Error(t *testing.T) {
+	t.Parallel()
+
+	h, err := executeWithRewind(t, "5253958852529387", "5457")
+	require.Error(t, err)
+	assert.Equal(t, err, h.Err())
+
+	// The frames up to and including the failed OP_EQUALVERIFY, and the
+	// state it failed in.
+	require.Equal(t, 7, h.Len())
+	require.NoError(t, h.Jump(5))
+	assert.Equal(t, "OP_EQUALVERIFY", h.State().Opcode().Name())
+	assert.Equal(t, [][]byte{{4}, {7}, {6}}, h.State().DataStack)
+}
+
+func TestHistory_Resume(t *testing.T) {
+	t.Parallel()
+
+	lscript, err := bscript.NewFromHexString("5253958852529387")
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromHexString("5457")
+	require.NoError(t, err)
+
+	h, err := executeWithRewind(t, "5253958852529387", "5457")
+	require.Error(t, err)
+
+	// Fix the unlocking value before the failing opcode and re-execute from there.
+	require.NoError(t, h.JumpToOpcode(1, 3))
+	state := h.State()
+	state.DataStack[1] = []byte{6}
+
+	debugger := debug.NewDebugger(debug.WithRewind())
+	assert.NoError(t, interpreter.NewEngine().Execute(
+		interpreter.WithScripts(lscript, uscript),
+		interpreter.WithAfterGenesis(),
+		interpreter.WithDebugger(debugger),
+		interpreter.WithState(state),
+	))
+	assert.Equal(t, 6, debugger.History().Len())
+}
+
+func TestHistory_WithoutRewind(t *testing.T) {
+	t.Parallel()
+
+	lscript, err := bscript.NewFromHexString("5253958852529387")
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromHexString("5456")
+	require.NoError(t, err)
+
+	debugger := debug.NewDebugger()
+	require.NoError(t, interpreter.NewEngine().Execute(
+		interpreter.WithScripts(lscript, uscript),
+		interpreter.WithDebugger(debugger),
+	))
+	assert.Zero(t, debugger.History().Len())
+	assert.Nil(t, debugger.History().State())
+}
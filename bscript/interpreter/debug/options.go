@@ -4,7 +4,8 @@ package debug
 type DebuggerOptionFunc func(o *debugOpts)
 
 // WithRewind configure the debugger to enable rewind functionality. When
-// enabled, the debugger will save each stack frame from BeforeStep to memory.
+// enabled, the debugger will save the state from each BeforeStep to memory,
+// which can be stepped through after execution from History.
 func WithRewind() DebuggerOptionFunc {
 	return func(o *debugOpts) {
 		o.rewind = true
@@ -30,6 +30,13 @@ is used to prove the spender is authorized to perform the transaction.
 One benefit of using a scripting language is added flexibility in specifying
 what conditions must be met in order to spend bitcoins.
 
+Compiled Scripts
+
+Scripts are parsed on each execution.  A locking script executed many times,
+such as a template shared by many outputs, can be parsed once with Compile and
+executed with the WithCompiledLockingScript option.  A CompiledScript is
+immutable and can be shared across concurrent executions.
+
 Errors
 
 Errors returned by this package are of type interpreter.Error.  This allows the
@@ -38,5 +45,9 @@ ErrorCode field of the type asserted interpreter.Error while still providing ric
 error messages with contextual information.  A convenience function named
 IsErrorCode is also provided to allow callers to easily check for a specific
 error code.  See ErrorCode in the package documentation for a full list.
+
+Errors raised executing an opcode also carry its Position, identifying the
+script and offset the execution failed at.  A snapshot of the top of the
+stack can be included in errors with the WithErrorStack option.
 */
 package interpreter
@@ -5,11 +5,20 @@
 
 package interpreter
 
+import "context"
+
 // Engine is the virtual machine that executes scripts.
 type Engine interface {
 	Execute(opts ...ExecutionOptionFunc) error
 }
 
+// ContextEngine is an Engine which can also execute
+// scripts until a context is done.
+type ContextEngine interface {
+	Engine
+	ExecuteContext(ctx context.Context, opts ...ExecutionOptionFunc) error
+}
+
 type engine struct{}
 
 // NewEngine returns a new script engine for the provided locking script
@@ -20,6 +29,12 @@ func NewEngine() Engine {
 	return &engine{}
 }
 
+// NewContextEngine returns a new script engine which can
+// execute scripts with a context, see ExecuteContext.
+func NewContextEngine() ContextEngine {
+	return &engine{}
+}
+
 // Execute will execute all scripts in the script engine and return either nil
 // for successful validation or an error if one occurred.
 //
@@ -42,7 +57,25 @@ func NewEngine() Engine {
 //  }
 //
 func (e *engine) Execute(oo ...ExecutionOptionFunc) error {
-	opts := &execOpts{}
+	return e.ExecuteContext(context.Background(), oo...)
+}
+
+// ExecuteContext will execute all scripts in the script engine, checking
+// for the context being cancelled between each opcode, and return either
+// nil for successful validation or an error if one occurred.
+//
+// An errs.ErrExecutionCancelled is returned if the context is done before
+// the execution completes. Combine with WithBudget to bound the execution
+// of untrusted scripts:
+//  if err := engine.ExecuteContext(ctx,
+//      interpreter.WithScripts(lockingScript, unlockingScript),
+//      interpreter.WithAfterGenesis(),
+//      interpreter.WithBudget(interpreter.Budget{MaxOpcodes: 10000, MaxDuration: time.Second}),
+//  ); err != nil {
+//      // handle err
+//  }
+func (e *engine) ExecuteContext(ctx context.Context, oo ...ExecutionOptionFunc) error {
+	opts := &execOpts{ctx: ctx}
 	for _, o := range oo {
 		o(opts)
 	}
@@ -51,6 +84,7 @@ func (e *engine) Execute(oo ...ExecutionOptionFunc) error {
 	if err != nil {
 		return err
 	}
+	defer t.release()
 
 	if err := t.execute(); err != nil {
 		t.afterError(err)
@@ -5,13 +5,18 @@
 package interpreter
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/libsv/go-bt/v2"
 	"github.com/libsv/go-bt/v2/bscript"
 	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
 	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+	"github.com/libsv/go-bt/v2/chaincfg"
 	"github.com/libsv/go-bt/v2/sighash"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -58,7 +63,7 @@ func TestBadPC(t *testing.T) {
 	for _, test := range tests {
 		vm := &thread{
 			scriptParser: &DefaultOpcodeParser{},
-			cfg:          &beforeGenesisConfig{},
+			cfg:          newLimitsConfig(nil, false),
 		}
 		err := vm.apply(&execOpts{
 			previousTxOut: txOut,
@@ -114,7 +119,7 @@ func TestCheckErrorCondition(t *testing.T) {
 
 	vm := &thread{
 		scriptParser: &DefaultOpcodeParser{},
-		cfg:          &beforeGenesisConfig{},
+		cfg:          newLimitsConfig(nil, false),
 	}
 
 	err = vm.apply(&execOpts{
@@ -434,7 +439,7 @@ func TestInvalidFlagCombinations(t *testing.T) {
 	for i, test := range tests {
 		vm := &thread{
 			scriptParser: &DefaultOpcodeParser{},
-			cfg:          &beforeGenesisConfig{},
+			cfg:          newLimitsConfig(nil, false),
 		}
 		err := vm.apply(&execOpts{
 			tx:            tx,
@@ -995,3 +1000,328 @@ func TestExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestWithParams(t *testing.T) {
+	// a 5 byte number is only valid in an after-genesis context
+	unlockingScript, err := bscript.NewFromHexString("050000000001")
+	require.NoError(t, err)
+	lockingScript, err := bscript.NewFromASM("OP_1ADD OP_DROP OP_TRUE")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		params *chaincfg.Params
+		height uint32
+		expErr bool
+	}{
+		"mainnet before genesis": {
+			params: &chaincfg.MainNet,
+			height: chaincfg.MainNet.GenesisActivationHeight - 1,
+			expErr: true,
+		},
+		"mainnet after genesis": {
+			params: &chaincfg.MainNet,
+			height: chaincfg.MainNet.GenesisActivationHeight,
+		},
+		"regtest after genesis": {
+			params: &chaincfg.RegTest,
+			height: chaincfg.RegTest.GenesisActivationHeight + 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NewEngine().Execute(
+				WithScripts(lockingScript, unlockingScript),
+				WithParams(test.params, test.height),
+			)
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestExecute_ForHeight(t *testing.T) {
+	tx, err := bt.NewTxFromString(txHex1)
+	require.NoError(t, err)
+	prevTx, err := bt.NewTxFromString(prevTxHex1)
+	require.NoError(t, err)
+
+	prevOutput := prevTx.OutputIdx(int(tx.Inputs[0].PreviousTxOutIndex))
+	consensus, policy := scriptflag.ForHeight(&chaincfg.MainNet, 700000, 700000)
+
+	for _, flags := range []scriptflag.Flag{consensus, policy} {
+		assert.NoError(t, NewEngine().Execute(
+			WithTx(tx, 0, prevOutput),
+			WithFlags(flags),
+		))
+	}
+}
+
+func TestWithConfig(t *testing.T) {
+	unlockingScript, err := bscript.NewFromASM("OP_TRUE")
+	require.NoError(t, err)
+	lockingScript, err := bscript.NewFromASM("OP_NOP OP_NOP OP_NOP")
+	require.NoError(t, err)
+
+	bigLockingScript := &bscript.Script{}
+	require.NoError(t, bigLockingScript.AppendPushData(make([]byte, MaxScriptSizePolicyAfterGenesis)))
+	require.NoError(t, bigLockingScript.AppendOpcodes(bscript.OpDROP))
+
+	limits := ConsensusConfig
+	limits.AfterGenesis.MaxOps = 2
+
+	tests := map[string]struct {
+		lockingScript *bscript.Script
+		opts          []ExecutionOptionFunc
+		expErr        errs.ErrorCode
+	}{
+		"consensus": {
+			lockingScript: lockingScript,
+			opts:          []ExecutionOptionFunc{WithConfig(ConsensusConfig), WithAfterGenesis()},
+		},
+		"custom max ops": {
+			lockingScript: lockingScript,
+			opts:          []ExecutionOptionFunc{WithConfig(limits), WithAfterGenesis()},
+			expErr:        errs.ErrTooManyOperations,
+		},
+		"custom max ops before genesis is unchanged": {
+			lockingScript: lockingScript,
+			opts:          []ExecutionOptionFunc{WithConfig(limits)},
+		},
+		"consensus big script": {
+			lockingScript: bigLockingScript,
+			opts:          []ExecutionOptionFunc{WithAfterGenesis()},
+		},
+		"default policy big script": {
+			lockingScript: bigLockingScript,
+			opts:          []ExecutionOptionFunc{WithConfig(DefaultPolicyConfig), WithAfterGenesis()},
+			expErr:        errs.ErrScriptTooBig,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := append([]ExecutionOptionFunc{WithScripts(test.lockingScript, unlockingScript)}, test.opts...)
+			err := NewEngine().Execute(opts...)
+			if test.expErr != 0 {
+				assert.True(t, errs.IsErrorCode(err, test.expErr), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestStackMemoryUsage(t *testing.T) {
+	unlockingScript := &bscript.Script{}
+	require.NoError(t, unlockingScript.AppendPushData(bytes.Repeat([]byte{0x01}, 40)))
+	lockingScript, err := bscript.NewFromASM("OP_DUP OP_TOALTSTACK OP_DUP OP_2DROP OP_FROMALTSTACK")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		maxStackMemoryUsage int64
+		expErr              bool
+	}{
+		"within limit": {
+			maxStackMemoryUsage: 216,
+		},
+		"exceeds limit": {
+			maxStackMemoryUsage: 215,
+			expErr:              true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := ConsensusConfig
+			cfg.AfterGenesis.MaxStackMemoryUsage = test.maxStackMemoryUsage
+
+			th, err := createThread(&execOpts{
+				lockingScript:   lockingScript,
+				unlockingScript: unlockingScript,
+				flags:           scriptflag.UTXOAfterGenesis,
+				config:          &cfg,
+			})
+			require.NoError(t, err)
+
+			err = th.execute()
+			if test.expErr {
+				assert.True(t, errs.IsErrorCode(err, errs.ErrStackMemoryUsage), err)
+				return
+			}
+			require.NoError(t, err)
+
+			// 3 items of 40 bytes plus 32 bytes overhead across both stacks
+			assert.Equal(t, int64(216), th.State().PeakStackMemoryUsage)
+		})
+	}
+
+	t.Run("default policy limit", func(t *testing.T) {
+		err := NewEngine().Execute(
+			WithScripts(lockingScript, unlockingScript),
+			WithAfterGenesis(),
+			WithConfig(DefaultPolicyConfig),
+		)
+		assert.NoError(t, err)
+	})
+}
+
+func TestExecuteContext(t *testing.T) {
+	unlockingScript, err := bscript.NewFromASM("OP_TRUE")
+	require.NoError(t, err)
+	lockingScript := &bscript.Script{}
+	for i := 0; i < 10000; i++ {
+		require.NoError(t, lockingScript.AppendOpcodes(bscript.OpNOP))
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := map[string]struct {
+		ctx    context.Context
+		budget Budget
+		expErr errs.ErrorCode
+	}{
+		"no budget": {
+			ctx: context.Background(),
+		},
+		"within budget": {
+			ctx:    context.Background(),
+			budget: Budget{MaxOpcodes: 10001, MaxDuration: time.Minute},
+		},
+		"cancelled": {
+			ctx:    cancelled,
+			expErr: errs.ErrExecutionCancelled,
+		},
+		"max opcodes exceeded": {
+			ctx:    context.Background(),
+			budget: Budget{MaxOpcodes: 10000},
+			expErr: errs.ErrBudgetExceeded,
+		},
+		"max duration exceeded": {
+			ctx:    context.Background(),
+			budget: Budget{MaxDuration: time.Nanosecond},
+			expErr: errs.ErrBudgetExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NewContextEngine().ExecuteContext(test.ctx,
+				WithScripts(lockingScript, unlockingScript),
+				WithAfterGenesis(),
+				WithBudget(test.budget),
+			)
+			if test.expErr != 0 {
+				assert.True(t, errs.IsErrorCode(err, test.expErr), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestExecute_ErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		unlocking  string
+		locking    string
+		stackDepth int
+		expCode    errs.ErrorCode
+		expPos     *errs.Position
+		expStack   [][]byte
+	}{
+		"failure in locking script": {
+			unlocking:  "OP_1 OP_2",
+			locking:    "OP_7 OP_0 OP_DIV",
+			stackDepth: -1,
+			expCode:    errs.ErrDivideByZero,
+			expPos:     &errs.Position{Script: errs.ScriptLocking, ScriptIdx: 1, OpcodeIdx: 2, Offset: 2, Opcode: "OP_DIV"},
+			expStack:   [][]byte{{1}, {2}},
+		},
+		"failure in unlocking script": {
+			unlocking: "OP_1 OP_DROP OP_DROP",
+			locking:   "OP_1",
+			expCode:   errs.ErrInvalidStackOperation,
+			expPos:    &errs.Position{Script: errs.ScriptUnlocking, ScriptIdx: 0, OpcodeIdx: 2, Offset: 2, Opcode: "OP_DROP"},
+		},
+		"offset after push data": {
+			unlocking:  "OP_1",
+			locking:    "010203 OP_EQUALVERIFY",
+			stackDepth: 1,
+			expCode:    errs.ErrEqualVerify,
+			expPos:     &errs.Position{Script: errs.ScriptLocking, ScriptIdx: 1, OpcodeIdx: 1, Offset: 4, Opcode: "OP_EQUALVERIFY"},
+			expStack:   [][]byte{},
+		},
+		"stack snapshot limited to depth": {
+			unlocking:  "OP_1 OP_2 OP_3",
+			locking:    "OP_0 OP_VERIFY",
+			stackDepth: 2,
+			expCode:    errs.ErrVerify,
+			expPos:     &errs.Position{Script: errs.ScriptLocking, ScriptIdx: 1, OpcodeIdx: 1, Offset: 1, Opcode: "OP_VERIFY"},
+			expStack:   [][]byte{{2}, {3}},
+		},
+		"failure after execution has no position": {
+			unlocking:  "OP_1",
+			locking:    "OP_0",
+			stackDepth: -1,
+			expCode:    errs.ErrEvalFalse,
+			expStack:   [][]byte{{1}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			uscript, err := bscript.NewFromASM(test.unlocking)
+			require.NoError(t, err)
+			lscript, err := bscript.NewFromASM(test.locking)
+			require.NoError(t, err)
+
+			err = NewEngine().Execute(
+				WithScripts(lscript, uscript),
+				WithErrorStack(test.stackDepth),
+			)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errs.NewError(test.expCode, "")))
+
+			e := &errs.Error{}
+			require.True(t, errors.As(err, e))
+			assert.Equal(t, test.expCode, e.ErrorCode)
+			assert.Equal(t, test.expPos, e.Position)
+			assert.Equal(t, test.expStack, e.Stack)
+		})
+	}
+}
+
+func TestExecute_LeavesTxUntouched(t *testing.T) {
+	t.Parallel()
+
+	tx, err := bt.NewTxFromString("0200000003a9bc457fdc6a54d99300fb137b23714d860c350a9d19ff0f571e694a419ff3a0010000006b48304502210086c83beb2b2663e4709a583d261d75be538aedcafa7766bd983e5c8db2f8b2fc02201a88b178624ab0ad1748b37c875f885930166237c88f5af78ee4e61d337f935f412103e8be830d98bb3b007a0343ee5c36daa48796ae8bb57946b1e87378ad6e8a090dfeffffff0092bb9a47e27bf64fc98f557c530c04d9ac25e2f2a8b600e92a0b1ae7c89c20010000006b483045022100f06b3db1c0a11af348401f9cebe10ae2659d6e766a9dcd9e3a04690ba10a160f02203f7fbd7dfcfc70863aface1a306fcc91bbadf6bc884c21a55ef0d32bd6b088c8412103e8be830d98bb3b007a0343ee5c36daa48796ae8bb57946b1e87378ad6e8a090dfeffffff9d0d4554fa692420a0830ca614b6c60f1bf8eaaa21afca4aa8c99fb052d9f398000000006b483045022100d920f2290548e92a6235f8b2513b7f693a64a0d3fa699f81a034f4b4608ff82f0220767d7d98025aff3c7bd5f2a66aab6a824f5990392e6489aae1e1ae3472d8dffb412103e8be830d98bb3b007a0343ee5c36daa48796ae8bb57946b1e87378ad6e8a090dfeffffff02807c814a000000001976a9143a6bf34ebfcf30e8541bbb33a7882845e5a29cb488ac76b0e60e000000001976a914bd492b67f90cb85918494767ebb23102c4f06b7088ac67000000")
+	require.NoError(t, err)
+	prevTx, err := bt.NewTxFromString("0200000001424408c9d997772e56112c731b6dc6f050cb3847c5570cea12f30bfbc7df0a010000000049483045022100fe759b2cd7f25bce4fcda4c8366891b0d9289dc5bac1cf216909c89dc324437a02204aa590b6e82764971df4fe741adf41ece4cde607cb6443edceba831060213d3641feffffff02408c380c010000001976a914f761fc0927a43f4fab5740ef39f05b1fb7786f5288ac0065cd1d000000001976a914805096c5167877a5799977d46fb9dee5891dc3cb88ac66000000")
+	require.NoError(t, err)
+	prevOutput := prevTx.OutputIdx(int(tx.InputIdx(0).PreviousTxOutIndex))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, NewEngine().Execute(
+				WithTx(tx, 0, prevOutput),
+				WithForkID(),
+				WithAfterGenesis(),
+			))
+		}()
+	}
+	wg.Wait()
+
+	for _, in := range tx.Inputs {
+		assert.Nil(t, in.PreviousTxScript)
+		assert.Zero(t, in.PreviousTxSatoshis)
+	}
+}
@@ -325,6 +325,22 @@ const (
 	// set, but the ScriptEnableSighashForkID flag is not set.
 	ErrIllegalForkID
 
+	// ---------------------------
+	// Failures related to limits.
+	// ---------------------------
+
+	// ErrStackMemoryUsage is returned when the combined memory used by the
+	// data and alt stacks exceeds the maximum stack memory usage.
+	ErrStackMemoryUsage
+
+	// ErrBudgetExceeded is returned when the execution exceeds the
+	// maximum amount of opcodes or wall time of its budget.
+	ErrBudgetExceeded
+
+	// ErrExecutionCancelled is returned when the context of the
+	// execution is cancelled or reaches its deadline.
+	ErrExecutionCancelled
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -394,6 +410,9 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrNegativeLockTime:         "ErrNegativeLockTime",
 	ErrUnsatisfiedLockTime:      "ErrUnsatisfiedLockTime",
 	ErrIllegalForkID:            "ErrIllegalForkID",
+	ErrStackMemoryUsage:         "ErrStackMemoryUsage",
+	ErrBudgetExceeded:           "ErrBudgetExceeded",
+	ErrExecutionCancelled:       "ErrExecutionCancelled",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -414,10 +433,18 @@ func (e ErrorCode) String() string {
 // The caller can use type assertions on the returned errors to access the
 // ErrorCode field to ascertain the specific reason for the error.  As an
 // additional convenience, the caller may make use of the IsErrorCode function
-// to check for a specific error code.
+// to check for a specific error code, or errors.Is with an Error of the code.
 type Error struct {
 	ErrorCode   ErrorCode
 	Description string
+
+	// Position is the position of the opcode the execution failed at, or
+	// nil if the error was not raised executing an opcode.
+	Position *Position
+	// Stack is a snapshot of the top of the data stack when the execution
+	// failed, the last item being the top of the stack. It is only set if
+	// the execution was configured to take one.
+	Stack [][]byte
 }
 
 // Error satisfies the error interface and prints human-readable errors.
@@ -425,6 +452,67 @@ func (e Error) Error() string {
 	return e.Description
 }
 
+// Is returns whether the target is an Error with the same ErrorCode,
+// so errors.Is matches errors by code regardless of their details.
+func (e Error) Is(target error) bool {
+	var t Error
+	switch v := target.(type) { //nolint:errorlint // the target itself is compared
+	case Error:
+		t = v
+	case *Error:
+		if v == nil {
+			return false
+		}
+		t = *v
+	default:
+		return false
+	}
+
+	return e.ErrorCode == t.ErrorCode
+}
+
+// ScriptKind identifies a script of an execution.
+type ScriptKind int
+
+// The scripts of an execution, in order of execution.
+const (
+	ScriptUnlocking ScriptKind = iota
+	ScriptLocking
+	// ScriptRedeem is the redeem script of a P2SH output.
+	ScriptRedeem
+)
+
+// String returns the ScriptKind as a human-readable name.
+func (k ScriptKind) String() string {
+	switch k {
+	case ScriptUnlocking:
+		return "unlocking"
+	case ScriptLocking:
+		return "locking"
+	case ScriptRedeem:
+		return "redeem"
+	}
+	return fmt.Sprintf("Unknown ScriptKind (%d)", int(k))
+}
+
+// Position identifies an opcode of an execution.
+type Position struct {
+	Script ScriptKind
+	// ScriptIdx is the index of the script in the execution.
+	ScriptIdx int
+	// OpcodeIdx is the index of the opcode in its script.
+	OpcodeIdx int
+	// Offset is the byte offset of the opcode in its script.
+	Offset int
+	// Opcode is the name of the opcode.
+	Opcode string
+}
+
+// String returns the position as a human-readable string.
+func (p Position) String() string {
+	return fmt.Sprintf("%s %s script opcode %d at offset %d", p.Opcode, p.Script, p.OpcodeIdx, p.Offset)
+}
+
 // NewError creates an Error given a set of arguments.
 func NewError(c ErrorCode, desc string, fmtArgs ...interface{}) Error {
 	return Error{ErrorCode: c, Description: fmt.Sprintf(desc, fmtArgs...)}
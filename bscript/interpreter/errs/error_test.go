@@ -5,6 +5,8 @@
 package errs
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -78,6 +80,9 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrNegativeLockTime, "ErrNegativeLockTime"},
 		{ErrUnsatisfiedLockTime, "ErrUnsatisfiedLockTime"},
 		{ErrIllegalForkID, "ErrIllegalForkID"},
+		{ErrStackMemoryUsage, "ErrStackMemoryUsage"},
+		{ErrBudgetExceeded, "ErrBudgetExceeded"},
+		{ErrExecutionCancelled, "ErrExecutionCancelled"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -126,3 +131,35 @@ func TestError(t *testing.T) {
 		}
 	}
 }
+
+// TestErrorIs tests errors are matched by their error code.
+func TestErrorIs(t *testing.T) {
+	t.Parallel()
+
+	err := Error{
+		ErrorCode:   ErrEqualVerify,
+		Description: "OP_EQUALVERIFY failed",
+		Position:    &Position{Script: ScriptLocking, ScriptIdx: 1, OpcodeIdx: 2, Opcode: "OP_EQUALVERIFY"},
+		Stack:       [][]byte{{1}},
+	}
+
+	tests := []struct {
+		target error
+		want   bool
+	}{
+		{NewError(ErrEqualVerify, ""), true},
+		{&Error{ErrorCode: ErrEqualVerify}, true},
+		{NewError(ErrVerify, "OP_EQUALVERIFY failed"), false},
+		{(*Error)(nil), false},
+		{errors.New("OP_EQUALVERIFY failed"), false},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		result := errors.Is(fmt.Errorf("wrapped: %w", err), test.target)
+		if result != test.want {
+			t.Errorf("Is #%d\n got: %v want: %v", i, result, test.want)
+			continue
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"math/big"
 
+	"github.com/libsv/go-bt/v2/bscript"
 	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
 )
 
@@ -33,14 +34,16 @@ import (
 // number is out of range or not minimally encoded depending on parameters.
 // Since all numeric opcodes involve pulling data from the stack and
 // interpreting it as an integer, it provides the required behaviour.
+//
+// Values are kept in an int64 while they fit, only promoting to a big.Int
+// when an operation overflows or a big operand is decoded after genesis, so
+// that the common case doesn't allocate.
 type scriptNumber struct {
-	val          *big.Int
+	val          *big.Int // set when the value doesn't fit in small
+	small        int64
 	afterGenesis bool
 }
 
-var zero = big.NewInt(0)
-var one = big.NewInt(1)
-
 // makeScriptNumber interprets the passed serialised bytes as an encoded integer
 // and returns the result as a Number.
 //
@@ -70,7 +73,7 @@ var one = big.NewInt(1)
 func makeScriptNumber(bb []byte, scriptNumLen int, requireMinimal, afterGenesis bool) (*scriptNumber, error) {
 	// Interpreting data requires that it is not larger than the passed scriptNumLen value.
 	if len(bb) > scriptNumLen {
-		return &scriptNumber{val: big.NewInt(0), afterGenesis: false}, errs.NewError(
+		return &scriptNumber{}, errs.NewError(
 			errs.ErrNumberTooBig,
 			"numeric value encoded as %x is %d bytes which exceeds the max allowed of %d",
 			bb, len(bb), scriptNumLen,
@@ -80,158 +83,213 @@ func makeScriptNumber(bb []byte, scriptNumLen int, requireMinimal, afterGenesis
 	// Enforce minimal encoded if requested.
 	if requireMinimal {
 		if err := checkMinimalDataEncoding(bb); err != nil {
-			return &scriptNumber{
-				val:          big.NewInt(0),
-				afterGenesis: false,
-			}, err
+			return &scriptNumber{}, err
 		}
 	}
 
 	// Zero is encoded as an empty byte slice.
 	if len(bb) == 0 {
-		return &scriptNumber{
-			afterGenesis: afterGenesis,
-			val:          big.NewInt(0),
-		}, nil
+		return &scriptNumber{afterGenesis: afterGenesis}, nil
 	}
 
-	// Decode from little endian.
-	//
-	// The following is the equivalent of:
-	//    var v int64
-	//    for i, b := range bb {
-	//        v |= int64(b) << uint8(8*i)
-	//    }
-	v := new(big.Int)
-	for i, b := range bb {
-		v.Or(v, new(big.Int).Lsh(new(big.Int).SetBytes([]byte{b}), uint(8*i)))
+	if len(bb) <= bscript.MaxScriptNumberInt64Len {
+		v, _ := bscript.DecodeScriptNumber(bb, false)
+		return &scriptNumber{small: int64(v), afterGenesis: afterGenesis}, nil
+	}
+
+	v, _ := bscript.DecodeBigScriptNumber(bb, false)
+	n := &scriptNumber{afterGenesis: afterGenesis}
+	n.setBig(v)
+	return n, nil
+}
+
+// bigVal returns the value of the receiver as a big.Int. The returned
+// value must not be modified.
+func (n *scriptNumber) bigVal() *big.Int {
+	if n.val != nil {
+		return n.val
 	}
+	return big.NewInt(n.small)
+}
 
-	// When the most significant byte of the input bytes has the sign bit
-	// set, the result is negative.  So, remove the sign bit from the result
-	// and make it negative.
-	//
-	// The following is the equivalent of:
-	//    if bb[len(bb)-1]&0x80 != 0 {
-	//        v &= ^(int64(0x80) << uint8(8*(len(bb)-1)))
-	//        return -v, nil
-	//    }
-	if bb[len(bb)-1]&0x80 != 0 {
-		// The maximum length of bb has already been determined to be 4
-		// above, so uint8 is enough to cover the max possible shift
-		// value of 24.
-		shift := big.NewInt(int64(0x80))
-		shift.Not(shift.Lsh(shift, uint(8*(len(bb)-1))))
-		v.And(v, shift).Neg(v)
+// setBig sets the receiver to v, keeping it in an int64 if it fits.
+func (n *scriptNumber) setBig(v *big.Int) *scriptNumber {
+	if v.IsInt64() {
+		n.val = nil
+		n.small = v.Int64()
+		return n
 	}
-	return &scriptNumber{
-		val:          v,
-		afterGenesis: afterGenesis,
-	}, nil
+	n.val = v
+	return n
+}
+
+// setSmall sets the receiver to v.
+func (n *scriptNumber) setSmall(v int64) *scriptNumber {
+	n.val = nil
+	n.small = v
+	return n
+}
+
+// isSmall returns true if the value of the receiver
+// is held in an int64.
+func (n *scriptNumber) isSmall() bool {
+	return n.val == nil
 }
 
 // Add adds the receiver and the number, sets the result over the receiver and returns.
 func (n *scriptNumber) Add(o *scriptNumber) *scriptNumber {
-	*n.val = *new(big.Int).Add(n.val, o.val)
-	return n
+	if n.isSmall() && o.isSmall() {
+		if r := n.small + o.small; (r > n.small) == (o.small > 0) {
+			return n.setSmall(r)
+		}
+	}
+	return n.setBig(new(big.Int).Add(n.bigVal(), o.bigVal()))
 }
 
 // Sub subtracts the number from the receiver, sets the result over the receiver and returns.
 func (n *scriptNumber) Sub(o *scriptNumber) *scriptNumber {
-	*n.val = *new(big.Int).Sub(n.val, o.val)
-	return n
+	if n.isSmall() && o.isSmall() {
+		if r := n.small - o.small; (r < n.small) == (o.small > 0) {
+			return n.setSmall(r)
+		}
+	}
+	return n.setBig(new(big.Int).Sub(n.bigVal(), o.bigVal()))
 }
 
 // Mul multiplies the receiver by the number, sets the result over the receiver and returns.
 func (n *scriptNumber) Mul(o *scriptNumber) *scriptNumber {
-	*n.val = *new(big.Int).Mul(n.val, o.val)
-	return n
+	if n.isSmall() && o.isSmall() {
+		a, b := n.small, o.small
+		if a == 0 || b == 0 {
+			return n.setSmall(0)
+		}
+		if r := a * b; r/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64) {
+			return n.setSmall(r)
+		}
+	}
+	return n.setBig(new(big.Int).Mul(n.bigVal(), o.bigVal()))
 }
 
 // Div divides the receiver by the number, sets the result over the receiver and returns.
 func (n *scriptNumber) Div(o *scriptNumber) *scriptNumber {
-	*n.val = *new(big.Int).Quo(n.val, o.val)
-	return n
+	if n.isSmall() && o.isSmall() && o.small != 0 && !(n.small == math.MinInt64 && o.small == -1) {
+		return n.setSmall(n.small / o.small)
+	}
+	return n.setBig(new(big.Int).Quo(n.bigVal(), o.bigVal()))
 }
 
 // Mod divides the receiver by the number, sets the remainder over the receiver and returns.
 func (n *scriptNumber) Mod(o *scriptNumber) *scriptNumber {
-	*n.val = *new(big.Int).Rem(n.val, o.val)
-	return n
+	if n.isSmall() && o.isSmall() && o.small != 0 {
+		return n.setSmall(n.small % o.small)
+	}
+	return n.setBig(new(big.Int).Rem(n.bigVal(), o.bigVal()))
+}
+
+// cmp compares the receiver with the number passed, returning
+// -1 if smaller, 0 if equal and +1 if larger.
+func (n *scriptNumber) cmp(o *scriptNumber) int {
+	if n.isSmall() && o.isSmall() {
+		switch {
+		case n.small < o.small:
+			return -1
+		case n.small > o.small:
+			return 1
+		}
+		return 0
+	}
+	return n.bigVal().Cmp(o.bigVal())
 }
 
 // LessThanInt returns true if the receiver is smaller than the integer passed.
 func (n *scriptNumber) LessThanInt(i int64) bool {
-	return n.LessThan(&scriptNumber{val: big.NewInt(i)})
+	return n.LessThan(&scriptNumber{small: i})
 }
 
 // LessThan returns true if the receiver is smaller than the number passed.
 func (n *scriptNumber) LessThan(o *scriptNumber) bool {
-	return n.val.Cmp(o.val) == -1
+	return n.cmp(o) == -1
 }
 
 // LessThanOrEqual returns ture if the receiver is smaller or equal to the number passed.
 func (n *scriptNumber) LessThanOrEqual(o *scriptNumber) bool {
-	return n.val.Cmp(o.val) < 1
+	return n.cmp(o) < 1
 }
 
 // GreaterThanInt returns true if the receiver is larger than the integer passed.
 func (n *scriptNumber) GreaterThanInt(i int64) bool {
-	return n.GreaterThan(&scriptNumber{val: big.NewInt(i)})
+	return n.GreaterThan(&scriptNumber{small: i})
 }
 
 // GreaterThan returns true if the receiver is larger than the number passed.
 func (n *scriptNumber) GreaterThan(o *scriptNumber) bool {
-	return n.val.Cmp(o.val) == 1
+	return n.cmp(o) == 1
 }
 
 // GreaterThanOrEqual returns true if the receiver is larger or equal to the number passed.
 func (n *scriptNumber) GreaterThanOrEqual(o *scriptNumber) bool {
-	return n.val.Cmp(o.val) > -1
+	return n.cmp(o) > -1
 }
 
 // EqualInt returns true if the receiver is equal to the integer passed.
 func (n *scriptNumber) EqualInt(i int64) bool {
-	return n.Equal(&scriptNumber{val: big.NewInt(i)})
+	return n.Equal(&scriptNumber{small: i})
 }
 
 // Equal returns true if the receiver is equal to the number passed.
 func (n *scriptNumber) Equal(o *scriptNumber) bool {
-	return n.val.Cmp(o.val) == 0
+	return n.cmp(o) == 0
 }
 
 // IsZero return strue if hte receiver equals zero.
 func (n *scriptNumber) IsZero() bool {
-	return n.val.Cmp(zero) == 0
+	if n.isSmall() {
+		return n.small == 0
+	}
+	return n.val.Sign() == 0
 }
 
 // Incr increment the receiver by one.
 func (n *scriptNumber) Incr() *scriptNumber {
-	*n.val = *new(big.Int).Add(n.val, one)
-	return n
+	return n.Add(&scriptNumber{small: 1})
 }
 
 // Decr decrement the receiver by one.
 func (n *scriptNumber) Decr() *scriptNumber {
-	*n.val = *new(big.Int).Sub(n.val, one)
-	return n
+	return n.Sub(&scriptNumber{small: 1})
 }
 
 // Neg sets the receiver to the negative of the receiver.
 func (n *scriptNumber) Neg() *scriptNumber {
-	*n.val = *new(big.Int).Neg(n.val)
-	return n
+	if n.isSmall() && n.small != math.MinInt64 {
+		return n.setSmall(-n.small)
+	}
+	return n.setBig(new(big.Int).Neg(n.bigVal()))
 }
 
 // Abs sets the receiver to the absolute value of hte receiver.
 func (n *scriptNumber) Abs() *scriptNumber {
-	*n.val = *new(big.Int).Abs(n.val)
-	return n
+	if n.isSmall() && n.small != math.MinInt64 {
+		if n.small < 0 {
+			return n.setSmall(-n.small)
+		}
+		return n
+	}
+	return n.setBig(new(big.Int).Abs(n.bigVal()))
+}
+
+// int64Bits returns the low 64 bits of the receiver as an int64,
+// matching big.Int's Int64 for values which don't fit.
+func (n *scriptNumber) int64Bits() int64 {
+	if n.isSmall() {
+		return n.small
+	}
+	return n.val.Int64()
 }
 
 // Int returns the receivers value as an int.
 func (n *scriptNumber) Int() int {
-	return int(n.val.Int64())
+	return int(n.int64Bits())
 }
 
 // Int32 returns the Number clamped to a valid int32.  That is to say
@@ -248,7 +306,7 @@ func (n *scriptNumber) Int() int {
 // out of range before being reinterpreted as an integer, this will provide the
 // correct behaviour.
 func (n *scriptNumber) Int32() int32 {
-	v := n.val.Int64()
+	v := n.int64Bits()
 	if v > math.MaxInt32 {
 		return math.MaxInt32
 	}
@@ -272,10 +330,13 @@ func (n *scriptNumber) Int32() int32 {
 // out of range before being reinterpreted as an integer, this will provide the
 // correct behaviour.
 func (n *scriptNumber) Int64() int64 {
-	if n.GreaterThanInt(math.MaxInt64) {
+	if n.isSmall() {
+		return n.small
+	}
+	if n.val.Sign() > 0 && !n.val.IsInt64() {
 		return math.MaxInt64
 	}
-	if n.LessThanInt(math.MinInt64) {
+	if n.val.Sign() < 0 && !n.val.IsInt64() {
 		return math.MinInt64
 	}
 	return n.val.Int64()
@@ -283,8 +344,7 @@ func (n *scriptNumber) Int64() int64 {
 
 // Set the value of the receiver.
 func (n *scriptNumber) Set(i int64) *scriptNumber {
-	*n.val = *new(big.Int).SetInt64(i)
-	return n
+	return n.setSmall(i)
 }
 
 // Bytes returns the number serialised as a little endian with a sign bit.
@@ -303,123 +363,17 @@ func (n *scriptNumber) Set(i int64) *scriptNumber {
 //     32768 -> [0x00 0x80 0x00]
 //    -32768 -> [0x00 0x80 0x80]
 func (n *scriptNumber) Bytes() []byte {
-	// Zero encodes as an empty byte slice.
-	if n.IsZero() {
-		return []byte{}
-	}
-
-	// Take the absolute value and keep track of whether it was originally
-	// negative.
-	isNegative := n.val.Cmp(zero) == -1
-	if isNegative {
-		n.Neg()
-	}
-
-	var bb []byte
-	if !n.afterGenesis {
-		v := n.val.Int64()
-		if v > math.MaxInt32 {
-			bb = big.NewInt(int64(math.MaxInt32)).Bytes()
-		} else if v < math.MinInt32 {
-			bb = big.NewInt(int64(math.MinInt32)).Bytes()
-		}
-	}
-	if bb == nil {
-		bb = n.val.Bytes()
-	}
-
-	// Encode to little endian.  The maximum number of encoded bytes is len(bb)+1
-	// (8 bytes for max int64 plus a potential byte for sign extension).
-	//
-	// The following is the equivalent of:
-	//    result := make([]byte, 0, len(bb)+1)
-	//    for n > 0 {
-	//        result = append(result, byte(n&0xff))
-	//        n >>= 8
-	//    }
-	result := make([]byte, 0, len(bb)+1)
-	cpy := new(big.Int).SetBytes(n.val.Bytes())
-	for cpy.Cmp(zero) == 1 {
-		result = append(result, byte(cpy.Int64()&0xff))
-		cpy.Rsh(cpy, 8)
-	}
-
-	// When the most significant byte already has the high bit set, an
-	// additional high byte is required to indicate whether the number is
-	// negative or positive.  The additional byte is removed when converting
-	// back to an integral and its high bit is used to denote the sign.
-	//
-	// Otherwise, when the most significant byte does not already have the
-	// high bit set, use it to indicate the value is negative, if needed.
-	if result[len(result)-1]&0x80 != 0 {
-		extraByte := byte(0x00)
-		if isNegative {
-			extraByte = 0x80
-		}
-		result = append(result, extraByte)
-	} else if isNegative {
-		result[len(result)-1] |= 0x80
+	if n.isSmall() {
+		return bscript.ScriptNumber(n.small).Bytes()
 	}
-
-	return result
-}
-
-func minimallyEncode(data []byte) []byte {
-	if len(data) == 0 {
-		return data
-	}
-
-	last := data[len(data)-1]
-	if last&0x7f != 0 {
-		return data
-	}
-
-	if len(data) == 1 {
-		return []byte{}
-	}
-
-	if data[len(data)-2]&0x80 != 0 {
-		return data
-	}
-
-	for i := len(data) - 1; i > 0; i-- {
-		if data[i-1] != 0 {
-			if data[i-1]&0x80 != 0 {
-				data[i] = last
-				i++
-			} else {
-				data[i-1] |= last
-			}
-
-			return data[:i]
-		}
-	}
-
-	return []byte{}
+	return bscript.BigScriptNumberBytes(n.val)
 }
 
 // checkMinimalDataEncoding returns whether the passed byte array adheres
 // to the minimal encoding requirements.
 func checkMinimalDataEncoding(v []byte) error {
-	if len(v) == 0 {
-		return nil
-	}
-
-	// Check that the number is encoded with the minimum possible
-	// number of bytes.
-	//
-	// If the most-significant-byte - excluding the sign bit - is zero
-	// then we're not minimal.  Note how this test also rejects the
-	// negative-zero encoding, [0x80].
-	if v[len(v)-1]&0x7f == 0 {
-		// One exception: if there's more than one byte and the most
-		// significant bit of the second-most-significant-byte is set
-		// it would conflict with the sign bit.  An example of this case
-		// is +-255, which encode to 0xff00 and 0xff80 respectively.
-		// (big-endian).
-		if len(v) == 1 || v[len(v)-2]&0x80 == 0 {
-			return errs.NewError(errs.ErrMinimalData, "numeric value encoded as %x is not minimally encoded", v)
-		}
+	if err := bscript.CheckMinimalEncoding(v); err != nil {
+		return errs.NewError(errs.ErrMinimalData, "numeric value encoded as %x is not minimally encoded", v)
 	}
 
 	return nil
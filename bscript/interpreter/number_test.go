@@ -334,6 +334,121 @@ func TestScriptNumInt64(t *testing.T) {
 	}
 }
 
+// TestScriptNumOverflow ensures that arithmetic which overflows an int64
+// is promoted to a big.Int and produces the same results as performing
+// the operation with big.Int directly.
+func TestScriptNumOverflow(t *testing.T) {
+	t.Parallel()
+
+	maxInt64 := big.NewInt(math.MaxInt64)
+	minInt64 := big.NewInt(math.MinInt64)
+
+	tests := []struct {
+		name string
+		op   func() *scriptNumber
+		want *big.Int
+	}{
+		{"max int64 add one", func() *scriptNumber {
+			return (&scriptNumber{small: math.MaxInt64}).Add(&scriptNumber{small: 1})
+		}, new(big.Int).Add(maxInt64, big.NewInt(1))},
+		{"min int64 sub one", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Sub(&scriptNumber{small: 1})
+		}, new(big.Int).Sub(minInt64, big.NewInt(1))},
+		{"min int64 sub min int64", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Sub(&scriptNumber{small: math.MinInt64})
+		}, big.NewInt(0)},
+		{"max int64 incr", func() *scriptNumber {
+			return (&scriptNumber{small: math.MaxInt64}).Incr()
+		}, new(big.Int).Add(maxInt64, big.NewInt(1))},
+		{"min int64 decr", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Decr()
+		}, new(big.Int).Sub(minInt64, big.NewInt(1))},
+		{"max int64 mul max int64", func() *scriptNumber {
+			return (&scriptNumber{small: math.MaxInt64}).Mul(&scriptNumber{small: math.MaxInt64})
+		}, new(big.Int).Mul(maxInt64, maxInt64)},
+		{"min int64 mul minus one", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Mul(&scriptNumber{small: -1})
+		}, new(big.Int).Neg(minInt64)},
+		{"minus one mul min int64", func() *scriptNumber {
+			return (&scriptNumber{small: -1}).Mul(&scriptNumber{small: math.MinInt64})
+		}, new(big.Int).Neg(minInt64)},
+		{"min int64 div minus one", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Div(&scriptNumber{small: -1})
+		}, new(big.Int).Neg(minInt64)},
+		{"min int64 mod minus one", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Mod(&scriptNumber{small: -1})
+		}, big.NewInt(0)},
+		{"negative div truncates", func() *scriptNumber {
+			return (&scriptNumber{small: -7}).Div(&scriptNumber{small: 2})
+		}, big.NewInt(-3)},
+		{"negative mod keeps sign", func() *scriptNumber {
+			return (&scriptNumber{small: -7}).Mod(&scriptNumber{small: 2})
+		}, big.NewInt(-1)},
+		{"min int64 neg", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Neg()
+		}, new(big.Int).Neg(minInt64)},
+		{"min int64 abs", func() *scriptNumber {
+			return (&scriptNumber{small: math.MinInt64}).Abs()
+		}, new(big.Int).Neg(minInt64)},
+		{"big sub back into int64", func() *scriptNumber {
+			return (&scriptNumber{val: new(big.Int).Add(maxInt64, big.NewInt(1))}).Sub(&scriptNumber{small: 1})
+		}, maxInt64},
+	}
+
+	for _, test := range tests {
+		n := test.op()
+		if n.bigVal().Cmp(test.want) != 0 {
+			t.Errorf("%s: got %s, want %s", test.name, n.bigVal(), test.want)
+			continue
+		}
+		if n.isSmall() != test.want.IsInt64() {
+			t.Errorf("%s: expected small to be %v", test.name, test.want.IsInt64())
+			continue
+		}
+
+		// The serialised form must match the big.Int encoding and
+		// decode back to the same value.
+		gotBytes := n.Bytes()
+		wantBytes := (&scriptNumber{val: test.want}).Bytes()
+		if !bytes.Equal(gotBytes, wantBytes) {
+			t.Errorf("%s: Bytes got %x, want %x", test.name, gotBytes, wantBytes)
+			continue
+		}
+		decoded, err := makeScriptNumber(gotBytes, len(gotBytes), true, true)
+		if err != nil {
+			t.Errorf("%s: unexpected error decoding %x: %v", test.name, gotBytes, err)
+			continue
+		}
+		if !decoded.Equal(n) {
+			t.Errorf("%s: decoded %s, want %s", test.name, decoded.bigVal(), n.bigVal())
+		}
+	}
+}
+
+func BenchmarkScriptNumArithmetic(b *testing.B) {
+	x := &scriptNumber{small: 123456789}
+	y := &scriptNumber{small: 987654}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		n := &scriptNumber{small: x.small}
+		n.Add(y).Mul(y).Sub(x).Div(y).Mod(x)
+	}
+}
+
+func BenchmarkScriptNumRoundTrip(b *testing.B) {
+	bb := (&scriptNumber{small: -2147483647}).Bytes()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		n, err := makeScriptNumber(bb, 4, true, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = n.Bytes()
+	}
+}
+
 func TestDisasmString(t *testing.T) {
 	script, _ := bscript.NewFromHexString("3105abcdef4280548004abcdefc2877451a0637c757451a0637c757451a0637c757451a0637c757451a0637c756868686868")
 	prev, _ := bscript.NewFromHexString("a91464902b04c3d9ea558b7f2edb24758b383343a2d587")
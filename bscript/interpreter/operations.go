@@ -5,7 +5,6 @@ import (
 	"crypto/sha1" //nolint:gosec // OP_SHA1 support requires this
 	"crypto/sha256"
 	"hash"
-	"math/big"
 
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bk/crypto"
@@ -367,7 +366,7 @@ func opcodePushData(op *ParsedOpcode, t *thread) error {
 // opcode1Negate pushes -1, encoded as a number, to the data stack.
 func opcode1Negate(op *ParsedOpcode, t *thread) error {
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(-1),
+		small:        -1,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -856,7 +855,7 @@ func opcodeIfDup(op *ParsedOpcode, t *thread) error {
 // Example with 3 items: [x1 x2 x3] -> [x1 x2 x3 3]
 func opcodeDepth(op *ParsedOpcode, t *thread) error {
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(int64(t.dstack.Depth())),
+		small:        int64(t.dstack.Depth()),
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1060,7 +1059,7 @@ func opcodeBin2num(op *ParsedOpcode, t *thread) error {
 		return err
 	}
 
-	b := minimallyEncode(a)
+	b := bscript.MinimallyEncode(a)
 	if len(b) > t.cfg.MaxScriptNumberLength() {
 		return errs.NewError(errs.ErrNumberTooBig, "script numbers are limited to %d bytes", t.cfg.MaxScriptNumberLength())
 	}
@@ -1080,7 +1079,7 @@ func opcodeSize(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(int64(len(so))),
+		small:        int64(len(so)),
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1302,7 +1301,7 @@ func opcodeNot(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1507,7 +1506,7 @@ func opcodeBoolAnd(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1537,7 +1536,7 @@ func opcodeBoolOr(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1565,7 +1564,7 @@ func opcodeNumEqual(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1609,7 +1608,7 @@ func opcodeNumNotEqual(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1637,7 +1636,7 @@ func opcodeLessThan(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1665,7 +1664,7 @@ func opcodeGreaterThan(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1693,7 +1692,7 @@ func opcodeLessThanOrEqual(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1721,7 +1720,7 @@ func opcodeGreaterThanOrEqual(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1805,7 +1804,7 @@ func opcodeWithin(op *ParsedOpcode, t *thread) error {
 	}
 
 	t.dstack.PushInt(&scriptNumber{
-		val:          big.NewInt(n),
+		small:        n,
 		afterGenesis: t.afterGenesis,
 	})
 	return nil
@@ -1974,10 +1973,7 @@ func opcodeCheckSig(op *ParsedOpcode, t *thread) error {
 		return err
 	}
 
-	txCopy := t.tx.Clone()
-	txCopy.Inputs[t.inputIdx].PreviousTxScript = up
-
-	hash, err = txCopy.CalcInputSignatureHash(uint32(t.inputIdx), shf)
+	hash, err = t.sigHash(up, shf)
 	if err != nil {
 		t.dstack.PushBool(false)
 		return err
@@ -2000,7 +1996,7 @@ func opcodeCheckSig(op *ParsedOpcode, t *thread) error {
 		return nil //nolint:nilerr // only need a false push in this case
 	}
 
-	ok := signature.Verify(hash, pubKey)
+	ok := t.sigVerifier.VerifySignature(signature, pubKey, hash, shf)
 	if !ok && t.hasFlag(scriptflag.VerifyNullFail) && len(sigBytes) > 0 {
 		return errs.NewError(errs.ErrNullFail, "signature not empty on failed checksig")
 	}
@@ -2215,16 +2211,13 @@ func opcodeCheckMultiSig(op *ParsedOpcode, t *thread) error {
 		}
 
 		// Generate the signature hash based on the signature hash type.
-		txCopy := t.tx.Clone()
-		txCopy.Inputs[t.inputIdx].PreviousTxScript = up
-
-		signatureHash, err := txCopy.CalcInputSignatureHash(uint32(t.inputIdx), shf)
+		signatureHash, err := t.sigHash(up, shf)
 		if err != nil {
 			t.dstack.PushBool(false)
 			return nil //nolint:nilerr // only need a false push in this case
 		}
 
-		if ok := parsedSig.Verify(signatureHash, parsedPubKey); ok {
+		if ok := t.sigVerifier.VerifySignature(parsedSig, parsedPubKey, signatureHash, shf); ok {
 			// PubKey verified, move on to the next signature.
 			signatureIdx++
 			numSignatures--
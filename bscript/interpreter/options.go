@@ -4,6 +4,7 @@ import (
 	"github.com/libsv/go-bt/v2"
 	"github.com/libsv/go-bt/v2/bscript"
 	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+	"github.com/libsv/go-bt/v2/chaincfg"
 )
 
 // ExecutionOptionFunc for setting execution options.
@@ -26,6 +27,15 @@ func WithScripts(lockingScript *bscript.Script, unlockingScript *bscript.Script)
 	}
 }
 
+// WithCompiledLockingScript configure the execution to run the locking script
+// compiled with Compile, rather than parsing it. If a locking script is also
+// provided, with WithScripts or WithTx, it must match the compiled script.
+func WithCompiledLockingScript(c *CompiledScript) ExecutionOptionFunc {
+	return func(p *execOpts) {
+		p.compiledLockingScript = c
+	}
+}
+
 // WithAfterGenesis configure the execution to operate in an after-genesis context.
 func WithAfterGenesis() ExecutionOptionFunc {
 	return func(p *execOpts) {
@@ -33,6 +43,20 @@ func WithAfterGenesis() ExecutionOptionFunc {
 	}
 }
 
+// WithParams configure the execution with the script rules of the network
+// params provided, for a utxo created at the given block height.
+//
+// The execution operates in an after-genesis context if the genesis
+// upgrade is active at the utxo height. Use scriptflag.ForHeight with
+// WithFlags to apply all the rules active at a block height.
+func WithParams(params *chaincfg.Params, utxoHeight uint32) ExecutionOptionFunc {
+	return func(p *execOpts) {
+		if params.IsGenesisActive(utxoHeight) {
+			p.flags.AddFlag(scriptflag.UTXOAfterGenesis)
+		}
+	}
+}
+
 // WithForkID configure the execution to allow a tx with a fork id.
 func WithForkID() ExecutionOptionFunc {
 	return func(p *execOpts) {
@@ -47,6 +71,41 @@ func WithP2SH() ExecutionOptionFunc {
 	}
 }
 
+// WithConfig configure the execution with the limits of the config provided,
+// such as DefaultPolicyConfig or limits matching the settings of a node.
+// ConsensusConfig is used if no config is provided.
+func WithConfig(cfg Config) ExecutionOptionFunc {
+	return func(p *execOpts) {
+		p.config = &cfg
+	}
+}
+
+// WithBudget configure the execution to fail with an errs.ErrBudgetExceeded
+// once it exceeds the amount of opcodes or wall time of the budget.
+func WithBudget(b Budget) ExecutionOptionFunc {
+	return func(p *execOpts) {
+		p.budget = b
+	}
+}
+
+// WithErrorStack configure the execution to take a snapshot of the top depth
+// items of the data stack into the errs.Error it fails with, if any. A negative
+// depth snapshots the whole stack.
+func WithErrorStack(depth int) ExecutionOptionFunc {
+	return func(p *execOpts) {
+		p.errStackDepth = depth
+	}
+}
+
+// WithSignatureVerifier configure the execution to verify the signatures of
+// OP_CHECKSIG and OP_CHECKMULTISIG with the provided verifier, rather than
+// the DefaultSignatureVerifier.
+func WithSignatureVerifier(v SignatureVerifier) ExecutionOptionFunc {
+	return func(p *execOpts) {
+		p.sigVerifier = v
+	}
+}
+
 // WithFlags configure the execution with the provided flags.
 func WithFlags(flags scriptflag.Flag) ExecutionOptionFunc {
 	return func(p *execOpts) {
@@ -63,8 +122,11 @@ func WithDebugger(debugger Debugger) ExecutionOptionFunc {
 	}
 }
 
-// WithState inject the provided state into the execution thread. This assumes
-// that the state is correct for the scripts provided.
+// WithState inject the provided state into the execution thread. The execution
+// fails with an errs.ErrInvalidParams if the scripts, flags or position of the
+// state don't match the execution, but otherwise assumes that the state is correct
+// for the scripts provided. A State can be checkpointed and restored, for example
+// across processes, with its MarshalBinary and MarshalJSON encodings.
 //
 // NOTE: This is highly experimental and is unstable when used with unintended states,
 // and likely still when used in a happy path scenario. Therefore, it is recommended
@@ -0,0 +1,73 @@
+package scriptflag
+
+import "github.com/libsv/go-bt/v2/chaincfg"
+
+// MandatoryPolicy contains the policy flags enforced
+// by nodes when accepting transactions, on top of the
+// consensus flags for the current height.
+const MandatoryPolicy = Bip16 | VerifyStrictEncoding | EnableSighashForkID | VerifyLowS | VerifyNullFail |
+	VerifyDERSignatures | VerifyMinimalData | StrictMultiSig | DiscourageUpgradableNops | VerifyCleanStack |
+	VerifyCheckLockTimeVerify | VerifyCheckSequenceVerify | VerifySigPushOnly
+
+// ChronicleRelaxed contains the malleability flags which are
+// no longer enforced for tx versions above 1 once chronicle
+// is active.
+const ChronicleRelaxed = VerifyLowS | VerifyNullFail | VerifyMinimalData | StrictMultiSig | VerifyCleanStack |
+	VerifySigPushOnly | VerifyMinimalIf
+
+// ForHeight returns the consensus flags used to validate a block at blockHeight
+// spending a utxo created at utxoHeight on the network of the params provided,
+// along with the policy flags used to accept a transaction at that height.
+//
+// Unconfirmed utxos are spent at the height of the block
+// being validated, so blockHeight should be used as utxoHeight.
+//
+// The flags are derived for each input, as whether the utxo was created after
+// genesis decides the rules its script is executed under:
+//
+//	consensus, _ := scriptflag.ForHeight(&chaincfg.MainNet, blockHeight, utxoHeight)
+//	err := interpreter.NewEngine().Execute(
+//	    interpreter.WithTx(tx, inputIdx, prevOutput),
+//	    interpreter.WithFlags(consensus),
+//	)
+func ForHeight(params *chaincfg.Params, blockHeight, utxoHeight uint32) (consensus Flag, policy Flag) {
+	if blockHeight >= params.BIP16Height {
+		consensus.AddFlag(Bip16)
+	}
+	if blockHeight >= params.BIP66Height {
+		consensus.AddFlag(VerifyDERSignatures)
+	}
+	if blockHeight >= params.BIP65Height {
+		consensus.AddFlag(VerifyCheckLockTimeVerify)
+	}
+	if blockHeight >= params.CSVHeight {
+		consensus.AddFlag(VerifyCheckSequenceVerify)
+	}
+	if blockHeight >= params.UAHFHeight {
+		consensus.AddFlag(VerifyStrictEncoding | EnableSighashForkID)
+	}
+	if blockHeight >= params.DAAHeight {
+		consensus.AddFlag(VerifyLowS | VerifyNullFail)
+	}
+	if params.IsGenesisActive(blockHeight) {
+		consensus.AddFlag(VerifySigPushOnly)
+		if params.IsGenesisActive(utxoHeight) {
+			consensus.AddFlag(UTXOAfterGenesis)
+		}
+	}
+	if params.IsChronicleActive(blockHeight) {
+		consensus.AddFlag(ChronicleActive)
+	}
+
+	return consensus, consensus | MandatoryPolicy
+}
+
+// ForTxVersion returns the flags with the chronicle malleability
+// rules relaxed if chronicle is active and the tx version is above 1.
+func (s Flag) ForTxVersion(version uint32) Flag {
+	if !s.HasFlag(ChronicleActive) || version <= 1 {
+		return s
+	}
+
+	return s &^ ChronicleRelaxed
+}
@@ -0,0 +1,83 @@
+package scriptflag_test
+
+import (
+	"testing"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+	"github.com/libsv/go-bt/v2/chaincfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForHeight(t *testing.T) {
+	tests := map[string]struct {
+		params       *chaincfg.Params
+		blockHeight  uint32
+		utxoHeight   uint32
+		expConsensus scriptflag.Flag
+	}{
+		"mainnet before p2sh": {
+			params:      &chaincfg.MainNet,
+			blockHeight: 170000,
+			utxoHeight:  160000,
+		},
+		"mainnet after bip66": {
+			params:       &chaincfg.MainNet,
+			blockHeight:  380000,
+			utxoHeight:   370000,
+			expConsensus: scriptflag.Bip16 | scriptflag.VerifyDERSignatures,
+		},
+		"mainnet after uahf": {
+			params:      &chaincfg.MainNet,
+			blockHeight: 478559,
+			utxoHeight:  478000,
+			expConsensus: scriptflag.Bip16 | scriptflag.VerifyDERSignatures | scriptflag.VerifyCheckLockTimeVerify |
+				scriptflag.VerifyCheckSequenceVerify | scriptflag.VerifyStrictEncoding | scriptflag.EnableSighashForkID,
+		},
+		"mainnet after genesis spending pre genesis utxo": {
+			params:      &chaincfg.MainNet,
+			blockHeight: 620538,
+			utxoHeight:  620537,
+			expConsensus: scriptflag.Bip16 | scriptflag.VerifyDERSignatures | scriptflag.VerifyCheckLockTimeVerify |
+				scriptflag.VerifyCheckSequenceVerify | scriptflag.VerifyStrictEncoding | scriptflag.EnableSighashForkID |
+				scriptflag.VerifyLowS | scriptflag.VerifyNullFail | scriptflag.VerifySigPushOnly,
+		},
+		"mainnet after genesis spending post genesis utxo": {
+			params:      &chaincfg.MainNet,
+			blockHeight: 620538,
+			utxoHeight:  620538,
+			expConsensus: scriptflag.Bip16 | scriptflag.VerifyDERSignatures | scriptflag.VerifyCheckLockTimeVerify |
+				scriptflag.VerifyCheckSequenceVerify | scriptflag.VerifyStrictEncoding | scriptflag.EnableSighashForkID |
+				scriptflag.VerifyLowS | scriptflag.VerifyNullFail | scriptflag.VerifySigPushOnly | scriptflag.UTXOAfterGenesis,
+		},
+		"testnet after chronicle": {
+			params:      &chaincfg.TestNet,
+			blockHeight: 1713168,
+			utxoHeight:  1713168,
+			expConsensus: scriptflag.Bip16 | scriptflag.VerifyDERSignatures | scriptflag.VerifyCheckLockTimeVerify |
+				scriptflag.VerifyCheckSequenceVerify | scriptflag.VerifyStrictEncoding | scriptflag.EnableSighashForkID |
+				scriptflag.VerifyLowS | scriptflag.VerifyNullFail | scriptflag.VerifySigPushOnly | scriptflag.UTXOAfterGenesis |
+				scriptflag.ChronicleActive,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			consensus, policy := scriptflag.ForHeight(test.params, test.blockHeight, test.utxoHeight)
+			assert.Equal(t, test.expConsensus, consensus)
+			assert.Equal(t, test.expConsensus|scriptflag.MandatoryPolicy, policy)
+		})
+	}
+}
+
+func TestFlag_ForTxVersion(t *testing.T) {
+	consensus, policy := scriptflag.ForHeight(&chaincfg.MainNet, 950000, 950000)
+
+	assert.Equal(t, policy, policy.ForTxVersion(1))
+	assert.False(t, policy.ForTxVersion(2).HasAny(scriptflag.VerifyLowS, scriptflag.VerifyCleanStack, scriptflag.VerifySigPushOnly))
+	assert.False(t, (policy | scriptflag.VerifyMinimalIf).ForTxVersion(2).HasFlag(scriptflag.VerifyMinimalIf))
+	assert.True(t, policy.ForTxVersion(2).HasFlag(scriptflag.EnableSighashForkID|scriptflag.UTXOAfterGenesis))
+
+	pre, _ := scriptflag.ForHeight(&chaincfg.MainNet, 900000, 900000)
+	assert.Equal(t, pre, pre.ForTxVersion(2))
+	assert.NotEqual(t, consensus, consensus.ForTxVersion(2))
+}
@@ -78,6 +78,12 @@ const (
 	// VerifyMinimalIf defines the enforcement of any conditional statement using the
 	// minimum required data.
 	VerifyMinimalIf
+
+	// ChronicleActive marks that chronicle is active at the height the
+	// flags are for. It doesn't change how scripts are executed, as the
+	// chronicle rules aren't implemented by the interpreter, and is only
+	// used by ForTxVersion to relax the ChronicleRelaxed flags.
+	ChronicleActive
 )
 
 // HasFlag returns whether the Flags has the passed flag set.
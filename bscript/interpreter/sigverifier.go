@@ -0,0 +1,69 @@
+package interpreter
+
+import (
+	"sync"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bt/v2/sighash"
+)
+
+// SignatureVerifier verifies the signatures checked by OP_CHECKSIG and
+// OP_CHECKMULTISIG, once parsed and found to be of a valid encoding for the
+// flags of the execution. It receives the signature hash digest the signature
+// should sign, calculated with the sighash flag of the signature.
+//
+// It can be replaced with WithSignatureVerifier, for example to batch
+// verifications, or to stub them in tests.
+type SignatureVerifier interface {
+	VerifySignature(sig *bec.Signature, pubKey *bec.PublicKey, hash []byte, shf sighash.Flag) bool
+}
+
+// DefaultSignatureVerifier verifies signatures with ECDSA, and is used
+// if no verifier is provided.
+type DefaultSignatureVerifier struct{}
+
+// VerifySignature verifies the signature of the hash with the public key.
+func (DefaultSignatureVerifier) VerifySignature(sig *bec.Signature, pubKey *bec.PublicKey, hash []byte, _ sighash.Flag) bool {
+	return sig.Verify(hash, pubKey)
+}
+
+// SignatureCheck a signature verification requested of a SignatureVerifier.
+type SignatureCheck struct {
+	Signature   *bec.Signature
+	PubKey      *bec.PublicKey
+	Hash        []byte
+	SigHashFlag sighash.Flag
+}
+
+// StubSignatureVerifier a SignatureVerifier for tests, which records the
+// verifications requested, verifying every signature unless Fail is set.
+// It is safe to use across concurrent executions.
+type StubSignatureVerifier struct {
+	Fail bool
+
+	mu     sync.Mutex
+	checks []SignatureCheck
+}
+
+// VerifySignature records the verification, returning true unless Fail is set.
+func (s *StubSignatureVerifier) VerifySignature(sig *bec.Signature, pubKey *bec.PublicKey, hash []byte, shf sighash.Flag) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checks = append(s.checks, SignatureCheck{
+		Signature:   sig,
+		PubKey:      pubKey,
+		Hash:        append([]byte{}, hash...),
+		SigHashFlag: shf,
+	})
+
+	return !s.Fail
+}
+
+// Checks returns the verifications requested so far, in order.
+func (s *StubSignatureVerifier) Checks() []SignatureCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SignatureCheck{}, s.checks...)
+}
@@ -0,0 +1,136 @@
+package interpreter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+	"github.com/libsv/go-bt/v2/sighash"
+)
+
+func TestWithSignatureVerifier_CheckSig(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		verifier  func() SignatureVerifier
+		tamper    bool
+		expErr    bool
+		expChecks int
+	}{
+		"default verifier": {
+			verifier: func() SignatureVerifier { return nil },
+		},
+		"default verifier fails tampered tx": {
+			verifier: func() SignatureVerifier { return nil },
+			tamper:   true,
+			expErr:   true,
+		},
+		"stub verifies tampered tx": {
+			verifier:  func() SignatureVerifier { return &StubSignatureVerifier{} },
+			tamper:    true,
+			expChecks: 1,
+		},
+		"stub fails valid tx": {
+			verifier:  func() SignatureVerifier { return &StubSignatureVerifier{Fail: true} },
+			expErr:    true,
+			expChecks: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tx, err := bt.NewTxFromString(txHex1)
+			require.NoError(t, err)
+			prevTx, err := bt.NewTxFromString(prevTxHex1)
+			require.NoError(t, err)
+			prevOutput := prevTx.OutputIdx(int(tx.Inputs[0].PreviousTxOutIndex))
+			if test.tamper {
+				tx.Outputs[0].Satoshis++
+			}
+
+			verifier := test.verifier()
+			oo := []ExecutionOptionFunc{WithTx(tx, 0, prevOutput), WithForkID(), WithAfterGenesis()}
+			if verifier != nil {
+				oo = append(oo, WithSignatureVerifier(verifier))
+			}
+			err = NewEngine().Execute(oo...)
+			if test.expErr {
+				assert.True(t, errs.IsErrorCode(err, errs.ErrEvalFalse), err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			stub, ok := verifier.(*StubSignatureVerifier)
+			if !ok {
+				return
+			}
+			checks := stub.Checks()
+			require.Len(t, checks, test.expChecks)
+			sig := tx.Inputs[0].UnlockingScript
+			parts, err := bscript.DecodeParts(*sig)
+			require.NoError(t, err)
+			assert.Equal(t, parts[1], checks[0].PubKey.SerialiseCompressed())
+			assert.Equal(t, sighash.Flag(parts[0][len(parts[0])-1]), checks[0].SigHashFlag)
+			assert.Len(t, checks[0].Hash, 32)
+		})
+	}
+}
+
+func TestWithSignatureVerifier_CheckMultiSig(t *testing.T) {
+	t.Parallel()
+
+	// Signatures of an arbitrary hash, which only a stub verifies.
+	var pubKeys, sigs [][]byte
+	for i := 0; i < 3; i++ {
+		key, err := bec.NewPrivateKey(bec.S256())
+		require.NoError(t, err)
+		sig, err := key.Sign(bytes.Repeat([]byte{byte(i)}, 32))
+		require.NoError(t, err)
+		pubKeys = append(pubKeys, key.PubKey().SerialiseCompressed())
+		sigs = append(sigs, append(sig.Serialise(), byte(sighash.AllForkID)))
+	}
+
+	lscript := &bscript.Script{}
+	require.NoError(t, lscript.AppendOpcodes(bscript.Op2))
+	for _, pk := range pubKeys {
+		require.NoError(t, lscript.AppendPushData(pk))
+	}
+	require.NoError(t, lscript.AppendOpcodes(bscript.Op3, bscript.OpCHECKMULTISIG))
+
+	uscript := &bscript.Script{}
+	require.NoError(t, uscript.AppendOpcodes(bscript.Op0))
+	require.NoError(t, uscript.AppendPushData(sigs[0]))
+	require.NoError(t, uscript.AppendPushData(sigs[2]))
+
+	tx := bt.NewTx()
+	require.NoError(t, tx.From("0000000000000000000000000000000000000000000000000000000000000001", 0, lscript.String(), 1000))
+	tx.AddOutput(&bt.Output{Satoshis: 900, LockingScript: lscript})
+	tx.Inputs[0].UnlockingScript = uscript
+	prevOutput := &bt.Output{Satoshis: 1000, LockingScript: lscript}
+
+	err := NewEngine().Execute(WithTx(tx, 0, prevOutput), WithForkID(), WithAfterGenesis())
+	assert.True(t, errs.IsErrorCode(err, errs.ErrEvalFalse), err)
+
+	stub := &StubSignatureVerifier{}
+	require.NoError(t, NewEngine().Execute(
+		WithTx(tx, 0, prevOutput),
+		WithForkID(),
+		WithAfterGenesis(),
+		WithSignatureVerifier(stub),
+	))
+
+	// Signatures and keys are checked from the top of the stack, the
+	// last signature against the last key, then the first against the
+	// second key, as the stub verifies every signature.
+	checks := stub.Checks()
+	require.Len(t, checks, 2)
+	assert.Equal(t, pubKeys[2], checks[0].PubKey.SerialiseCompressed())
+	assert.Equal(t, pubKeys[1], checks[1].PubKey.SerialiseCompressed())
+	assert.Equal(t, sighash.AllForkID, checks[1].SigHashFlag)
+}
@@ -32,6 +32,33 @@ func fromBool(v bool) []byte {
 	return nil
 }
 
+// stackElementOverhead is the amount of bytes each stack item
+// is accounted for on top of its size, matching bitcoin-sv.
+const stackElementOverhead = 32
+
+// stackMemory tracks the memory used by the stacks sharing it.
+type stackMemory struct {
+	usage int64
+	peak  int64
+}
+
+func (m *stackMemory) push(bb []byte) {
+	if m == nil {
+		return
+	}
+	m.usage += int64(len(bb)) + stackElementOverhead
+	if m.usage > m.peak {
+		m.peak = m.usage
+	}
+}
+
+func (m *stackMemory) pop(bb []byte) {
+	if m == nil {
+		return
+	}
+	m.usage -= int64(len(bb)) + stackElementOverhead
+}
+
 // stack represents a stack of immutable objects to be used with bitcoin
 // scripts.  Objects may be shared, therefore in usage if a value is to be
 // changed it *must* be deep-copied first to avoid changing other values on the
@@ -41,6 +68,7 @@ type stack struct {
 	maxNumLength      int
 	afterGenesis      bool
 	verifyMinimalData bool
+	mem               *stackMemory
 	debug             Debugger
 	sh                StateHandler
 }
@@ -67,6 +95,7 @@ func (s *stack) PushByteArray(so []byte) {
 	defer s.afterStackPush(so)
 	s.beforeStackPush(so)
 	s.stk = append(s.stk, so)
+	s.mem.push(so)
 }
 
 // PushInt converts the provided scriptNumber to a suitable byte array then pushes
@@ -182,6 +211,7 @@ func (s *stack) nipN(idx int32) ([]byte, error) {
 		s.stk = s.stk[:sz-idx-1]
 		s.stk = append(s.stk, s1...)
 	}
+	s.mem.pop(so)
 	return so, nil
 }
 
@@ -1,6 +1,9 @@
 package interpreter
 
-import "github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+import (
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+)
 
 // State a snapshot of a threads state during execution.
 type State struct {
@@ -14,12 +17,20 @@ type State struct {
 	OpcodeIdx            int
 	LastCodeSeparatorIdx int
 	NumOps               int
+	StackMemoryUsage     int64
+	PeakStackMemoryUsage int64
 	Flags                scriptflag.Flag
 	IsFinished           bool
 	Genesis              struct {
 		AfterGenesis bool
 		EarlyReturn  bool
 	}
+	// Tx, InputIdx and PrevOutput are those the execution is against, if
+	// any. They're not copied, must not be modified, and aren't encoded
+	// or restored with the state.
+	Tx         *bt.Tx
+	InputIdx   int
+	PrevOutput *bt.Output
 }
 
 // Opcode the current interpreter.ParsedOpcode from the
@@ -68,6 +79,8 @@ func (t *thread) State() *State {
 		OpcodeIdx:            offsetIdx,
 		LastCodeSeparatorIdx: t.lastCodeSep,
 		NumOps:               t.numOps,
+		StackMemoryUsage:     t.stackMem.usage,
+		PeakStackMemoryUsage: t.stackMem.peak,
 		Flags:                t.flags,
 		IsFinished:           t.scriptIdx > scriptIdx,
 		Genesis: struct {
@@ -77,6 +90,9 @@ func (t *thread) State() *State {
 			AfterGenesis: t.afterGenesis,
 			EarlyReturn:  t.earlyReturnAfterGenesis,
 		},
+		Tx:         t.tx,
+		InputIdx:   t.inputIdx,
+		PrevOutput: t.prevOutput,
 	}
 
 	for i, dd := range t.dstack.stk {
@@ -129,6 +145,9 @@ func (t *thread) SetState(state *State) {
 	t.scriptOff = state.OpcodeIdx
 	t.lastCodeSep = state.LastCodeSeparatorIdx
 	t.numOps = state.NumOps
+	if t.stackMem.peak = state.PeakStackMemoryUsage; t.stackMem.usage > t.stackMem.peak {
+		t.stackMem.peak = t.stackMem.usage
+	}
 	t.flags = state.Flags
 	t.afterGenesis = state.Genesis.AfterGenesis
 	t.earlyReturnAfterGenesis = state.Genesis.EarlyReturn
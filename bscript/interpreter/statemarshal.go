@@ -0,0 +1,350 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+)
+
+// StateVersion is the version of the binary and JSON encodings of a State.
+const StateVersion = 1
+
+// stateMagic prefixes the binary encoding of a State.
+var stateMagic = []byte("btst")
+
+// The bits of the binary encoding of the booleans of a State.
+const (
+	stateFinished = 1 << iota
+	stateAfterGenesis
+	stateEarlyReturn
+)
+
+// MarshalBinary encodes the state, so an execution can be checkpointed and
+// resumed later with WithState. Scripts are encoded as raw bytes.
+func (s *State) MarshalBinary() ([]byte, error) {
+	scripts, err := s.rawScripts()
+	if err != nil {
+		return nil, err
+	}
+
+	var bools byte
+	if s.IsFinished {
+		bools |= stateFinished
+	}
+	if s.Genesis.AfterGenesis {
+		bools |= stateAfterGenesis
+	}
+	if s.Genesis.EarlyReturn {
+		bools |= stateEarlyReturn
+	}
+
+	e := &stateEncoder{}
+	e.buf.Write(stateMagic)
+	e.uint(StateVersion)
+	e.uint(uint64(s.Flags))
+	e.buf.WriteByte(bools)
+	for _, n := range []int{s.ScriptIdx, s.OpcodeIdx, s.LastCodeSeparatorIdx, s.NumOps} {
+		e.int(int64(n))
+	}
+	e.int(s.StackMemoryUsage)
+	e.int(s.PeakStackMemoryUsage)
+
+	e.uint(uint64(len(s.CondStack)))
+	for _, c := range s.CondStack {
+		e.int(int64(c))
+	}
+	for _, stack := range [][][]byte{s.DataStack, s.AltStack, s.ElseStack, s.SavedFirstStack, scripts} {
+		e.items(stack)
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a state encoded with MarshalBinary.
+func (s *State) UnmarshalBinary(b []byte) error {
+	if !bytes.HasPrefix(b, stateMagic) {
+		return errs.NewError(errs.ErrInvalidParams, "invalid state encoding: missing magic")
+	}
+	d := &stateDecoder{r: bytes.NewReader(b[len(stateMagic):])}
+
+	if v := d.uint(); d.err == nil && v != StateVersion {
+		return errs.NewError(errs.ErrInvalidParams, "unsupported state version %d", v)
+	}
+
+	var st State
+	st.Flags = scriptflag.Flag(d.uint())
+	bools := d.byte()
+	for _, n := range []*int{&st.ScriptIdx, &st.OpcodeIdx, &st.LastCodeSeparatorIdx, &st.NumOps} {
+		*n = int(d.int())
+	}
+	st.StackMemoryUsage = d.int()
+	st.PeakStackMemoryUsage = d.int()
+
+	st.CondStack = make([]int, d.count())
+	for i := range st.CondStack {
+		st.CondStack[i] = int(d.int())
+	}
+	st.DataStack = d.items()
+	st.AltStack = d.items()
+	st.ElseStack = d.items()
+	st.SavedFirstStack = d.items()
+	scripts := d.items()
+	if d.err != nil {
+		return errs.NewError(errs.ErrInvalidParams, "invalid state encoding: %s", d.err)
+	}
+	if d.r.Len() != 0 {
+		return errs.NewError(errs.ErrInvalidParams, "invalid state encoding: %d trailing bytes", d.r.Len())
+	}
+
+	st.IsFinished = bools&stateFinished != 0
+	st.Genesis.AfterGenesis = bools&stateAfterGenesis != 0
+	st.Genesis.EarlyReturn = bools&stateEarlyReturn != 0
+	if err := st.parseScripts(scripts); err != nil {
+		return err
+	}
+
+	*s = st
+	return nil
+}
+
+// stateJSON is the JSON encoding of a State, with byte slices hex encoded.
+type stateJSON struct {
+	Version              int             `json:"version"`
+	DataStack            []string        `json:"dataStack"`
+	AltStack             []string        `json:"altStack"`
+	ElseStack            []string        `json:"elseStack"`
+	CondStack            []int           `json:"condStack"`
+	SavedFirstStack      []string        `json:"savedFirstStack"`
+	Scripts              []string        `json:"scripts"`
+	ScriptIdx            int             `json:"scriptIdx"`
+	OpcodeIdx            int             `json:"opcodeIdx"`
+	LastCodeSeparatorIdx int             `json:"lastCodeSeparatorIdx"`
+	NumOps               int             `json:"numOps"`
+	StackMemoryUsage     int64           `json:"stackMemoryUsage"`
+	PeakStackMemoryUsage int64           `json:"peakStackMemoryUsage"`
+	Flags                scriptflag.Flag `json:"flags"`
+	IsFinished           bool            `json:"isFinished"`
+	AfterGenesis         bool            `json:"afterGenesis"`
+	EarlyReturn          bool            `json:"earlyReturn"`
+}
+
+// MarshalJSON encodes the state as JSON, hex encoding stack items and scripts.
+func (s *State) MarshalJSON() ([]byte, error) {
+	scripts, err := s.rawScripts()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(stateJSON{
+		Version:              StateVersion,
+		DataStack:            encodeHex(s.DataStack),
+		AltStack:             encodeHex(s.AltStack),
+		ElseStack:            encodeHex(s.ElseStack),
+		CondStack:            append([]int{}, s.CondStack...),
+		SavedFirstStack:      encodeHex(s.SavedFirstStack),
+		Scripts:              encodeHex(scripts),
+		ScriptIdx:            s.ScriptIdx,
+		OpcodeIdx:            s.OpcodeIdx,
+		LastCodeSeparatorIdx: s.LastCodeSeparatorIdx,
+		NumOps:               s.NumOps,
+		StackMemoryUsage:     s.StackMemoryUsage,
+		PeakStackMemoryUsage: s.PeakStackMemoryUsage,
+		Flags:                s.Flags,
+		IsFinished:           s.IsFinished,
+		AfterGenesis:         s.Genesis.AfterGenesis,
+		EarlyReturn:          s.Genesis.EarlyReturn,
+	})
+}
+
+// UnmarshalJSON decodes a state encoded with MarshalJSON.
+func (s *State) UnmarshalJSON(b []byte) error {
+	var sj stateJSON
+	if err := json.Unmarshal(b, &sj); err != nil {
+		return err
+	}
+	if sj.Version != StateVersion {
+		return errs.NewError(errs.ErrInvalidParams, "unsupported state version %d", sj.Version)
+	}
+
+	st := State{
+		CondStack:            append([]int{}, sj.CondStack...),
+		ScriptIdx:            sj.ScriptIdx,
+		OpcodeIdx:            sj.OpcodeIdx,
+		LastCodeSeparatorIdx: sj.LastCodeSeparatorIdx,
+		NumOps:               sj.NumOps,
+		StackMemoryUsage:     sj.StackMemoryUsage,
+		PeakStackMemoryUsage: sj.PeakStackMemoryUsage,
+		Flags:                sj.Flags,
+		IsFinished:           sj.IsFinished,
+	}
+	st.Genesis.AfterGenesis = sj.AfterGenesis
+	st.Genesis.EarlyReturn = sj.EarlyReturn
+
+	var scripts [][]byte
+	for _, f := range []struct {
+		dst *[][]byte
+		src []string
+	}{
+		{&st.DataStack, sj.DataStack},
+		{&st.AltStack, sj.AltStack},
+		{&st.ElseStack, sj.ElseStack},
+		{&st.SavedFirstStack, sj.SavedFirstStack},
+		{&scripts, sj.Scripts},
+	} {
+		items, err := decodeHex(f.src)
+		if err != nil {
+			return errs.NewError(errs.ErrInvalidParams, "invalid state encoding: %s", err)
+		}
+		*f.dst = items
+	}
+	if err := st.parseScripts(scripts); err != nil {
+		return err
+	}
+
+	*s = st
+	return nil
+}
+
+func (s *State) rawScripts() ([][]byte, error) {
+	p := &DefaultOpcodeParser{}
+	scripts := make([][]byte, len(s.Scripts))
+	for i, ps := range s.Scripts {
+		script, err := p.Unparse(ps)
+		if err != nil {
+			return nil, err
+		}
+		scripts[i] = *script
+	}
+
+	return scripts, nil
+}
+
+func (s *State) parseScripts(scripts [][]byte) error {
+	p := &DefaultOpcodeParser{}
+	s.Scripts = make([]ParsedScript, len(scripts))
+	for i, b := range scripts {
+		ps, err := p.Parse(bscript.NewFromBytes(b))
+		if err != nil {
+			return err
+		}
+		s.Scripts[i] = ps
+	}
+
+	return nil
+}
+
+func encodeHex(items [][]byte) []string {
+	ss := make([]string, len(items))
+	for i, b := range items {
+		ss[i] = hex.EncodeToString(b)
+	}
+
+	return ss
+}
+
+func decodeHex(ss []string) ([][]byte, error) {
+	items := make([][]byte, len(ss))
+	for i, s := range ss {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = b
+	}
+
+	return items, nil
+}
+
+type stateEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *stateEncoder) uint(n uint64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf.Write(b[:binary.PutUvarint(b[:], n)])
+}
+
+func (e *stateEncoder) int(n int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf.Write(b[:binary.PutVarint(b[:], n)])
+}
+
+func (e *stateEncoder) items(items [][]byte) {
+	e.uint(uint64(len(items)))
+	for _, b := range items {
+		e.uint(uint64(len(b)))
+		e.buf.Write(b)
+	}
+}
+
+// stateDecoder decodes the values of a binary encoded state,
+// holding the first error encountered.
+type stateDecoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (d *stateDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	n, err := binary.ReadUvarint(d.r)
+	d.err = err
+
+	return n
+}
+
+func (d *stateDecoder) int() int64 {
+	if d.err != nil {
+		return 0
+	}
+	n, err := binary.ReadVarint(d.r)
+	d.err = err
+
+	return n
+}
+
+func (d *stateDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	b, err := d.r.ReadByte()
+	d.err = err
+
+	return b
+}
+
+// count reads a length, which can't exceed the bytes remaining
+// as every item is encoded in at least a byte.
+func (d *stateDecoder) count() int {
+	n := d.uint()
+	if d.err == nil && n > uint64(d.r.Len()) {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+
+	return int(n)
+}
+
+func (d *stateDecoder) items() [][]byte {
+	items := make([][]byte, d.count())
+	for i := range items {
+		n := d.uint()
+		if d.err == nil && n > uint64(d.r.Len()) {
+			d.err = io.ErrUnexpectedEOF
+		}
+		if d.err != nil {
+			return nil
+		}
+		items[i] = make([]byte, n)
+		_, d.err = io.ReadFull(d.r, items[i])
+	}
+
+	return items
+}
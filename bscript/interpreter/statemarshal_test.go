@@ -0,0 +1,295 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+)
+
+// stateRecorder records the state before each step.
+type stateRecorder struct {
+	nopDebugger
+	states []*State
+}
+
+func (r *stateRecorder) BeforeStep(s *State) {
+	r.states = append(r.states, s)
+}
+
+func recordStates(t *testing.T, lscript, uscript *bscript.Script, oo ...ExecutionOptionFunc) []*State {
+	r := &stateRecorder{}
+	require.NoError(t, NewEngine().Execute(append(oo,
+		WithScripts(lscript, uscript),
+		WithDebugger(r),
+	)...))
+
+	return r.states
+}
+
+// assertStateEqual compares states, comparing scripts by their
+// bytes as parsed opcodes hold functions, which never compare equal.
+func assertStateEqual(t *testing.T, exp, actual *State) {
+	expScripts, err := exp.rawScripts()
+	require.NoError(t, err)
+	actualScripts, err := actual.rawScripts()
+	require.NoError(t, err)
+	assert.Equal(t, expScripts, actualScripts)
+
+	e, a := *exp, *actual
+	e.Scripts, a.Scripts = nil, nil
+	assert.Equal(t, e, a)
+}
+
+func TestState_Marshal(t *testing.T) {
+	t.Parallel()
+
+	// OP_1 OP_2 | OP_TOALTSTACK OP_IF OP_FROMALTSTACK OP_ELSE OP_0 OP_ENDIF OP_RETURN 0xaabb
+	lscript, err := bscript.NewFromASM("OP_TOALTSTACK OP_IF OP_FROMALTSTACK OP_ELSE OP_0 OP_ENDIF OP_RETURN aabb")
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromASM("OP_1 OP_2")
+	require.NoError(t, err)
+	states := recordStates(t, lscript, uscript, WithAfterGenesis(), WithForkID())
+	require.Len(t, states, 9)
+
+	encodings := map[string]struct {
+		marshal   func(s *State) ([]byte, error)
+		unmarshal func(s *State, b []byte) error
+	}{
+		"binary": {
+			marshal:   (*State).MarshalBinary,
+			unmarshal: (*State).UnmarshalBinary,
+		},
+		"json": {
+			marshal: func(s *State) ([]byte, error) {
+				return json.Marshal(s)
+			},
+			unmarshal: func(s *State, b []byte) error {
+				return json.Unmarshal(b, s)
+			},
+		},
+	}
+
+	for name, enc := range encodings {
+		t.Run(name, func(t *testing.T) {
+			for i, state := range states {
+				b, err := enc.marshal(state)
+				require.NoError(t, err)
+
+				var decoded State
+				require.NoError(t, enc.unmarshal(&decoded, b))
+				assertStateEqual(t, state, &decoded)
+
+				// Resuming the decoded state completes the execution.
+				assert.NoError(t, NewEngine().Execute(
+					WithScripts(lscript, uscript),
+					WithAfterGenesis(),
+					WithForkID(),
+					WithState(&decoded),
+				), "state %d", i)
+			}
+		})
+	}
+}
+
+func TestState_UnmarshalInvalid(t *testing.T) {
+	t.Parallel()
+
+	lscript, err := bscript.NewFromASM("OP_ADD OP_3 OP_EQUAL")
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromASM("OP_1 OP_2")
+	require.NoError(t, err)
+	state := recordStates(t, lscript, uscript)[3]
+
+	b, err := state.MarshalBinary()
+	require.NoError(t, err)
+	j, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		unmarshal func(s *State) error
+	}{
+		"binary missing magic": {
+			unmarshal: func(s *State) error { return s.UnmarshalBinary(b[4:]) },
+		},
+		"binary unsupported version": {
+			unmarshal: func(s *State) error {
+				bb := append([]byte{}, b...)
+				bb[4] = StateVersion + 1
+				return s.UnmarshalBinary(bb)
+			},
+		},
+		"binary truncated": {
+			unmarshal: func(s *State) error { return s.UnmarshalBinary(b[:len(b)-1]) },
+		},
+		"binary trailing bytes": {
+			unmarshal: func(s *State) error { return s.UnmarshalBinary(append(append([]byte{}, b...), 0)) },
+		},
+		"json unsupported version": {
+			unmarshal: func(s *State) error { return json.Unmarshal([]byte(`{"version":2}`), s) },
+		},
+		"json invalid hex": {
+			unmarshal: func(s *State) error { return json.Unmarshal([]byte(`{"version":1,"dataStack":["zz"]}`), s) },
+		},
+	}
+
+	require.NoError(t, (&State{}).UnmarshalBinary(b))
+	require.NoError(t, json.Unmarshal(j, &State{}))
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, test.unmarshal(&State{}))
+		})
+	}
+}
+
+func TestWithState_Validation(t *testing.T) {
+	t.Parallel()
+
+	lscript, err := bscript.NewFromASM("OP_ADD OP_3 OP_EQUAL")
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromASM("OP_1 OP_2")
+	require.NoError(t, err)
+	other, err := bscript.NewFromASM("OP_ADD OP_4 OP_EQUAL")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		lscript *bscript.Script
+		flags   scriptflag.Flag
+		modify  func(s *State)
+		expErr  bool
+	}{
+		"matching state": {
+			lscript: lscript,
+			flags:   scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID,
+		},
+		"implied flags omitted": {
+			lscript: lscript,
+			flags:   scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID,
+			modify: func(s *State) {
+				s.Flags = scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID
+			},
+		},
+		"different locking script": {
+			lscript: other,
+			flags:   scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID,
+			expErr:  true,
+		},
+		"different flags": {
+			lscript: lscript,
+			flags:   scriptflag.UTXOAfterGenesis,
+			expErr:  true,
+		},
+		"before genesis": {
+			lscript: lscript,
+			flags:   scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID,
+			modify: func(s *State) {
+				s.Genesis.AfterGenesis = false
+			},
+			expErr: true,
+		},
+		"extra script": {
+			lscript: lscript,
+			flags:   scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID,
+			modify: func(s *State) {
+				s.Scripts = append(s.Scripts, s.Scripts[1])
+			},
+			expErr: true,
+		},
+		"opcode out of range": {
+			lscript: lscript,
+			flags:   scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID,
+			modify: func(s *State) {
+				s.OpcodeIdx = 4
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := recordStates(t, lscript, uscript, WithAfterGenesis(), WithForkID())[2]
+			if test.modify != nil {
+				test.modify(state)
+			}
+
+			err := NewEngine().Execute(
+				WithScripts(test.lscript, uscript),
+				WithFlags(test.flags),
+				WithState(state),
+			)
+			if test.expErr {
+				assert.True(t, errs.IsErrorCode(err, errs.ErrInvalidParams), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWithState_P2SH(t *testing.T) {
+	t.Parallel()
+
+	redeem, err := bscript.NewFromASM("OP_ADD OP_3 OP_EQUAL")
+	require.NoError(t, err)
+	other, err := bscript.NewFromASM("OP_ADD OP_4 OP_EQUAL")
+	require.NoError(t, err)
+	lscript := &bscript.Script{}
+	require.NoError(t, lscript.AppendOpcodes(bscript.OpHASH160))
+	require.NoError(t, lscript.AppendPushData(crypto.Hash160(*redeem)))
+	require.NoError(t, lscript.AppendOpcodes(bscript.OpEQUAL))
+	uscript, err := bscript.NewFromASM("OP_1 OP_2")
+	require.NoError(t, err)
+	require.NoError(t, uscript.AppendPushData(*redeem))
+
+	var state *State
+	for _, s := range recordStates(t, lscript, uscript, WithFlags(scriptflag.Bip16)) {
+		if s.ScriptIdx == 2 {
+			state = s
+			break
+		}
+	}
+	require.NotNil(t, state)
+	require.Len(t, state.Scripts, 3)
+
+	tests := map[string]struct {
+		modify func(s *State)
+		expErr bool
+	}{
+		"matching redeem script": {},
+		"different redeem script": {
+			modify: func(s *State) {
+				ps, err := (&DefaultOpcodeParser{}).Parse(other)
+				require.NoError(t, err)
+				s.Scripts[2] = ps
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := *state
+			s.Scripts = append([]ParsedScript{}, state.Scripts...)
+			if test.modify != nil {
+				test.modify(&s)
+			}
+
+			err := NewEngine().Execute(
+				WithScripts(lscript, uscript),
+				WithFlags(scriptflag.Bip16),
+				WithState(&s),
+			)
+			if test.expErr {
+				assert.True(t, errs.IsErrorCode(err, errs.ErrInvalidParams), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
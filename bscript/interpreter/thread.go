@@ -1,7 +1,11 @@
 package interpreter
 
 import (
+	"bytes"
+	"context"
 	"math/big"
+	"sync"
+	"time"
 
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bt/v2"
@@ -38,7 +42,17 @@ type thread struct {
 	inputIdx   int
 	prevOutput *bt.Output
 
-	numOps int
+	numOps   int
+	stackMem *stackMemory
+	mem      stackMemory
+
+	ctx      context.Context
+	budget   Budget
+	deadline time.Time
+	numSteps int
+
+	errStackDepth int
+	sigVerifier   SignatureVerifier
 
 	flags scriptflag.Flag
 	bip16 bool // treat execution as pay-to-script-hash
@@ -47,21 +61,69 @@ type thread struct {
 	earlyReturnAfterGenesis bool
 }
 
+// maxPooledStackDepth is the capacity above which the
+// stacks of a thread are dropped rather than pooled.
+const maxPooledStackDepth = 1024
+
+// threadPool pools threads across executions, to reuse their stacks.
+var threadPool = sync.Pool{
+	New: func() interface{} {
+		return &thread{}
+	},
+}
+
 func createThread(opts *execOpts) (*thread, error) {
-	th := &thread{
-		scriptParser: &DefaultOpcodeParser{
-			ErrorOnCheckSig: opts.tx == nil || opts.previousTxOut == nil,
-		},
-		cfg: &beforeGenesisConfig{},
+	th := threadPool.Get().(*thread)
+	th.scriptParser = &DefaultOpcodeParser{
+		ErrorOnCheckSig: opts.tx == nil || opts.previousTxOut == nil,
 	}
+	th.cfg = newLimitsConfig(opts.config, false)
 
 	if err := th.apply(opts); err != nil {
+		th.release()
 		return nil, err
 	}
 
 	return th, nil
 }
 
+// release resets the thread and returns it to the pool, keeping
+// its stacks' memory. The thread must not be used afterwards.
+func (t *thread) release() {
+	dstk, astk, condStack := poolable(t.dstack.stk), poolable(t.astack.stk), t.condStack
+	if cap(condStack) > maxPooledStackDepth {
+		condStack = nil
+	}
+
+	*t = thread{}
+	t.dstack.stk, t.astack.stk, t.condStack = dstk, astk, condStack[:0]
+	threadPool.Put(t)
+}
+
+// poolable returns the stack emptied for reuse, releasing its items,
+// or nil if too large to be pooled.
+func poolable(stk [][]byte) [][]byte {
+	if cap(stk) > maxPooledStackDepth {
+		return nil
+	}
+	for i := range stk {
+		stk[i] = nil
+	}
+
+	return stk[:0]
+}
+
+// useCompiled uses the compiled locking script, which is shared
+// across executions so is never modified.
+func (t *thread) useCompiled(c *CompiledScript) error {
+	if p, ok := t.scriptParser.(*DefaultOpcodeParser); ok && p.ErrorOnCheckSig && c.requiresTx {
+		return errs.NewError(errs.ErrInvalidParams, "tx and previous output must be supplied for checksig")
+	}
+	t.scripts[1] = c.parsed
+
+	return nil
+}
+
 // execOpts are the params required for building an Engine
 //
 // Raw *bscript.Scripts can be supplied as LockingScript and UnlockingScript, or
@@ -78,6 +140,13 @@ type execOpts struct {
 	flags           scriptflag.Flag
 	debugger        Debugger
 	state           *State
+	config          *Config
+	ctx             context.Context
+	budget          Budget
+	errStackDepth   int
+	sigVerifier     SignatureVerifier
+
+	compiledLockingScript *CompiledScript
 }
 
 func (o execOpts) validate() error {
@@ -134,10 +203,6 @@ func (t *thread) hasAny(ff ...scriptflag.Flag) bool {
 	return t.flags.HasAny(ff...)
 }
 
-func (t *thread) addFlag(flag scriptflag.Flag) {
-	t.flags.AddFlag(flag)
-}
-
 // isBranchExecuting returns whether the current conditional branch is
 // actively executing. For example, when the data stack has an OP_FALSE on it
 // and an OP_IF is encountered, the branch is inactive until an OP_ELSE or
@@ -246,6 +311,16 @@ func (t *thread) CheckErrorCondition(finalScript bool) error {
 }
 
 func (t *thread) apply(opts *execOpts) error {
+	if c := opts.compiledLockingScript; c != nil {
+		if opts.lockingScript != nil && !opts.lockingScript.Equals(&c.script) {
+			return errs.NewError(
+				errs.ErrInvalidParams,
+				"locking script does not match the compiled locking script",
+			)
+		}
+		opts.lockingScript = &c.script
+	}
+
 	if err := opts.validate(); err != nil {
 		return err
 	}
@@ -259,6 +334,11 @@ func (t *thread) apply(opts *execOpts) error {
 
 	t.tx = opts.tx
 	t.flags = opts.flags
+	t.errStackDepth = opts.errStackDepth
+	t.sigVerifier = opts.sigVerifier
+	if t.sigVerifier == nil {
+		t.sigVerifier = DefaultSignatureVerifier{}
+	}
 	t.inputIdx = opts.inputIdx
 	t.prevOutput = opts.previousTxOut
 
@@ -270,15 +350,14 @@ func (t *thread) apply(opts *execOpts) error {
 	// Thus, allowing the clean stack flag without the P2SH flag would make
 	// it possible to have a situation where P2SH would not be a soft fork
 	// when it should be.
-	if t.hasFlag(scriptflag.EnableSighashForkID) {
-		t.addFlag(scriptflag.VerifyStrictEncoding)
-	}
+	t.flags = impliedFlags(t.flags)
 
 	t.elseStack = &nopBoolStack{}
+	t.cfg = newLimitsConfig(opts.config, false)
 	if t.hasFlag(scriptflag.UTXOAfterGenesis) {
 		t.elseStack = &stack{debug: &nopDebugger{}, sh: &nopStateHandler{}}
 		t.afterGenesis = true
-		t.cfg = &afterGenesisConfig{}
+		t.cfg = newLimitsConfig(opts.config, true)
 	}
 
 	uscript := opts.unlockingScript
@@ -319,6 +398,13 @@ func (t *thread) apply(opts *execOpts) error {
 	// a third script to execute.
 	t.scripts = make([]ParsedScript, 2)
 	for i, script := range []*bscript.Script{uscript, lscript} {
+		if i == 1 && opts.compiledLockingScript != nil {
+			if err := t.useCompiled(opts.compiledLockingScript); err != nil {
+				return err
+			}
+			continue
+		}
+
 		pscript, err := t.scriptParser.Parse(script)
 		if err != nil {
 			return err
@@ -348,13 +434,14 @@ func (t *thread) apply(opts *execOpts) error {
 		t.bip16 = true
 	}
 
+	// Keep the memory of the stacks of a pooled thread.
+	dstk, astk := t.dstack.stk, t.astack.stk
 	t.dstack = newStack(t.cfg, t.hasFlag(scriptflag.VerifyMinimalData))
 	t.astack = newStack(t.cfg, t.hasFlag(scriptflag.VerifyMinimalData))
-
-	if t.tx != nil {
-		t.tx.InputIdx(t.inputIdx).PreviousTxScript = t.prevOutput.LockingScript
-		t.tx.InputIdx(t.inputIdx).PreviousTxSatoshis = t.prevOutput.Satoshis
-	}
+	t.dstack.stk, t.astack.stk = dstk, astk
+	t.stackMem = &t.mem
+	t.dstack.mem = t.stackMem
+	t.astack.mem = t.stackMem
 
 	t.state = t
 	if opts.debugger == nil {
@@ -368,9 +455,18 @@ func (t *thread) apply(opts *execOpts) error {
 	t.astack.sh = t.state
 
 	if opts.state != nil {
+		if err := t.validateState(opts.state); err != nil {
+			return err
+		}
 		t.SetState(opts.state)
 	}
 
+	t.ctx = opts.ctx
+	if t.ctx == nil {
+		t.ctx = context.Background()
+	}
+	t.budget = opts.budget
+
 	return nil
 }
 
@@ -378,12 +474,19 @@ func (t *thread) execute() error {
 	if err := func() error {
 		defer t.afterExecute()
 		t.beforeExecute()
+		if t.budget.MaxDuration > 0 {
+			t.deadline = time.Now().Add(t.budget.MaxDuration)
+		}
 		for {
+			if err := t.checkBudget(); err != nil {
+				return err
+			}
 			t.beforeStep()
 
+			scriptIdx, scriptOff := t.scriptIdx, t.scriptOff
 			done, err := t.Step()
 			if err != nil {
-				return err
+				return t.annotateError(err, t.position(scriptIdx, scriptOff))
 			}
 
 			t.afterStep()
@@ -395,7 +498,75 @@ func (t *thread) execute() error {
 		return err
 	}
 
-	return t.CheckErrorCondition(true)
+	if err := t.CheckErrorCondition(true); err != nil {
+		return t.annotateError(err, nil)
+	}
+
+	return nil
+}
+
+// annotateError adds the position of the opcode the execution failed at, and
+// a snapshot of the stack if configured, to an errs.Error raised by the thread.
+func (t *thread) annotateError(err error, pos *errs.Position) error {
+	e, ok := err.(errs.Error) //nolint:errorlint // only errors raised by the thread are annotated
+	if !ok {
+		return err
+	}
+
+	if e.Position == nil {
+		e.Position = pos
+	}
+	if t.errStackDepth != 0 && e.Stack == nil {
+		stack := t.GetStack()
+		if t.errStackDepth > 0 && len(stack) > t.errStackDepth {
+			stack = stack[len(stack)-t.errStackDepth:]
+		}
+		e.Stack = make([][]byte, len(stack))
+		for i, b := range stack {
+			e.Stack[i] = append([]byte{}, b...)
+		}
+	}
+
+	return e
+}
+
+// position returns the position of the opcode, or nil if it doesn't exist.
+func (t *thread) position(scriptIdx, scriptOff int) *errs.Position {
+	if scriptIdx >= len(t.scripts) || scriptOff >= len(t.scripts[scriptIdx]) {
+		return nil
+	}
+
+	pos := &errs.Position{
+		Script:    errs.ScriptKind(scriptIdx),
+		ScriptIdx: scriptIdx,
+		OpcodeIdx: scriptOff,
+		Opcode:    t.scripts[scriptIdx][scriptOff].Name(),
+	}
+	if s, err := t.scriptParser.Unparse(t.scripts[scriptIdx][:scriptOff]); err == nil {
+		pos.Offset = len(*s)
+	}
+
+	return pos
+}
+
+// checkBudget returns an error if the context of the execution is done
+// or the execution exceeded its budget, counting the step about to run.
+func (t *thread) checkBudget() error {
+	select {
+	case <-t.ctx.Done():
+		return errs.NewError(errs.ErrExecutionCancelled, "execution cancelled: %s", t.ctx.Err())
+	default:
+	}
+
+	t.numSteps++
+	if t.budget.MaxOpcodes > 0 && t.numSteps > t.budget.MaxOpcodes {
+		return errs.NewError(errs.ErrBudgetExceeded, "exceeded budget of %d opcodes", t.budget.MaxOpcodes)
+	}
+	if !t.deadline.IsZero() && time.Now().After(t.deadline) {
+		return errs.NewError(errs.ErrBudgetExceeded, "exceeded budget of %s", t.budget.MaxDuration)
+	}
+
+	return nil
 }
 
 // Step will execute the next instruction and move the program counter to the
@@ -436,6 +607,13 @@ func (t *thread) Step() (bool, error) {
 			"combined stack size %d > max allowed %d", combinedStackSize, t.cfg.MaxStackSize())
 	}
 
+	// The memory used by the data and alt stacks combined must
+	// not exceed the maximum stack memory usage allowed.
+	if t.stackMem.usage > t.cfg.MaxStackMemoryUsage() {
+		return false, errs.NewError(errs.ErrStackMemoryUsage,
+			"stack memory usage %d > max allowed %d", t.stackMem.usage, t.cfg.MaxStackMemoryUsage())
+	}
+
 	if t.scriptOff < len(t.scripts[t.scriptIdx]) {
 		return false, nil
 	}
@@ -512,6 +690,21 @@ func (t *thread) subScript() ParsedScript {
 	return t.scripts[t.scriptIdx][skip:]
 }
 
+// sigHash returns the signature hash of the input being executed, signing
+// the script provided. The hash is calculated on a copy of the tx with the
+// previous output set on the input, so the tx provided is never written to
+// and can be shared by concurrent executions.
+func (t *thread) sigHash(script *bscript.Script, shf sighash.Flag) ([]byte, error) {
+	txCopy := t.tx.Clone()
+	in := txCopy.Inputs[t.inputIdx]
+	in.PreviousTxScript = script
+	if t.prevOutput != nil {
+		in.PreviousTxSatoshis = t.prevOutput.Satoshis
+	}
+
+	return txCopy.CalcInputSignatureHash(uint32(t.inputIdx), shf)
+}
+
 // checkHashTypeEncoding returns whether the passed hashtype adheres to
 // the strict encoding requirements if enabled.
 func (t *thread) checkHashTypeEncoding(shf sighash.Flag) error {
@@ -782,6 +975,81 @@ func (t *thread) shouldExec(pop ParsedOpcode) bool {
 	return cf && (!t.earlyReturnAfterGenesis || pop.op.val == bscript.OpRETURN)
 }
 
+// impliedFlags returns the flags with those implied by them added.
+func impliedFlags(flags scriptflag.Flag) scriptflag.Flag {
+	if flags.HasFlag(scriptflag.EnableSighashForkID) {
+		flags.AddFlag(scriptflag.VerifyStrictEncoding)
+	}
+
+	return flags
+}
+
+// validateState returns an error if the state can't be resumed in the
+// execution, as its scripts, flags or position don't match.
+func (t *thread) validateState(state *State) error {
+	if impliedFlags(state.Flags) != t.flags {
+		return errs.NewError(errs.ErrInvalidParams, "state flags %d do not match execution flags %d", state.Flags, t.flags)
+	}
+	if state.Genesis.AfterGenesis != t.afterGenesis {
+		return errs.NewError(errs.ErrInvalidParams, "state after genesis %t does not match execution", state.Genesis.AfterGenesis)
+	}
+
+	maxScripts := 2
+	if t.bip16 {
+		maxScripts = 3
+	}
+	if len(state.Scripts) < 2 || len(state.Scripts) > maxScripts {
+		return errs.NewError(errs.ErrInvalidParams, "state has %d scripts, execution expects %d", len(state.Scripts), maxScripts)
+	}
+	for i, script := range t.scripts {
+		if !scriptsEqual(state.Scripts[i], script) {
+			return errs.NewError(errs.ErrInvalidParams, "state script %d does not match execution", i)
+		}
+	}
+	if len(state.Scripts) == 3 {
+		redeem, err := t.p2shRedeemScript()
+		if err != nil {
+			return err
+		}
+		if !scriptsEqual(state.Scripts[2], redeem) {
+			return errs.NewError(errs.ErrInvalidParams, "state script 2 does not match the pushed redeem script")
+		}
+	}
+
+	if state.ScriptIdx < 0 || state.ScriptIdx >= len(state.Scripts) {
+		return errs.NewError(errs.ErrInvalidParams, "state script index %d out of range", state.ScriptIdx)
+	}
+	if state.OpcodeIdx < 0 || state.OpcodeIdx > len(state.Scripts[state.ScriptIdx]) {
+		return errs.NewError(errs.ErrInvalidParams, "state opcode index %d out of range", state.OpcodeIdx)
+	}
+
+	return nil
+}
+
+// p2shRedeemScript parses the redeem script pushed last by the
+// unlocking script of a pay-to-script-hash execution.
+func (t *thread) p2shRedeemScript() (ParsedScript, error) {
+	uscript := t.scripts[0]
+	if len(uscript) == 0 {
+		return nil, errs.NewError(errs.ErrInvalidParams, "unlocking script pushes no redeem script")
+	}
+
+	return t.scriptParser.Parse(bscript.NewFromBytes(uscript[len(uscript)-1].Data))
+}
+
+func scriptsEqual(a, b ParsedScript) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].op.val != b[i].op.val || !bytes.Equal(a[i].Data, b[i].Data) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (t *thread) shiftScript() {
 	defer t.afterScriptChange()
 	t.beforeScriptChange()
@@ -0,0 +1,9 @@
+package trace
+
+import "github.com/pkg/errors"
+
+// Sentinel errors raised by traces.
+var (
+	ErrInvalidTrace       = errors.New("invalid trace")
+	ErrUnsupportedVersion = errors.New("unsupported trace version")
+)
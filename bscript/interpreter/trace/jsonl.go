@@ -0,0 +1,123 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// The types of the lines of a JSON lines trace.
+const (
+	lineHeader = "header"
+	lineStep   = "step"
+	lineResult = "result"
+)
+
+// WriteTo writes the trace as JSON lines: the header, a line per
+// step, then the result.
+func (tr *Trace) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+
+	if err := writeLine(cw, lineHeader, &tr.Header); err != nil {
+		return cw.n, err
+	}
+	for i := range tr.Steps {
+		if err := writeLine(cw, lineStep, &tr.Steps[i]); err != nil {
+			return cw.n, err
+		}
+	}
+	if tr.Result != nil {
+		if err := writeLine(cw, lineResult, tr.Result); err != nil {
+			return cw.n, err
+		}
+	}
+
+	return cw.n, nil
+}
+
+// writeLine writes the record as a line, with its type as the first field.
+func writeLine(w io.Writer, typ string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	line := make([]byte, 0, len(b)+len(typ)+12)
+	line = append(line, `{"type":"`...)
+	line = append(line, typ...)
+	line = append(line, '"')
+	if len(b) > 2 {
+		line = append(line, ',')
+	}
+	line = append(line, b[1:]...)
+	line = append(line, '\n')
+	_, err = w.Write(line)
+
+	return err
+}
+
+// ReadTrace reads a trace written as JSON lines by WriteTo.
+func ReadTrace(r io.Reader) (*Trace, error) {
+	tr := &Trace{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64*1024*1024)
+
+	var n int
+	var header bool
+	for sc.Scan() {
+		n++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var l struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			return nil, errors.Wrapf(ErrInvalidTrace, "line %d: %s", n, err)
+		}
+
+		var err error
+		switch {
+		case l.Type == lineHeader && !header:
+			header = true
+			err = json.Unmarshal(sc.Bytes(), &tr.Header)
+		case l.Type == lineStep && header && tr.Result == nil:
+			var s Step
+			err = json.Unmarshal(sc.Bytes(), &s)
+			tr.Steps = append(tr.Steps, s)
+		case l.Type == lineResult && header && tr.Result == nil:
+			tr.Result = &Result{}
+			err = json.Unmarshal(sc.Bytes(), tr.Result)
+		default:
+			return nil, errors.Wrapf(ErrInvalidTrace, "line %d: unexpected %q", n, l.Type)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidTrace, "line %d: %s", n, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, errors.Wrap(ErrInvalidTrace, "no header")
+	}
+	if tr.Header.Version != Version {
+		return nil, errors.Wrapf(ErrUnsupportedVersion, "%d", tr.Header.Version)
+	}
+
+	return tr, nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,135 @@
+package trace
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// Divergence the first difference between a recorded trace and its replay.
+type Divergence struct {
+	// Step is the index of the step which diverged, or -1 if
+	// the steps matched but the results differ.
+	Step int
+	// Field is the json name of the field which diverged, or "steps"
+	// if one execution ran more steps than the other.
+	Field    string
+	Recorded interface{}
+	Replayed interface{}
+}
+
+// String returns a description of the divergence.
+func (d *Divergence) String() string {
+	if d.Step < 0 {
+		return fmt.Sprintf("result %s: recorded %v, replayed %v", d.Field, d.Recorded, d.Replayed)
+	}
+
+	return fmt.Sprintf("step %d %s: recorded %v, replayed %v", d.Step, d.Field, d.Recorded, d.Replayed)
+}
+
+// Replay re-executes the trace with the engine, recording it again, and returns
+// the first divergence from the recorded trace, or nil if there is none. Further
+// options, such as interpreter.WithConfig, can be provided to match the original
+// execution. Any debugger provided is replaced by the tracer.
+func Replay(tr *Trace, oo ...interpreter.ExecutionOptionFunc) (*Divergence, error) {
+	replayed, err := Rerun(tr, oo...)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compare(tr, replayed), nil
+}
+
+// Rerun re-executes the trace with the engine, returning the new trace.
+func Rerun(tr *Trace, oo ...interpreter.ExecutionOptionFunc) (*Trace, error) {
+	if tr.Header.Version != Version {
+		return nil, errors.Wrapf(ErrUnsupportedVersion, "%d", tr.Header.Version)
+	}
+
+	lscript, err := bscript.NewFromHexString(tr.Header.LockingScript)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidTrace, "locking script: %s", err)
+	}
+
+	opts := []interpreter.ExecutionOptionFunc{interpreter.WithFlags(tr.Header.Flags)}
+	if tr.Header.Tx != "" {
+		tx, err := bt.NewTxFromString(tr.Header.Tx)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidTrace, "tx: %s", err)
+		}
+		prevOutput := &bt.Output{Satoshis: tr.Header.Satoshis, LockingScript: lscript}
+		opts = append(opts, interpreter.WithTx(tx, tr.Header.InputIdx, prevOutput))
+	} else {
+		uscript, err := bscript.NewFromHexString(tr.Header.UnlockingScript)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidTrace, "unlocking script: %s", err)
+		}
+		opts = append(opts, interpreter.WithScripts(lscript, uscript))
+	}
+	tracer := NewTracer()
+	opts = append(opts, oo...)
+	opts = append(opts, interpreter.WithDebugger(tracer))
+
+	// The outcome is compared through the trace result.
+	_ = interpreter.NewEngine().Execute(opts...)
+
+	return tracer.Trace(), nil
+}
+
+// Compare returns the first divergence of the replayed trace from the
+// recorded trace, or nil if they match.
+func Compare(recorded, replayed *Trace) *Divergence {
+	for i := 0; i < len(recorded.Steps) && i < len(replayed.Steps); i++ {
+		if d := compareStep(&recorded.Steps[i], &replayed.Steps[i]); d != nil {
+			d.Step = i
+			return d
+		}
+	}
+	if len(recorded.Steps) != len(replayed.Steps) {
+		n := len(recorded.Steps)
+		if len(replayed.Steps) < n {
+			n = len(replayed.Steps)
+		}
+		return &Divergence{Step: n, Field: "steps", Recorded: len(recorded.Steps), Replayed: len(replayed.Steps)}
+	}
+
+	var rec, rep Result
+	if recorded.Result != nil {
+		rec = *recorded.Result
+	}
+	if replayed.Result != nil {
+		rep = *replayed.Result
+	}
+	switch {
+	case rec.Success != rep.Success:
+		return &Divergence{Step: -1, Field: "success", Recorded: rec.Success, Replayed: rep.Success}
+	case rec.ErrorCode != rep.ErrorCode:
+		return &Divergence{Step: -1, Field: "errorCode", Recorded: rec.ErrorCode, Replayed: rep.ErrorCode}
+	case rec.Err != rep.Err:
+		return &Divergence{Step: -1, Field: "error", Recorded: rec.Err, Replayed: rep.Err}
+	}
+
+	return nil
+}
+
+// compareStep compares the steps field by field, in order of declaration.
+func compareStep(a, b *Step) *Divergence {
+	va, vb := reflect.ValueOf(*a), reflect.ValueOf(*b)
+	for i := 0; i < va.NumField(); i++ {
+		fa, fb := va.Field(i).Interface(), vb.Field(i).Interface()
+		if reflect.DeepEqual(fa, fb) {
+			continue
+		}
+		name := strings.Split(va.Type().Field(i).Tag.Get("json"), ",")[0]
+
+		return &Divergence{Field: name, Recorded: fa, Replayed: fb}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package trace records script executions as structured traces, which can
+// be exported as JSON lines and replayed against the engine to find where
+// its behaviour diverges, for example between library versions.
+//
+// Record a trace by executing with a Tracer as the debugger:
+//
+//	tracer := trace.NewTracer()
+//	err := interpreter.NewEngine().Execute(
+//	    interpreter.WithTx(tx, inputIdx, prevOutput),
+//	    interpreter.WithForkID(),
+//	    interpreter.WithAfterGenesis(),
+//	    interpreter.WithDebugger(tracer),
+//	)
+//	_, _ = tracer.Trace().WriteTo(f)
+package trace
+
+import (
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/debug"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+)
+
+// Version the version of the trace format.
+const Version = 1
+
+// Trace a recorded execution.
+type Trace struct {
+	Header Header
+	Steps  []Step
+	// Result is nil if the execution failed before starting, for
+	// example due to invalid options.
+	Result *Result
+}
+
+// Header the inputs of the execution, needed to replay it.
+type Header struct {
+	Version         int             `json:"version"`
+	LockingScript   string          `json:"lockingScript"`
+	UnlockingScript string          `json:"unlockingScript"`
+	Flags           scriptflag.Flag `json:"flags"`
+	// Tx, InputIdx and Satoshis are set if the execution
+	// was against a transaction, so signature checks can
+	// be replayed.
+	Tx       string `json:"tx,omitempty"`
+	InputIdx int    `json:"inputIdx,omitempty"`
+	Satoshis uint64 `json:"satoshis,omitempty"`
+}
+
+// Step an executed opcode. Stacks are hex encoded, the last
+// item being the top of the stack.
+type Step struct {
+	ScriptIdx      int             `json:"scriptIdx"`
+	OpcodeIdx      int             `json:"opcodeIdx"`
+	Offset         int             `json:"offset"`
+	Opcode         string          `json:"opcode"`
+	Data           string          `json:"data,omitempty"`
+	Flags          scriptflag.Flag `json:"flags"`
+	StackBefore    []string        `json:"stackBefore"`
+	StackAfter     []string        `json:"stackAfter"`
+	AltStackBefore []string        `json:"altStackBefore"`
+	AltStackAfter  []string        `json:"altStackAfter"`
+	// Err is the error the opcode failed with, if any.
+	Err string `json:"error,omitempty"`
+}
+
+// Result the outcome of the execution.
+type Result struct {
+	Success   bool   `json:"success"`
+	Err       string `json:"error,omitempty"`
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// Tracer an interpreter.Debugger which records each executed opcode. Further
+// hooks can be attached as to any debug.DefaultDebugger.
+type Tracer struct {
+	debug.DefaultDebugger
+
+	trace   *Trace
+	pending *Step
+	// next is the position of the opcode following the last step.
+	next *errs.Position
+}
+
+// NewTracer returns a tracer, to be provided to an
+// execution with interpreter.WithDebugger.
+func NewTracer() *Tracer {
+	t := &Tracer{
+		DefaultDebugger: debug.NewDebugger(),
+		trace:           &Trace{Header: Header{Version: Version}},
+	}
+
+	t.AttachBeforeExecute(t.beforeExecute)
+	t.AttachBeforeStep(t.beforeStep)
+	t.AttachAfterStep(t.afterStep)
+	t.AttachAfterSuccess(t.afterSuccess)
+	t.AttachAfterError(t.afterError)
+
+	return t
+}
+
+// Trace returns the trace recorded by the last execution.
+func (t *Tracer) Trace() *Trace {
+	return t.trace
+}
+
+func (t *Tracer) beforeExecute(state *interpreter.State) {
+	h := t.trace.Header
+	h.LockingScript, h.UnlockingScript = "", ""
+	p := &interpreter.DefaultOpcodeParser{}
+	if len(state.Scripts) > 1 {
+		if s, err := p.Unparse(state.Scripts[1]); err == nil {
+			h.LockingScript = s.String()
+		}
+	}
+	if len(state.Scripts) > 0 {
+		if s, err := p.Unparse(state.Scripts[0]); err == nil {
+			h.UnlockingScript = s.String()
+		}
+	}
+	h.Flags = state.Flags
+	h.Tx, h.InputIdx, h.Satoshis = "", 0, 0
+	if state.Tx != nil {
+		h.Tx = state.Tx.String()
+		h.InputIdx = state.InputIdx
+		if state.PrevOutput != nil {
+			h.Satoshis = state.PrevOutput.Satoshis
+		}
+	}
+
+	t.trace = &Trace{Header: h}
+	t.pending = nil
+	t.next = nil
+}
+
+func (t *Tracer) beforeStep(state *interpreter.State) {
+	op := state.Opcode()
+	t.pending = &Step{
+		ScriptIdx:      state.ScriptIdx,
+		OpcodeIdx:      state.OpcodeIdx,
+		Offset:         t.offset(state),
+		Opcode:         op.Name(),
+		Data:           hex.EncodeToString(op.Data),
+		Flags:          state.Flags,
+		StackBefore:    encodeStack(state.DataStack),
+		AltStackBefore: encodeStack(state.AltStack),
+	}
+}
+
+// offset returns the byte offset of the opcode of the state in its script,
+// carrying on from the last step if it is the opcode following it.
+func (t *Tracer) offset(state *interpreter.State) int {
+	p := &interpreter.DefaultOpcodeParser{}
+	var off int
+	if n := t.next; n != nil && n.ScriptIdx == state.ScriptIdx && n.OpcodeIdx == state.OpcodeIdx {
+		off = n.Offset
+	} else if s, err := p.Unparse(state.Scripts[state.ScriptIdx][:state.OpcodeIdx]); err == nil {
+		off = len(*s)
+	}
+
+	t.next = &errs.Position{ScriptIdx: state.ScriptIdx, OpcodeIdx: state.OpcodeIdx + 1, Offset: off}
+	if s, err := p.Unparse(interpreter.ParsedScript{state.Opcode()}); err == nil {
+		t.next.Offset += len(*s)
+	}
+
+	return off
+}
+
+func (t *Tracer) afterStep(state *interpreter.State) {
+	t.completeStep(state, nil)
+}
+
+func (t *Tracer) afterSuccess(*interpreter.State) {
+	t.trace.Result = &Result{Success: true}
+}
+
+func (t *Tracer) afterError(state *interpreter.State, err error) {
+	t.completeStep(state, err)
+
+	t.trace.Result = &Result{Err: err.Error()}
+	if e := (&errs.Error{}); errors.As(err, e) {
+		t.trace.Result.ErrorCode = e.ErrorCode.String()
+	}
+}
+
+// completeStep records the step in progress, if any.
+func (t *Tracer) completeStep(state *interpreter.State, err error) {
+	if t.pending == nil {
+		return
+	}
+
+	s := t.pending
+	s.StackAfter = encodeStack(state.DataStack)
+	s.AltStackAfter = encodeStack(state.AltStack)
+	if err != nil {
+		s.Err = err.Error()
+	}
+	t.trace.Steps = append(t.trace.Steps, *s)
+	t.pending = nil
+}
+
+func encodeStack(stack [][]byte) []string {
+	ss := make([]string, len(stack))
+	for i, b := range stack {
+		ss[i] = hex.EncodeToString(b)
+	}
+
+	return ss
+}
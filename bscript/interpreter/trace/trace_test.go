@@ -0,0 +1,265 @@
+package trace_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/trace"
+)
+
+func record(t *testing.T, lockingScriptHex, unlockingScriptHex string) (*trace.Trace, error) {
+	lscript, err := bscript.NewFromHexString(lockingScriptHex)
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromHexString(unlockingScriptHex)
+	require.NoError(t, err)
+
+	tracer := trace.NewTracer()
+	err = interpreter.NewEngine().Execute(
+		interpreter.WithScripts(lscript, uscript),
+		interpreter.WithAfterGenesis(),
+		interpreter.WithDebugger(tracer),
+	)
+
+	return tracer.Trace(), err
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	// OP_2 OP_3 | OP_TOALTSTACK OP_FROMALTSTACK OP_ADD OP_5 OP_EQUAL
+	tr, err := record(t, "6b6c935587", "5253")
+	require.NoError(t, err)
+
+	assert.Equal(t, trace.Version, tr.Header.Version)
+	assert.Equal(t, "6b6c935587", tr.Header.LockingScript)
+	assert.Equal(t, "5253", tr.Header.UnlockingScript)
+	require.NotNil(t, tr.Result)
+	assert.True(t, tr.Result.Success)
+
+	require.Len(t, tr.Steps, 7)
+	assert.Equal(t, trace.Step{
+		ScriptIdx:      1,
+		OpcodeIdx:      0,
+		Offset:         0,
+		Opcode:         "OP_TOALTSTACK",
+		Flags:          tr.Header.Flags,
+		StackBefore:    []string{"02", "03"},
+		StackAfter:     []string{"02"},
+		AltStackBefore: []string{},
+		AltStackAfter:  []string{"03"},
+	}, tr.Steps[2])
+	assert.Equal(t, 0, tr.Steps[1].ScriptIdx)
+	assert.Equal(t, "OP_ADD", tr.Steps[4].Opcode)
+	assert.Equal(t, []string{"05"}, tr.Steps[4].StackAfter)
+	assert.Equal(t, []string{"01"}, tr.Steps[6].StackAfter)
+}
+
+func TestTracer_Offset(t *testing.T) {
+	t.Parallel()
+
+	// 0x0102 OP_DROP 0x03 | OP_DROP OP_1
+	tr, err := record(t, "7551", "0201027501ff")
+	require.NoError(t, err)
+
+	offsets := make([]int, len(tr.Steps))
+	for i, s := range tr.Steps {
+		offsets[i] = s.Offset
+	}
+	assert.Equal(t, []int{0, 3, 4, 0, 1}, offsets)
+}
+
+func TestTracer_Tx(t *testing.T) {
+	t.Parallel()
+
+	tx, err := bt.NewTxFromString("0200000003a9bc457fdc6a54d99300fb137b23714d860c350a9d19ff0f571e694a419ff3a0010000006b48304502210086c83beb2b2663e4709a583d261d75be538aedcafa7766bd983e5c8db2f8b2fc02201a88b178624ab0ad1748b37c875f885930166237c88f5af78ee4e61d337f935f412103e8be830d98bb3b007a0343ee5c36daa48796ae8bb57946b1e87378ad6e8a090dfeffffff0092bb9a47e27bf64fc98f557c530c04d9ac25e2f2a8b600e92a0b1ae7c89c20010000006b483045022100f06b3db1c0a11af348401f9cebe10ae2659d6e766a9dcd9e3a04690ba10a160f02203f7fbd7dfcfc70863aface1a306fcc91bbadf6bc884c21a55ef0d32bd6b088c8412103e8be830d98bb3b007a0343ee5c36daa48796ae8bb57946b1e87378ad6e8a090dfeffffff9d0d4554fa692420a0830ca614b6c60f1bf8eaaa21afca4aa8c99fb052d9f398000000006b483045022100d920f2290548e92a6235f8b2513b7f693a64a0d3fa699f81a034f4b4608ff82f0220767d7d98025aff3c7bd5f2a66aab6a824f5990392e6489aae1e1ae3472d8dffb412103e8be830d98bb3b007a0343ee5c36daa48796ae8bb57946b1e87378ad6e8a090dfeffffff02807c814a000000001976a9143a6bf34ebfcf30e8541bbb33a7882845e5a29cb488ac76b0e60e000000001976a914bd492b67f90cb85918494767ebb23102c4f06b7088ac67000000")
+	require.NoError(t, err)
+	prevTx, err := bt.NewTxFromString("0200000001424408c9d997772e56112c731b6dc6f050cb3847c5570cea12f30bfbc7df0a010000000049483045022100fe759b2cd7f25bce4fcda4c8366891b0d9289dc5bac1cf216909c89dc324437a02204aa590b6e82764971df4fe741adf41ece4cde607cb6443edceba831060213d3641feffffff02408c380c010000001976a914f761fc0927a43f4fab5740ef39f05b1fb7786f5288ac0065cd1d000000001976a914805096c5167877a5799977d46fb9dee5891dc3cb88ac66000000")
+	require.NoError(t, err)
+	prevOutput := prevTx.OutputIdx(int(tx.InputIdx(0).PreviousTxOutIndex))
+
+	tracer := trace.NewTracer()
+	require.NoError(t, interpreter.NewEngine().Execute(
+		interpreter.WithTx(tx, 0, prevOutput),
+		interpreter.WithForkID(),
+		interpreter.WithAfterGenesis(),
+		interpreter.WithDebugger(tracer),
+	))
+
+	tr := tracer.Trace()
+	assert.Equal(t, tx.String(), tr.Header.Tx)
+	assert.Equal(t, 0, tr.Header.InputIdx)
+	assert.Equal(t, prevOutput.Satoshis, tr.Header.Satoshis)
+
+	d, err := trace.Replay(tr)
+	require.NoError(t, err)
+	assert.Nil(t, d)
+}
+
+func TestTracer_Error(t *testing.T) {
+	t.Parallel()
+
+	// OP_2 OP_2 | OP_ADD OP_5 OP_EQUALVERIFY
+	tr, err := record(t, "935588", "5252")
+	require.Error(t, err)
+
+	require.Len(t, tr.Steps, 5)
+	last := tr.Steps[4]
+	assert.Equal(t, "OP_EQUALVERIFY", last.Opcode)
+	assert.Contains(t, last.Err, "OP_EQUALVERIFY failed")
+	require.NotNil(t, tr.Result)
+	assert.False(t, tr.Result.Success)
+	assert.Equal(t, "ErrEqualVerify", tr.Result.ErrorCode)
+}
+
+func TestTrace_JSONLines(t *testing.T) {
+	t.Parallel()
+
+	tr, err := record(t, "935588", "5252")
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	n, err := tr.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 7)
+	assert.True(t, strings.HasPrefix(lines[0], `{"type":"header","version":1,`))
+	assert.True(t, strings.HasPrefix(lines[1], `{"type":"step","scriptIdx":0,"opcodeIdx":0,"offset":0,"opcode":"OP_2",`))
+	assert.True(t, strings.HasPrefix(lines[6], `{"type":"result","success":false,`))
+
+	read, err := trace.ReadTrace(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, tr, read)
+}
+
+func TestReadTrace_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input  string
+		expErr error
+	}{
+		"empty": {
+			expErr: trace.ErrInvalidTrace,
+		},
+		"not json": {
+			input:  "header\n",
+			expErr: trace.ErrInvalidTrace,
+		},
+		"step before header": {
+			input:  `{"type":"step","opcode":"OP_1"}` + "\n",
+			expErr: trace.ErrInvalidTrace,
+		},
+		"step after result": {
+			input: `{"type":"header","version":1}` + "\n" +
+				`{"type":"result","success":true}` + "\n" +
+				`{"type":"step","opcode":"OP_1"}` + "\n",
+			expErr: trace.ErrInvalidTrace,
+		},
+		"unsupported version": {
+			input:  `{"type":"header","version":2}` + "\n",
+			expErr: trace.ErrUnsupportedVersion,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := trace.ReadTrace(strings.NewReader(test.input))
+			assert.True(t, errors.Is(err, test.expErr), err)
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		locking   string
+		unlocking string
+		tamper    func(tr *trace.Trace)
+		exp       *trace.Divergence
+	}{
+		"success matches": {
+			locking:   "6b6c935587",
+			unlocking: "5253",
+		},
+		"error matches": {
+			locking:   "935588",
+			unlocking: "5252",
+		},
+		"stack diverges": {
+			locking:   "6b6c935587",
+			unlocking: "5253",
+			tamper: func(tr *trace.Trace) {
+				tr.Steps[4].StackAfter = []string{"06"}
+			},
+			exp: &trace.Divergence{Step: 4, Field: "stackAfter", Recorded: []string{"06"}, Replayed: []string{"05"}},
+		},
+		"offset diverges": {
+			locking:   "6b6c935587",
+			unlocking: "5253",
+			tamper: func(tr *trace.Trace) {
+				tr.Steps[3].Offset = 3
+			},
+			exp: &trace.Divergence{Step: 3, Field: "offset", Recorded: 3, Replayed: 1},
+		},
+		"extra step": {
+			locking:   "6b6c935587",
+			unlocking: "5253",
+			tamper: func(tr *trace.Trace) {
+				tr.Steps = append(tr.Steps, trace.Step{Opcode: "OP_NOP"})
+			},
+			exp: &trace.Divergence{Step: 7, Field: "steps", Recorded: 8, Replayed: 7},
+		},
+		"result diverges": {
+			locking:   "935588",
+			unlocking: "5252",
+			tamper: func(tr *trace.Trace) {
+				tr.Result.ErrorCode = "ErrVerify"
+			},
+			exp: &trace.Divergence{Step: -1, Field: "errorCode", Recorded: "ErrVerify", Replayed: "ErrEqualVerify"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tr, _ := record(t, test.locking, test.unlocking)
+
+			// Replay from the serialised trace.
+			var buf bytes.Buffer
+			_, err := tr.WriteTo(&buf)
+			require.NoError(t, err)
+			tr, err = trace.ReadTrace(&buf)
+			require.NoError(t, err)
+
+			if test.tamper != nil {
+				test.tamper(tr)
+			}
+			d, err := trace.Replay(tr)
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, d)
+		})
+	}
+}
+
+func TestReplay_Config(t *testing.T) {
+	t.Parallel()
+
+	// OP_1 OP_1 OP_1 | OP_ADD OP_ADD
+	tr, err := record(t, "9393", "515151")
+	require.NoError(t, err)
+
+	d, err := trace.Replay(tr, interpreter.WithBudget(interpreter.Budget{MaxOpcodes: 4}))
+	require.NoError(t, err)
+	require.NotNil(t, d)
+	assert.Equal(t, "steps", d.Field)
+}
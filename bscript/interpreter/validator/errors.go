@@ -0,0 +1,10 @@
+package validator
+
+import "github.com/pkg/errors"
+
+// Sentinel errors reported by block validation.
+var (
+	ErrPrevOutputNotFound  = errors.New("previous output not found")
+	ErrSpendsLaterTx       = errors.New("input spends a tx later in the block")
+	ErrOutputsExceedInputs = errors.New("outputs exceed inputs")
+)
@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+
+	"github.com/libsv/go-bt/v2"
+)
+
+// PrevOutput an output spent by a tx, along with the height of the block it
+// was created in, which decides the rules its locking script runs under.
+type PrevOutput struct {
+	Output *bt.Output
+	Height uint32
+}
+
+// PrevOutputFetcher fetches the outputs spent by the txs of a block, from
+// a utxo set, a node, or an in-memory store.
+//
+// FetchPrevOutput should return ErrPrevOutputNotFound if the output doesn't
+// exist, which is reported against the input spending it. Any other error
+// aborts the validation.
+type PrevOutputFetcher interface {
+	FetchPrevOutput(ctx context.Context, txID []byte, vout uint32) (*PrevOutput, error)
+}
+
+// MemoryFetcher a PrevOutputFetcher holding outputs in memory.
+// It is safe for concurrent use.
+type MemoryFetcher struct {
+	mu      sync.RWMutex
+	outputs map[outpoint]*PrevOutput
+}
+
+type outpoint struct {
+	txID string
+	vout uint32
+}
+
+// NewMemoryFetcher returns an empty MemoryFetcher.
+func NewMemoryFetcher() *MemoryFetcher {
+	return &MemoryFetcher{outputs: make(map[outpoint]*PrevOutput)}
+}
+
+// Add adds an output of the tx id provided, created at height.
+func (m *MemoryFetcher) Add(txID []byte, vout uint32, output *bt.Output, height uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.outputs[outpoint{txID: hex.EncodeToString(txID), vout: vout}] = &PrevOutput{
+		Output: output,
+		Height: height,
+	}
+}
+
+// AddTx adds every output of the tx, created at height.
+func (m *MemoryFetcher) AddTx(tx *bt.Tx, height uint32) {
+	txID := tx.TxIDBytes()
+	for i, o := range tx.Outputs {
+		m.Add(txID, uint32(i), o, height)
+	}
+}
+
+// FetchPrevOutput returns the output added for the tx id and vout,
+// or ErrPrevOutputNotFound.
+func (m *MemoryFetcher) FetchPrevOutput(_ context.Context, txID []byte, vout uint32) (*PrevOutput, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.outputs[outpoint{txID: hex.EncodeToString(txID), vout: vout}]
+	if !ok {
+		return nil, ErrPrevOutputNotFound
+	}
+
+	return o, nil
+}
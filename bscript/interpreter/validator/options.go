@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/chaincfg"
+)
+
+// OptionFunc for setting validator options.
+type OptionFunc func(o *opts)
+
+type opts struct {
+	params      *chaincfg.Params
+	concurrency int
+	policy      bool
+	execOpts    []interpreter.ExecutionOptionFunc
+}
+
+// WithParams configure the network the block is validated on, which sets the
+// script flags used at each height. Defaults to chaincfg.MainNet.
+func WithParams(params *chaincfg.Params) OptionFunc {
+	return func(o *opts) {
+		o.params = params
+	}
+}
+
+// WithConcurrency configure the number of script checks run at once.
+// Defaults to the number of CPUs.
+func WithConcurrency(n int) OptionFunc {
+	return func(o *opts) {
+		o.concurrency = n
+	}
+}
+
+// WithPolicy configure the validator to check scripts with the policy flags
+// used to accept txs at the height, rather than the consensus flags.
+func WithPolicy() OptionFunc {
+	return func(o *opts) {
+		o.policy = true
+	}
+}
+
+// WithExecutionOptions configure options applied to every script check,
+// after the tx and flags, for example a signature verifier or a config.
+func WithExecutionOptions(oo ...interpreter.ExecutionOptionFunc) OptionFunc {
+	return func(o *opts) {
+		o.execOpts = append(o.execOpts, oo...)
+	}
+}
@@ -0,0 +1,255 @@
+// Package validator validates the scripts of the txs of a block, or of any
+// batch of txs, spending outputs fetched with a PrevOutputFetcher or created
+// earlier in the batch.
+//
+// Every input is checked concurrently with the script flags active at the
+// block height for the height its previous output was created at, and every
+// failure is reported against its tx and input, along with the fees paid:
+//
+//	fetcher := validator.NewMemoryFetcher()
+//	fetcher.AddTx(prevTx, prevHeight)
+//	res, err := validator.New(fetcher).ValidateBlock(ctx, txs, height)
+//	if err != nil {
+//	    return err
+//	}
+//	if !res.Valid() {
+//	    for _, tx := range res.Invalid() {
+//	        ...
+//	    }
+//	}
+package validator
+
+import (
+	"context"
+	"encoding/hex"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/scriptflag"
+	"github.com/libsv/go-bt/v2/chaincfg"
+)
+
+// Validator validates the txs of blocks.
+type Validator struct {
+	fetcher PrevOutputFetcher
+	engine  interpreter.ContextEngine
+	opts
+}
+
+// Result the result of validating the txs of a block.
+type Result struct {
+	Height uint32
+	Txs    []*TxResult
+	// Fees the total fees paid by the txs whose outputs
+	// were all resolved and don't exceed their inputs.
+	Fees uint64
+}
+
+// Valid returns true if every tx is valid.
+func (r *Result) Valid() bool {
+	return len(r.Invalid()) == 0
+}
+
+// Invalid returns the results of the invalid txs, in block order.
+func (r *Result) Invalid() []*TxResult {
+	var invalid []*TxResult
+	for _, tx := range r.Txs {
+		if !tx.Valid() {
+			invalid = append(invalid, tx)
+		}
+	}
+
+	return invalid
+}
+
+// TxResult the result of validating a tx. The scripts of coinbase
+// txs aren't checked and they pay no fee.
+type TxResult struct {
+	TxID     string
+	Coinbase bool
+	Fee      uint64
+	// Err a failure of the tx as a whole, ErrOutputsExceedInputs.
+	Err error
+	// Failures the inputs which failed, in input order.
+	Failures []InputFailure
+}
+
+// Valid returns true if the tx and all its inputs are valid.
+func (t *TxResult) Valid() bool {
+	return t.Err == nil && len(t.Failures) == 0
+}
+
+// InputFailure the failure of an input, either its previous output not being
+// resolved or its scripts failing, in which case Err is an errs.Error.
+type InputFailure struct {
+	InputIdx int
+	Err      error
+}
+
+// New returns a Validator fetching previous outputs with the fetcher provided.
+func New(fetcher PrevOutputFetcher, oo ...OptionFunc) *Validator {
+	v := &Validator{
+		fetcher: fetcher,
+		engine:  interpreter.NewContextEngine(),
+		opts: opts{
+			params:      &chaincfg.MainNet,
+			concurrency: runtime.NumCPU(),
+		},
+	}
+	for _, o := range oo {
+		o(&v.opts)
+	}
+	if v.concurrency < 1 {
+		v.concurrency = 1
+	}
+
+	return v
+}
+
+// ValidateBlock validates the txs of a block at height, such as read with
+// Txs.ReadFrom. Inputs can spend the outputs of txs earlier in the block.
+//
+// Failures of txs and inputs are reported in the result. An error is only
+// returned if the fetcher fails or the context is done.
+func (v *Validator) ValidateBlock(ctx context.Context, txs bt.Txs, height uint32) (*Result, error) {
+	res := &Result{Height: height, Txs: make([]*TxResult, len(txs))}
+	blockTxs := make(map[string]int, len(txs))
+	for i, tx := range txs {
+		txID := tx.TxID()
+		if _, ok := blockTxs[txID]; !ok {
+			blockTxs[txID] = i
+		}
+		res.Txs[i] = &TxResult{TxID: txID, Coinbase: tx.IsCoinbase()}
+	}
+
+	// Each input writes to its own slot, so no locking is needed.
+	prevOutputs := make([][]*PrevOutput, len(txs))
+	inputErrs := make([][]error, len(txs))
+	for i, tx := range txs {
+		if !res.Txs[i].Coinbase {
+			prevOutputs[i] = make([]*PrevOutput, len(tx.Inputs))
+			inputErrs[i] = make([]error, len(tx.Inputs))
+		}
+	}
+
+	if err := v.forEachInput(ctx, prevOutputs, func(ctx context.Context, i, j int) error {
+		prev, err := v.prevOutput(ctx, txs, blockTxs, i, txs[i].Inputs[j], height)
+		if errors.Is(err, ErrPrevOutputNotFound) || errors.Is(err, ErrSpendsLaterTx) {
+			inputErrs[i][j] = err
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		prevOutputs[i][j] = prev
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := v.forEachInput(ctx, prevOutputs, func(ctx context.Context, i, j int) error {
+		prev := prevOutputs[i][j]
+		if prev == nil {
+			return nil
+		}
+		tx := txs[i]
+		inputErrs[i][j] = v.engine.ExecuteContext(ctx, append([]interpreter.ExecutionOptionFunc{
+			interpreter.WithTx(tx, j, prev.Output),
+			interpreter.WithFlags(v.flags(height, prev.Height).ForTxVersion(tx.Version)),
+		}, v.execOpts...)...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for i, tx := range txs {
+		r := res.Txs[i]
+		if r.Coinbase {
+			continue
+		}
+
+		var inputs uint64
+		resolved := true
+		for j, prev := range prevOutputs[i] {
+			if err := inputErrs[i][j]; err != nil {
+				r.Failures = append(r.Failures, InputFailure{InputIdx: j, Err: err})
+			}
+			if prev == nil {
+				resolved = false
+				continue
+			}
+			inputs += prev.Output.Satoshis
+		}
+		if !resolved {
+			continue
+		}
+
+		outputs := tx.TotalOutputSatoshis()
+		if outputs > inputs {
+			r.Err = errors.Wrapf(ErrOutputsExceedInputs, "%d > %d", outputs, inputs)
+			continue
+		}
+		r.Fee = inputs - outputs
+		res.Fees += r.Fee
+	}
+
+	return res, nil
+}
+
+// forEachInput calls fn concurrently for each input of the txs which aren't
+// coinbases, returning the first error, or the error of the context.
+func (v *Validator) forEachInput(ctx context.Context, prevOutputs [][]*PrevOutput,
+	fn func(ctx context.Context, txIdx, inputIdx int) error) error {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(v.concurrency)
+	for i := range prevOutputs {
+		for j := range prevOutputs[i] {
+			i, j := i, j
+			g.Go(func() error {
+				if err := gctx.Err(); err != nil {
+					return err
+				}
+				return fn(gctx, i, j)
+			})
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// prevOutput resolves the output spent by an input of the tx at txIdx, from
+// an earlier tx in the block, or with the fetcher.
+func (v *Validator) prevOutput(ctx context.Context, txs bt.Txs, blockTxs map[string]int, txIdx int,
+	in *bt.Input, height uint32) (*PrevOutput, error) {
+	parentIdx, ok := blockTxs[hex.EncodeToString(in.PreviousTxID())]
+	if !ok {
+		return v.fetcher.FetchPrevOutput(ctx, in.PreviousTxID(), in.PreviousTxOutIndex)
+	}
+	if parentIdx >= txIdx {
+		return nil, errors.Wrapf(ErrSpendsLaterTx, "tx %d spends tx %d", txIdx, parentIdx)
+	}
+
+	parent := txs[parentIdx]
+	if int(in.PreviousTxOutIndex) >= len(parent.Outputs) {
+		return nil, errors.Wrapf(ErrPrevOutputNotFound, "%s:%d", in.PreviousTxIDStr(), in.PreviousTxOutIndex)
+	}
+
+	// Outputs created in the block are spent at its height.
+	return &PrevOutput{Output: parent.Outputs[in.PreviousTxOutIndex], Height: height}, nil
+}
+
+func (v *Validator) flags(height, utxoHeight uint32) scriptflag.Flag {
+	consensus, policy := scriptflag.ForHeight(v.params, height, utxoHeight)
+	if v.policy {
+		return policy
+	}
+
+	return consensus
+}
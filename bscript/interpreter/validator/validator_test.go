@@ -0,0 +1,265 @@
+package validator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/errs"
+	"github.com/libsv/go-bt/v2/bscript/interpreter/validator"
+	"github.com/libsv/go-bt/v2/unlocker"
+)
+
+const (
+	blockHeight = 800000
+	fundHeight  = 799000
+)
+
+type chain struct {
+	key     *bec.PrivateKey
+	lscript *bscript.Script
+}
+
+func newChain(t *testing.T) *chain {
+	key, err := bec.NewPrivateKey(bec.S256())
+	require.NoError(t, err)
+	lscript, err := bscript.NewP2PKHFromPubKeyEC(key.PubKey())
+	require.NoError(t, err)
+
+	return &chain{key: key, lscript: lscript}
+}
+
+// spend returns a signed tx spending the output of prevTx at vout.
+func (c *chain) spend(t *testing.T, prevTx *bt.Tx, vout uint32, satoshis uint64) *bt.Tx {
+	tx := bt.NewTx()
+	require.NoError(t, tx.From(prevTx.TxID(), vout, c.lscript.String(), prevTx.Outputs[vout].Satoshis))
+	require.NoError(t, tx.PayTo(c.lscript, satoshis))
+	require.NoError(t, tx.FillAllInputs(context.Background(), &unlocker.Getter{PrivateKey: c.key}))
+
+	return tx
+}
+
+func (c *chain) fund(t *testing.T, satoshis uint64) *bt.Tx {
+	tx := bt.NewTx()
+	require.NoError(t, tx.From("0000000000000000000000000000000000000000000000000000000000000001", 0, c.lscript.String(), satoshis))
+	require.NoError(t, tx.PayTo(c.lscript, satoshis))
+
+	return tx
+}
+
+func coinbase(t *testing.T) *bt.Tx {
+	tx := bt.NewTx()
+	in := &bt.Input{
+		PreviousTxOutIndex: bt.DefaultSequenceNumber,
+		SequenceNumber:     bt.DefaultSequenceNumber,
+		UnlockingScript:    bscript.NewFromBytes([]byte{0x03, 0x00, 0x35, 0x0c}),
+	}
+	require.NoError(t, in.PreviousTxIDAdd(make([]byte, 32)))
+	tx.Inputs = append(tx.Inputs, in)
+	tx.AddOutput(&bt.Output{Satoshis: 625000000, LockingScript: &bscript.Script{}})
+
+	return tx
+}
+
+func failureErrs(r *validator.TxResult) []error {
+	var ee []error
+	for _, f := range r.Failures {
+		ee = append(ee, f.Err)
+	}
+	return ee
+}
+
+func TestValidator_ValidateBlock(t *testing.T) {
+	t.Parallel()
+
+	c := newChain(t)
+	fund := c.fund(t, 10000)
+
+	tests := map[string]struct {
+		block     func() bt.Txs
+		fetched   bool
+		expValid  bool
+		expFees   uint64
+		expResult func(t *testing.T, res *validator.Result)
+	}{
+		"spends earlier tx in block": {
+			block: func() bt.Txs {
+				tx1 := c.spend(t, fund, 0, 9000)
+				return bt.Txs{coinbase(t), tx1, c.spend(t, tx1, 0, 8500)}
+			},
+			fetched:  true,
+			expValid: true,
+			expFees:  1500,
+			expResult: func(t *testing.T, res *validator.Result) {
+				assert.True(t, res.Txs[0].Coinbase)
+				assert.Equal(t, uint64(1000), res.Txs[1].Fee)
+				assert.Equal(t, uint64(500), res.Txs[2].Fee)
+			},
+		},
+		"prev output not found": {
+			block: func() bt.Txs {
+				tx1 := c.spend(t, fund, 0, 9000)
+				return bt.Txs{coinbase(t), tx1, c.spend(t, tx1, 0, 8500)}
+			},
+			expFees: 500,
+			expResult: func(t *testing.T, res *validator.Result) {
+				require.Len(t, res.Invalid(), 1)
+				require.Len(t, res.Txs[1].Failures, 1)
+				assert.True(t, errors.Is(res.Txs[1].Failures[0].Err, validator.ErrPrevOutputNotFound))
+				assert.Zero(t, res.Txs[1].Fee)
+				assert.True(t, res.Txs[2].Valid())
+			},
+		},
+		"spends later tx in block": {
+			block: func() bt.Txs {
+				tx1 := c.spend(t, fund, 0, 9000)
+				return bt.Txs{coinbase(t), c.spend(t, tx1, 0, 8500), tx1}
+			},
+			fetched: true,
+			expFees: 1000,
+			expResult: func(t *testing.T, res *validator.Result) {
+				require.Len(t, res.Invalid(), 1)
+				assert.True(t, errors.Is(failureErrs(res.Txs[1])[0], validator.ErrSpendsLaterTx))
+			},
+		},
+		"invalid signature fails nullfail": {
+			block: func() bt.Txs {
+				tx1 := c.spend(t, fund, 0, 9000)
+				tx1.Outputs[0].Satoshis = 8000
+				return bt.Txs{coinbase(t), tx1}
+			},
+			fetched: true,
+			expFees: 2000,
+			expResult: func(t *testing.T, res *validator.Result) {
+				require.Len(t, res.Txs[1].Failures, 1)
+				assert.Equal(t, 0, res.Txs[1].Failures[0].InputIdx)
+				assert.True(t, errs.IsErrorCode(res.Txs[1].Failures[0].Err, errs.ErrNullFail))
+			},
+		},
+		"outputs exceed inputs": {
+			block: func() bt.Txs {
+				return bt.Txs{coinbase(t), c.spend(t, fund, 0, 10001)}
+			},
+			fetched: true,
+			expResult: func(t *testing.T, res *validator.Result) {
+				assert.Empty(t, res.Txs[1].Failures)
+				assert.True(t, errors.Is(res.Txs[1].Err, validator.ErrOutputsExceedInputs))
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fetcher := validator.NewMemoryFetcher()
+			if test.fetched {
+				fetcher.AddTx(fund, fundHeight)
+			}
+
+			res, err := validator.New(fetcher, validator.WithConcurrency(2)).
+				ValidateBlock(context.Background(), test.block(), blockHeight)
+			require.NoError(t, err)
+			assert.Equal(t, test.expValid, res.Valid())
+			assert.Equal(t, test.expFees, res.Fees)
+			test.expResult(t, res)
+		})
+	}
+}
+
+func TestValidator_ValidateBlock_FlagsPerHeight(t *testing.T) {
+	t.Parallel()
+
+	// OP_RETURN ends execution successfully only in scripts of utxos
+	// created after genesis.
+	lscript, err := bscript.NewFromASM("OP_RETURN")
+	require.NoError(t, err)
+	uscript, err := bscript.NewFromASM("OP_1")
+	require.NoError(t, err)
+
+	prevTx := bt.NewTx()
+	prevTx.AddOutput(&bt.Output{Satoshis: 1000, LockingScript: lscript})
+	prevTx.AddOutput(&bt.Output{Satoshis: 1000, LockingScript: lscript})
+
+	tx := bt.NewTx()
+	require.NoError(t, tx.From(prevTx.TxID(), 0, lscript.String(), 1000))
+	require.NoError(t, tx.From(prevTx.TxID(), 1, lscript.String(), 1000))
+	tx.Inputs[0].UnlockingScript = uscript
+	tx.Inputs[1].UnlockingScript = uscript
+	tx.AddOutput(&bt.Output{Satoshis: 1900, LockingScript: lscript})
+
+	fetcher := validator.NewMemoryFetcher()
+	fetcher.Add(prevTx.TxIDBytes(), 0, prevTx.Outputs[0], 600000)
+	fetcher.Add(prevTx.TxIDBytes(), 1, prevTx.Outputs[1], 700000)
+
+	res, err := validator.New(fetcher).ValidateBlock(context.Background(), bt.Txs{tx}, blockHeight)
+	require.NoError(t, err)
+	require.Len(t, res.Txs[0].Failures, 1)
+	assert.Equal(t, 0, res.Txs[0].Failures[0].InputIdx)
+	assert.True(t, errs.IsErrorCode(res.Txs[0].Failures[0].Err, errs.ErrEarlyReturn))
+	assert.Equal(t, uint64(100), res.Fees)
+}
+
+type errFetcher struct{}
+
+var errFetch = errors.New("fetch failed")
+
+func (errFetcher) FetchPrevOutput(context.Context, []byte, uint32) (*validator.PrevOutput, error) {
+	return nil, errFetch
+}
+
+func TestValidator_ValidateBlock_FetcherError(t *testing.T) {
+	t.Parallel()
+
+	c := newChain(t)
+	tx := c.spend(t, c.fund(t, 10000), 0, 9000)
+
+	_, err := validator.New(errFetcher{}).ValidateBlock(context.Background(), bt.Txs{coinbase(t), tx}, blockHeight)
+	assert.True(t, errors.Is(err, errFetch))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fetcher := validator.NewMemoryFetcher()
+	_, err = validator.New(fetcher).ValidateBlock(ctx, bt.Txs{coinbase(t), tx}, blockHeight)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestValidator_ValidateBlock_MultiInput(t *testing.T) {
+	t.Parallel()
+
+	c := newChain(t)
+	fund := bt.NewTx()
+	require.NoError(t, fund.From("0000000000000000000000000000000000000000000000000000000000000001", 0, c.lscript.String(), 8000))
+	for i := 0; i < 8; i++ {
+		require.NoError(t, fund.PayTo(c.lscript, 1000))
+	}
+
+	signed := bt.NewTx()
+	for i := range fund.Outputs {
+		require.NoError(t, signed.From(fund.TxID(), uint32(i), c.lscript.String(), 1000))
+	}
+	require.NoError(t, signed.PayTo(c.lscript, 7000))
+	require.NoError(t, signed.FillAllInputs(context.Background(), &unlocker.Getter{PrivateKey: c.key}))
+
+	// Parsed from bytes, as read with Txs.ReadFrom, so without previous outputs.
+	tx, err := bt.NewTxFromBytes(signed.Bytes())
+	require.NoError(t, err)
+
+	fetcher := validator.NewMemoryFetcher()
+	fetcher.AddTx(fund, fundHeight)
+	res, err := validator.New(fetcher, validator.WithConcurrency(8)).
+		ValidateBlock(context.Background(), bt.Txs{coinbase(t), tx}, blockHeight)
+	require.NoError(t, err)
+	assert.True(t, res.Valid(), failureErrs(res.Txs[1]))
+	assert.Equal(t, uint64(1000), res.Fees)
+
+	// The txs of the block are left untouched.
+	for _, in := range tx.Inputs {
+		assert.Nil(t, in.PreviousTxScript)
+		assert.Zero(t, in.PreviousTxSatoshis)
+	}
+}
@@ -222,6 +222,25 @@ func (s *Script) AppendOpcodes(oo ...uint8) error {
 	return nil
 }
 
+// AppendInt appends the integer n to the script using the smallest
+// possible encoding: OP_0, OP_1NEGATE and OP_1 to OP_16 for the small
+// integers, otherwise a minimal push of the script number.
+func (s *Script) AppendInt(n int64) error {
+	switch {
+	case n == 0:
+		*s = append(*s, Op0)
+		return nil
+	case n == -1:
+		*s = append(*s, Op1NEGATE)
+		return nil
+	case n >= 1 && n <= 16:
+		*s = append(*s, Op1+byte(n-1))
+		return nil
+	}
+
+	return s.AppendPushData(ScriptNumber(n).Bytes())
+}
+
 // String implements the stringer interface and returns the hex string of script.
 func (s *Script) String() string {
 	return hex.EncodeToString(*s)
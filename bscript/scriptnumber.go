@@ -0,0 +1,213 @@
+package bscript
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// MaxScriptNumberInt64Len is the maximum length of an encoded
+// script number which always fits into an int64.
+const MaxScriptNumberInt64Len = 8
+
+// ScriptNumber is an integer as it is represented in script.
+//
+// Numbers are encoded as little endian with the most significant bit
+// of the last byte used as the sign bit. Zero is encoded as an empty
+// byte slice.
+//
+// Example encodings:
+//
+//	   127 -> [0x7f]
+//	  -127 -> [0xff]
+//	   128 -> [0x80 0x00]
+//	  -128 -> [0x80 0x80]
+//	   256 -> [0x00 0x01]
+//	 32767 -> [0xff 0x7f]
+//	-32767 -> [0xff 0xff]
+//	 32768 -> [0x00 0x80 0x00]
+type ScriptNumber int64
+
+// Bytes returns the minimal script encoding of the number.
+func (n ScriptNumber) Bytes() []byte {
+	if n == 0 {
+		return []byte{}
+	}
+
+	isNegative := n < 0
+	abs := uint64(n)
+	if isNegative {
+		abs = uint64(-n) // -MinInt64 wraps to 1<<63 as required
+	}
+
+	result := make([]byte, 0, MaxScriptNumberInt64Len+1)
+	for abs > 0 {
+		result = append(result, byte(abs&0xff))
+		abs >>= 8
+	}
+
+	return appendSignBit(result, isNegative)
+}
+
+// DecodeScriptNumber decodes the script encoded number bb.
+//
+// ErrScriptNumberTooBig is returned if bb is longer than MaxScriptNumberInt64Len,
+// use DecodeBigScriptNumber for larger numbers. If requireMinimal is set,
+// ErrScriptNumberNotMinimal is returned if bb isn't minimally encoded.
+func DecodeScriptNumber(bb []byte, requireMinimal bool) (ScriptNumber, error) {
+	if len(bb) > MaxScriptNumberInt64Len {
+		return 0, fmt.Errorf("%w: %d bytes exceeds max of %d", ErrScriptNumberTooBig, len(bb), MaxScriptNumberInt64Len)
+	}
+	if requireMinimal {
+		if err := CheckMinimalEncoding(bb); err != nil {
+			return 0, err
+		}
+	}
+	if len(bb) == 0 {
+		return 0, nil
+	}
+
+	var v uint64
+	for i, b := range bb {
+		v |= uint64(b) << uint8(8*i)
+	}
+
+	// When the most significant byte has the sign bit set, the
+	// result is negative. With the sign bit removed, 8 bytes or
+	// less always fit into an int64.
+	if bb[len(bb)-1]&0x80 != 0 {
+		v &= ^(uint64(0x80) << uint8(8*(len(bb)-1)))
+		return ScriptNumber(-int64(v)), nil
+	}
+
+	return ScriptNumber(v), nil
+}
+
+// BigScriptNumberBytes returns the minimal script encoding of n,
+// for numbers which may not fit into a ScriptNumber.
+func BigScriptNumberBytes(n *big.Int) []byte {
+	if n.Sign() == 0 {
+		return []byte{}
+	}
+
+	abs := new(big.Int).Abs(n).Bytes() // big endian
+	result := make([]byte, len(abs), len(abs)+1)
+	for i, b := range abs {
+		result[len(abs)-1-i] = b
+	}
+
+	return appendSignBit(result, n.Sign() < 0)
+}
+
+// DecodeBigScriptNumber decodes the script encoded number bb of any
+// length. If requireMinimal is set, ErrScriptNumberNotMinimal is
+// returned if bb isn't minimally encoded.
+func DecodeBigScriptNumber(bb []byte, requireMinimal bool) (*big.Int, error) {
+	if len(bb) <= MaxScriptNumberInt64Len {
+		n, err := DecodeScriptNumber(bb, requireMinimal)
+		if err != nil {
+			return nil, err
+		}
+		return big.NewInt(int64(n)), nil
+	}
+	if requireMinimal {
+		if err := CheckMinimalEncoding(bb); err != nil {
+			return nil, err
+		}
+	}
+
+	// Reverse into big endian, stripping the sign bit.
+	be := make([]byte, len(bb))
+	for i, b := range bb {
+		be[len(bb)-1-i] = b
+	}
+	isNegative := be[0]&0x80 != 0
+	be[0] &= 0x7f
+
+	v := new(big.Int).SetBytes(be)
+	if isNegative {
+		v.Neg(v)
+	}
+
+	return v, nil
+}
+
+// MinimallyEncode returns the script encoded number data in its
+// minimal form. data may be modified in place.
+func MinimallyEncode(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+
+	last := data[len(data)-1]
+	if last&0x7f != 0 {
+		return data
+	}
+
+	if len(data) == 1 {
+		return []byte{}
+	}
+
+	if data[len(data)-2]&0x80 != 0 {
+		return data
+	}
+
+	for i := len(data) - 1; i > 0; i-- {
+		if data[i-1] != 0 {
+			if data[i-1]&0x80 != 0 {
+				data[i] = last
+				i++
+			} else {
+				data[i-1] |= last
+			}
+
+			return data[:i]
+		}
+	}
+
+	return []byte{}
+}
+
+// CheckMinimalEncoding returns ErrScriptNumberNotMinimal if the script
+// encoded number bb isn't encoded with the minimum possible number of bytes.
+func CheckMinimalEncoding(bb []byte) error {
+	if len(bb) == 0 {
+		return nil
+	}
+
+	// If the most-significant-byte - excluding the sign bit - is zero
+	// then we're not minimal.  Note how this test also rejects the
+	// negative-zero encoding, [0x80].
+	if bb[len(bb)-1]&0x7f == 0 {
+		// One exception: if there's more than one byte and the most
+		// significant bit of the second-most-significant-byte is set
+		// it would conflict with the sign bit.  An example of this case
+		// is +-255, which encode to 0xff00 and 0xff80 respectively.
+		// (big-endian).
+		if len(bb) == 1 || bb[len(bb)-2]&0x80 == 0 {
+			return fmt.Errorf("%w: %x", ErrScriptNumberNotMinimal, bb)
+		}
+	}
+
+	return nil
+}
+
+// appendSignBit sets the sign bit on the little endian magnitude bb.
+//
+// When the most significant byte already has the high bit set, an
+// additional high byte is required to indicate whether the number is
+// negative or positive.  Otherwise, the high bit of the most significant
+// byte is used to indicate the value is negative, if needed.
+func appendSignBit(bb []byte, isNegative bool) []byte {
+	if bb[len(bb)-1]&0x80 != 0 {
+		extraByte := byte(0x00)
+		if isNegative {
+			extraByte = 0x80
+		}
+		return append(bb, extraByte)
+	}
+	if isNegative {
+		bb[len(bb)-1] |= 0x80
+	}
+
+	return bb
+}
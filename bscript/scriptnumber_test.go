@@ -0,0 +1,175 @@
+package bscript_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+func TestScriptNumber_Bytes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		n      bscript.ScriptNumber
+		expHex string
+	}{
+		"zero":             {n: 0, expHex: ""},
+		"one":              {n: 1, expHex: "01"},
+		"minus one":        {n: -1, expHex: "81"},
+		"127":              {n: 127, expHex: "7f"},
+		"-127":             {n: -127, expHex: "ff"},
+		"128":              {n: 128, expHex: "8000"},
+		"-128":             {n: -128, expHex: "8080"},
+		"256":              {n: 256, expHex: "0001"},
+		"-256":             {n: -256, expHex: "0081"},
+		"32768":            {n: 32768, expHex: "008000"},
+		"-32768":           {n: -32768, expHex: "008080"},
+		"max int32":        {n: math.MaxInt32, expHex: "ffffff7f"},
+		"max int64":        {n: math.MaxInt64, expHex: "ffffffffffffff7f"},
+		"min int64":        {n: math.MinInt64, expHex: "000000000000008080"},
+		"min int64 plus 1": {n: math.MinInt64 + 1, expHex: "ffffffffffffffff"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bb := test.n.Bytes()
+			assert.Equal(t, test.expHex, hex.EncodeToString(bb))
+
+			n, err := bscript.DecodeBigScriptNumber(bb, true)
+			require.NoError(t, err)
+			assert.Equal(t, big.NewInt(int64(test.n)), n)
+			assert.Equal(t, bb, bscript.BigScriptNumberBytes(n))
+		})
+	}
+}
+
+func TestDecodeScriptNumber(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		hex            string
+		requireMinimal bool
+		exp            bscript.ScriptNumber
+		expErr         error
+	}{
+		"empty is zero": {
+			hex: "", requireMinimal: true, exp: 0,
+		},
+		"negative one": {
+			hex: "81", requireMinimal: true, exp: -1,
+		},
+		"255 needs sign byte": {
+			hex: "ff00", requireMinimal: true, exp: 255,
+		},
+		"negative zero rejected when minimal": {
+			hex: "80", requireMinimal: true, expErr: bscript.ErrScriptNumberNotMinimal,
+		},
+		"negative zero allowed when not minimal": {
+			hex: "80", exp: 0,
+		},
+		"padded rejected when minimal": {
+			hex: "7f00", requireMinimal: true, expErr: bscript.ErrScriptNumberNotMinimal,
+		},
+		"padded allowed when not minimal": {
+			hex: "7f0000", exp: 127,
+		},
+		"too big for int64": {
+			hex: "000000000000008080", expErr: bscript.ErrScriptNumberTooBig,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bb, err := hex.DecodeString(test.hex)
+			require.NoError(t, err)
+
+			n, err := bscript.DecodeScriptNumber(bb, test.requireMinimal)
+			if test.expErr != nil {
+				assert.True(t, errors.Is(err, test.expErr), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, n)
+		})
+	}
+}
+
+func TestBigScriptNumber(t *testing.T) {
+	t.Parallel()
+
+	big1 := new(big.Int).Lsh(big.NewInt(1), 100)
+	for _, n := range []*big.Int{big1, new(big.Int).Neg(big1), new(big.Int).Sub(big1, big.NewInt(1))} {
+		bb := bscript.BigScriptNumberBytes(n)
+		assert.NoError(t, bscript.CheckMinimalEncoding(bb))
+
+		dec, err := bscript.DecodeBigScriptNumber(bb, true)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n.Cmp(dec), "%s != %s", n, dec)
+	}
+
+	_, err := bscript.DecodeBigScriptNumber(append(bscript.BigScriptNumberBytes(big1), 0x00), true)
+	assert.True(t, errors.Is(err, bscript.ErrScriptNumberNotMinimal))
+}
+
+func TestMinimallyEncode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		hex    string
+		expHex string
+	}{
+		"empty":              {hex: "", expHex: ""},
+		"already minimal":    {hex: "7f", expHex: "7f"},
+		"negative zero":      {hex: "80", expHex: ""},
+		"padded zero":        {hex: "0000", expHex: ""},
+		"padded positive":    {hex: "7f0000", expHex: "7f"},
+		"padded negative":    {hex: "7f0080", expHex: "ff"},
+		"sign byte needed":   {hex: "ff0000", expHex: "ff00"},
+		"sign byte negative": {hex: "ff0080", expHex: "ff80"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bb, err := hex.DecodeString(test.hex)
+			require.NoError(t, err)
+
+			min := bscript.MinimallyEncode(bb)
+			assert.Equal(t, test.expHex, hex.EncodeToString(min))
+			assert.NoError(t, bscript.CheckMinimalEncoding(min))
+		})
+	}
+}
+
+func TestScript_AppendInt(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		n      int64
+		expHex string
+	}{
+		"zero":      {n: 0, expHex: "00"},
+		"minus one": {n: -1, expHex: "4f"},
+		"one":       {n: 1, expHex: "51"},
+		"sixteen":   {n: 16, expHex: "60"},
+		"seventeen": {n: 17, expHex: "0111"},
+		"minus two": {n: -2, expHex: "0182"},
+		"1000":      {n: 1000, expHex: "02e803"},
+		"max int64": {n: math.MaxInt64, expHex: "08ffffffffffffff7f"},
+		"min int64": {n: math.MinInt64, expHex: "09000000000000008080"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &bscript.Script{}
+			require.NoError(t, s.AppendInt(test.n))
+			assert.Equal(t, test.expHex, s.String())
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package bscript
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/libsv/go-bk/bec"
+
+	"github.com/libsv/go-bt/v2/chaincfg"
+)
+
+// TemplateParamType the type of a Template parameter, which determines
+// the arguments accepted and how the parameter appears in the script.
+type TemplateParamType byte
+
+// Supported TemplateParamTypes.
+const (
+	// TemplateParamBytes is pushed as data. Arguments are []byte.
+	TemplateParamBytes TemplateParamType = iota + 1
+	// TemplateParamInt is pushed as a minimal script number. Arguments are
+	// int, int32, int64, uint32, uint64, ScriptNumber or *big.Int and are
+	// matched as an int64, or a *big.Int if they don't fit.
+	TemplateParamInt
+	// TemplateParamString is pushed as UTF-8 data. Arguments are string.
+	TemplateParamString
+	// TemplateParamPubKey is pushed as a 33 or 65 byte public key. Arguments
+	// are []byte or *bec.PublicKey, which is pushed compressed, and are
+	// matched as []byte.
+	TemplateParamPubKey
+	// TemplateParamPubKeyHash is pushed as a 20 byte hash. Arguments are []byte.
+	TemplateParamPubKeyHash
+)
+
+// TemplateParam a named parameter of a Template.
+type TemplateParam struct {
+	Name string
+	Type TemplateParamType
+}
+
+// Template is a script with typed placeholders, which can be encoded
+// as a bitcoin-template BIP276, instantiated into a script and matched
+// against a script to extract the arguments.
+type Template struct {
+	Params   []TemplateParam
+	segments []templateSegment
+}
+
+// templateSegment is either raw script, or a reference to a param.
+type templateSegment struct {
+	script Script
+	param  int
+}
+
+// template segment tags in the encoded template.
+const (
+	templateTagScript byte = iota
+	templateTagParam
+)
+
+// NewTemplate creates a template from the ASM source, see Assemble, where
+// every $name placeholder must be one of the params provided. Constants and
+// macros are taken from args, args.Params are ignored.
+func NewTemplate(asm string, args *AssembleArgs, params ...TemplateParam) (*Template, error) {
+	if args == nil {
+		args = &AssembleArgs{}
+	}
+
+	if len(params) > 0xff {
+		return nil, fmt.Errorf("%w: %d params exceeds max of 255", ErrTemplateInvalidParam, len(params))
+	}
+
+	t := &Template{Params: params}
+	idx := make(map[string]int, len(params))
+	for i, p := range params {
+		if _, ok := idx[p.Name]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateDuplicateParam, p.Name)
+		}
+		if p.Type < TemplateParamBytes || p.Type > TemplateParamPubKeyHash {
+			return nil, fmt.Errorf("%w: %s has type %d", ErrTemplateInvalidParam, p.Name, p.Type)
+		}
+		idx[p.Name] = i
+	}
+
+	used := make(map[string]bool, len(params))
+	a := &assembler{args: args, s: &Script{}}
+	a.param = func(name string) error {
+		i, ok := idx[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrTemplateUnknownParam, name)
+		}
+		used[name] = true
+		t.appendScript(*a.s)
+		t.segments = append(t.segments, templateSegment{param: i})
+		*a.s = Script{}
+		return nil
+	}
+	if err := a.assemble(asm); err != nil {
+		return nil, err
+	}
+	t.appendScript(*a.s)
+
+	for _, p := range params {
+		if !used[p.Name] {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateUnusedParam, p.Name)
+		}
+	}
+
+	return t, nil
+}
+
+// NewTemplateFromBytes decodes a template from its byte encoding, see Bytes.
+func NewTemplateFromBytes(b []byte) (*Template, error) {
+	parts, err := DecodeParts(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateInvalidData, err)
+	}
+	if len(parts) == 0 || len(parts[0]) != 1 || len(parts) < 1+int(parts[0][0]) {
+		return nil, ErrTemplateInvalidData
+	}
+
+	t := &Template{Params: make([]TemplateParam, parts[0][0])}
+	for i := range t.Params {
+		p := parts[1+i]
+		if len(p) == 0 {
+			return nil, ErrTemplateInvalidData
+		}
+		t.Params[i] = TemplateParam{Type: TemplateParamType(p[0]), Name: string(p[1:])}
+	}
+
+	for _, p := range parts[1+len(t.Params):] {
+		switch {
+		case len(p) > 1 && p[0] == templateTagScript:
+			t.segments = append(t.segments, templateSegment{script: Script(p[1:])})
+		case len(p) == 2 && p[0] == templateTagParam && int(p[1]) < len(t.Params):
+			t.segments = append(t.segments, templateSegment{param: int(p[1])})
+		default:
+			return nil, ErrTemplateInvalidData
+		}
+	}
+
+	// Validate by re-creating the template from its ASM.
+	if _, err := NewTemplate(t.ASM(), nil, t.Params...); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateInvalidData, err)
+	}
+
+	return t, nil
+}
+
+// DecodeBIP276Template decodes a bitcoin-template BIP276 string into a template.
+func DecodeBIP276Template(text string) (*Template, *BIP276, error) {
+	b, err := DecodeBIP276(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b.Prefix != PrefixTemplate {
+		return nil, nil, fmt.Errorf("%w: %s", ErrBIP276NotTemplate, b.Prefix)
+	}
+
+	t, err := NewTemplateFromBytes(b.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return t, b, nil
+}
+
+// Bytes returns the template encoded as a sequence of push data parts:
+//
+//	<param count> [<type><name>...] [<0x00><script> | <0x01><param index>...]
+func (t *Template) Bytes() []byte {
+	parts := make([][]byte, 0, 1+len(t.Params)+len(t.segments))
+	parts = append(parts, []byte{byte(len(t.Params))})
+	for _, p := range t.Params {
+		parts = append(parts, append([]byte{byte(p.Type)}, p.Name...))
+	}
+	for _, s := range t.segments {
+		if s.script != nil {
+			parts = append(parts, append([]byte{templateTagScript}, s.script...))
+			continue
+		}
+		parts = append(parts, []byte{templateTagParam, byte(s.param)})
+	}
+
+	// parts are never too big to encode, as a script part can't
+	// exceed the 4GB script max.
+	b, _ := EncodeParts(parts)
+	return b
+}
+
+// BIP276 returns the template as bitcoin-template BIP276 data for the
+// network of the params provided.
+func (t *Template) BIP276(params *chaincfg.Params) BIP276 {
+	return NewBIP276(PrefixTemplate, params, t.Bytes())
+}
+
+// EncodeBIP276 returns the template encoded as a bitcoin-template
+// BIP276 string for the network of the params provided.
+func (t *Template) EncodeBIP276(params *chaincfg.Params) string {
+	return EncodeBIP276(t.BIP276(params))
+}
+
+// ASM returns the ASM source of the template, see ToASMWithMode,
+// with params rendered as $name placeholders.
+func (t *Template) ASM() string {
+	asm := make([]string, 0, len(t.segments))
+	for _, s := range t.segments {
+		if s.script == nil {
+			asm = append(asm, "$"+t.Params[s.param].Name)
+			continue
+		}
+		a, _ := s.script.ToASMWithMode(ASMModeAssembler)
+		asm = append(asm, a)
+	}
+
+	return strings.Join(asm, " ")
+}
+
+// Instantiate returns the script of the template with the
+// placeholders filled in with the args, keyed by param name.
+func (t *Template) Instantiate(args map[string]interface{}) (*Script, error) {
+	s := &Script{}
+	a := &assembler{args: &AssembleArgs{}, s: s}
+	for _, seg := range t.segments {
+		if seg.script != nil {
+			*s = append(*s, seg.script...)
+			continue
+		}
+
+		p := t.Params[seg.param]
+		v, ok := args[p.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateMissingArg, p.Name)
+		}
+		v, err := p.value(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.appendValue(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Match matches the script against the template, returning the args
+// extracted keyed by param name. ErrTemplateMismatch is returned if the
+// script isn't an instance of the template.
+func (t *Template) Match(s *Script) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(t.Params))
+	b := []byte(*s)
+	for _, seg := range t.segments {
+		if seg.script != nil {
+			if !bytes.HasPrefix(b, seg.script) {
+				return nil, ErrTemplateMismatch
+			}
+			b = b[len(seg.script):]
+			continue
+		}
+		if len(b) == 0 {
+			return nil, ErrTemplateMismatch
+		}
+
+		p := t.Params[seg.param]
+		op, data, n, err := readOp(b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateMismatch, err)
+		}
+		v, err := p.match(op, data)
+		if err != nil {
+			return nil, err
+		}
+
+		// The value must be encoded exactly as instantiating it would.
+		exp := &Script{}
+		if err := (&assembler{args: &AssembleArgs{}, s: exp}).appendValue(v); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(*exp, b[:n]) {
+			return nil, fmt.Errorf("%w: %s is not minimally encoded", ErrTemplateMismatch, p.Name)
+		}
+		b = b[n:]
+
+		if prev, ok := args[p.Name]; ok && !templateArgsEqual(prev, v) {
+			return nil, fmt.Errorf("%w: %s has different values", ErrTemplateMismatch, p.Name)
+		}
+		args[p.Name] = v
+	}
+	if len(b) > 0 {
+		return nil, ErrTemplateMismatch
+	}
+
+	return args, nil
+}
+
+func (t *Template) appendScript(s Script) {
+	if len(s) == 0 {
+		return
+	}
+	t.segments = append(t.segments, templateSegment{script: append(Script{}, s...)})
+}
+
+// value validates the arg v is of the param type, returning
+// the value to be appended to the script.
+func (p TemplateParam) value(v interface{}) (interface{}, error) {
+	invalid := fmt.Errorf("%w: %s (%T)", ErrTemplateInvalidArg, p.Name, v)
+	switch p.Type {
+	case TemplateParamBytes:
+		if _, ok := v.([]byte); ok {
+			return v, nil
+		}
+	case TemplateParamInt:
+		switch v.(type) {
+		case int, int32, int64, uint32, uint64, ScriptNumber, *big.Int:
+			return v, nil
+		}
+	case TemplateParamString:
+		if _, ok := v.(string); ok {
+			return v, nil
+		}
+	case TemplateParamPubKey:
+		switch v := v.(type) {
+		case *bec.PublicKey:
+			return v.SerialiseCompressed(), nil
+		case []byte:
+			if len(v) == 33 || len(v) == 65 {
+				return v, nil
+			}
+		}
+	case TemplateParamPubKeyHash:
+		if b, ok := v.([]byte); ok && len(b) == 20 {
+			return v, nil
+		}
+	}
+
+	return nil, invalid
+}
+
+// match returns the value of the param from the opcode and data read.
+func (p TemplateParam) match(op byte, data []byte) (interface{}, error) {
+	mismatch := fmt.Errorf("%w: %s", ErrTemplateMismatch, p.Name)
+	if p.Type == TemplateParamInt {
+		switch {
+		case op == Op1NEGATE:
+			return int64(-1), nil
+		case op >= Op1 && op <= Op16:
+			return int64(op - Op1 + 1), nil
+		case op > OpPUSHDATA4:
+			return nil, mismatch
+		}
+		n, err := DecodeBigScriptNumber(data, true)
+		if err != nil {
+			return nil, mismatch
+		}
+		if n.IsInt64() {
+			return n.Int64(), nil
+		}
+		return n, nil
+	}
+
+	if op > OpPUSHDATA4 {
+		return nil, mismatch
+	}
+	if data == nil {
+		data = []byte{}
+	}
+
+	switch p.Type {
+	case TemplateParamString:
+		return string(data), nil
+	case TemplateParamPubKey:
+		if len(data) != 33 && len(data) != 65 {
+			return nil, mismatch
+		}
+	case TemplateParamPubKeyHash:
+		if len(data) != 20 {
+			return nil, mismatch
+		}
+	}
+
+	return data, nil
+}
+
+func templateArgsEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case []byte:
+		b, ok := b.([]byte)
+		return ok && bytes.Equal(a, b)
+	case *big.Int:
+		b, ok := b.(*big.Int)
+		return ok && a.Cmp(b) == 0
+	}
+
+	return a == b
+}
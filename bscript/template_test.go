@@ -0,0 +1,280 @@
+package bscript_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/chaincfg"
+)
+
+func p2pkhTemplate(t *testing.T) *bscript.Template {
+	tmpl, err := bscript.NewTemplate(
+		"OP_DUP OP_HASH160 $pkh OP_EQUALVERIFY OP_CHECKSIG",
+		nil,
+		bscript.TemplateParam{Name: "pkh", Type: bscript.TemplateParamPubKeyHash},
+	)
+	require.NoError(t, err)
+
+	return tmpl
+}
+
+func TestNewTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		asm    string
+		params []bscript.TemplateParam
+		expASM string
+		expErr error
+	}{
+		"p2pkh": {
+			asm:    "OP_DUP OP_HASH160 $pkh OP_EQUALVERIFY OP_CHECKSIG",
+			params: []bscript.TemplateParam{{Name: "pkh", Type: bscript.TemplateParamPubKeyHash}},
+			expASM: "OP_DUP OP_HASH160 $pkh OP_EQUALVERIFY OP_CHECKSIG",
+		},
+		"param used twice": {
+			asm:    "$n $n OP_EQUAL",
+			params: []bscript.TemplateParam{{Name: "n", Type: bscript.TemplateParamInt}},
+			expASM: "$n $n OP_EQUAL",
+		},
+		"no params": {
+			asm:    "OP_1 \"hi\"",
+			expASM: `1 "hi"`,
+		},
+		"unknown param": {
+			asm:    "$a $b",
+			params: []bscript.TemplateParam{{Name: "a", Type: bscript.TemplateParamBytes}},
+			expErr: bscript.ErrTemplateUnknownParam,
+		},
+		"unused param": {
+			asm: "$a",
+			params: []bscript.TemplateParam{
+				{Name: "a", Type: bscript.TemplateParamBytes},
+				{Name: "b", Type: bscript.TemplateParamBytes},
+			},
+			expErr: bscript.ErrTemplateUnusedParam,
+		},
+		"duplicate param": {
+			asm: "$a",
+			params: []bscript.TemplateParam{
+				{Name: "a", Type: bscript.TemplateParamBytes},
+				{Name: "a", Type: bscript.TemplateParamInt},
+			},
+			expErr: bscript.ErrTemplateDuplicateParam,
+		},
+		"invalid param type": {
+			asm:    "$a",
+			params: []bscript.TemplateParam{{Name: "a", Type: 99}},
+			expErr: bscript.ErrTemplateInvalidParam,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := bscript.NewTemplate(test.asm, nil, test.params...)
+			if test.expErr != nil {
+				assert.True(t, errors.Is(err, test.expErr), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expASM, tmpl.ASM())
+		})
+	}
+}
+
+func TestTemplate_BIP276(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := bscript.NewTemplate(
+		"$pk OP_CHECKSIGVERIFY $n OP_DROP $memo OP_DROP $data",
+		nil,
+		bscript.TemplateParam{Name: "pk", Type: bscript.TemplateParamPubKey},
+		bscript.TemplateParam{Name: "n", Type: bscript.TemplateParamInt},
+		bscript.TemplateParam{Name: "memo", Type: bscript.TemplateParamString},
+		bscript.TemplateParam{Name: "data", Type: bscript.TemplateParamBytes},
+	)
+	require.NoError(t, err)
+
+	text := tmpl.EncodeBIP276(&chaincfg.TestNet)
+	assert.Regexp(t, "^bitcoin-template:0201", text)
+
+	decoded, b, err := bscript.DecodeBIP276Template(text)
+	require.NoError(t, err)
+	assert.Equal(t, chaincfg.TestNet.BIP276Network, b.Network)
+	assert.Equal(t, tmpl.Params, decoded.Params)
+	assert.Equal(t, tmpl.ASM(), decoded.ASM())
+	assert.Equal(t, tmpl.Bytes(), decoded.Bytes())
+
+	t.Run("not a template", func(t *testing.T) {
+		s := bscript.EncodeBIP276(bscript.NewBIP276(bscript.PrefixScript, &chaincfg.MainNet, []byte{bscript.OpTRUE}))
+		_, _, err := bscript.DecodeBIP276Template(s)
+		assert.True(t, errors.Is(err, bscript.ErrBIP276NotTemplate))
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		for _, h := range []string{"", "0101", "010102016e0102", "0100020200"} {
+			b, err := hex.DecodeString(h)
+			require.NoError(t, err)
+
+			_, err = bscript.NewTemplateFromBytes(b)
+			assert.True(t, errors.Is(err, bscript.ErrTemplateInvalidData), "%s: %v", h, err)
+		}
+	})
+}
+
+func TestTemplate_InstantiateMatch(t *testing.T) {
+	t.Parallel()
+
+	key, err := bec.NewPrivateKey(bec.S256())
+	require.NoError(t, err)
+	pk := key.PubKey().SerialiseCompressed()
+
+	tmpl, err := bscript.NewTemplate(
+		"$pk OP_CHECKSIGVERIFY $n OP_DROP $memo OP_DROP $data",
+		nil,
+		bscript.TemplateParam{Name: "pk", Type: bscript.TemplateParamPubKey},
+		bscript.TemplateParam{Name: "n", Type: bscript.TemplateParamInt},
+		bscript.TemplateParam{Name: "memo", Type: bscript.TemplateParamString},
+		bscript.TemplateParam{Name: "data", Type: bscript.TemplateParamBytes},
+	)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		args    map[string]interface{}
+		expArgs map[string]interface{}
+	}{
+		"small int": {
+			args: map[string]interface{}{"pk": key.PubKey(), "n": 5, "memo": "hello", "data": []byte{0xff}},
+			expArgs: map[string]interface{}{
+				"pk": pk, "n": int64(5), "memo": "hello", "data": []byte{0xff},
+			},
+		},
+		"pushed int and empty data": {
+			args: map[string]interface{}{"pk": pk, "n": int64(-1000), "memo": "", "data": []byte{}},
+			expArgs: map[string]interface{}{
+				"pk": pk, "n": int64(-1000), "memo": "", "data": []byte{},
+			},
+		},
+		"big int": {
+			args: map[string]interface{}{"pk": pk, "n": new(big.Int).Lsh(big.NewInt(1), 80), "memo": "m", "data": []byte{1, 2}},
+			expArgs: map[string]interface{}{
+				"pk": pk, "n": new(big.Int).Lsh(big.NewInt(1), 80), "memo": "m", "data": []byte{1, 2},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := tmpl.Instantiate(test.args)
+			require.NoError(t, err)
+
+			args, err := tmpl.Match(s)
+			require.NoError(t, err)
+			assert.Equal(t, test.expArgs, args)
+		})
+	}
+}
+
+func TestTemplate_Instantiate(t *testing.T) {
+	t.Parallel()
+
+	tmpl := p2pkhTemplate(t)
+	pkh := make([]byte, 20)
+
+	s, err := tmpl.Instantiate(map[string]interface{}{"pkh": pkh})
+	require.NoError(t, err)
+	assert.True(t, s.IsP2PKH())
+
+	_, err = tmpl.Instantiate(map[string]interface{}{})
+	assert.True(t, errors.Is(err, bscript.ErrTemplateMissingArg))
+
+	_, err = tmpl.Instantiate(map[string]interface{}{"pkh": make([]byte, 19)})
+	assert.True(t, errors.Is(err, bscript.ErrTemplateInvalidArg))
+
+	_, err = tmpl.Instantiate(map[string]interface{}{"pkh": "not bytes"})
+	assert.True(t, errors.Is(err, bscript.ErrTemplateInvalidArg))
+}
+
+func TestTemplate_Match(t *testing.T) {
+	t.Parallel()
+
+	intTmpl, err := bscript.NewTemplate("$n $n OP_EQUAL", nil,
+		bscript.TemplateParam{Name: "n", Type: bscript.TemplateParamInt})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		tmpl    *bscript.Template
+		hex     string
+		expArgs map[string]interface{}
+		expErr  error
+	}{
+		"p2pkh": {
+			tmpl:    p2pkhTemplate(t),
+			hex:     "76a914e2a623699e81b291c0327f408fea765d534baa2a88ac",
+			expArgs: map[string]interface{}{"pkh": mustDecodeHex(t, "e2a623699e81b291c0327f408fea765d534baa2a")},
+		},
+		"p2pkh wrong hash length": {
+			tmpl:   p2pkhTemplate(t),
+			hex:    "76a913e2a623699e81b291c0327f408fea765d534baa88ac",
+			expErr: bscript.ErrTemplateMismatch,
+		},
+		"p2pkh trailing data": {
+			tmpl:   p2pkhTemplate(t),
+			hex:    "76a914e2a623699e81b291c0327f408fea765d534baa2a88ac51",
+			expErr: bscript.ErrTemplateMismatch,
+		},
+		"p2pkh truncated": {
+			tmpl:   p2pkhTemplate(t),
+			hex:    "76a9",
+			expErr: bscript.ErrTemplateMismatch,
+		},
+		"repeated param": {
+			tmpl:    intTmpl,
+			hex:     "555587",
+			expArgs: map[string]interface{}{"n": int64(5)},
+		},
+		"repeated param differs": {
+			tmpl:   intTmpl,
+			hex:    "555687",
+			expErr: bscript.ErrTemplateMismatch,
+		},
+		"non minimal int": {
+			tmpl:   intTmpl,
+			hex:    "0105010587",
+			expErr: bscript.ErrTemplateMismatch,
+		},
+		"int is not an opcode": {
+			tmpl:   intTmpl,
+			hex:    "767687",
+			expErr: bscript.ErrTemplateMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := bscript.NewFromHexString(test.hex)
+			require.NoError(t, err)
+
+			args, err := test.tmpl.Match(s)
+			if test.expErr != nil {
+				assert.True(t, errors.Is(err, test.expErr), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expArgs, args)
+		})
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,161 @@
+// Package chaincfg defines the parameters of the bitcoin networks.
+//
+// The parameters are used to select the address and WIF prefixes,
+// the BIP276 network and the script rules active at a given height:
+//
+//	addr, err := bscript.NewAddressFromPublicKeyWithParams(pubKey, &chaincfg.TestNet)
+//
+//	err := interpreter.NewEngine().Execute(
+//	    interpreter.WithTx(tx, inputIdx, prevOutput),
+//	    interpreter.WithParams(&chaincfg.MainNet, utxoHeight),
+//	    interpreter.WithForkID(),
+//	)
+package chaincfg
+
+// Params defines a bitcoin network by its parameters.
+type Params struct {
+	// Name is the human-readable name of the network.
+	Name string
+
+	// Magic contains the magic bytes starting
+	// each p2p message, in wire order.
+	Magic [4]byte
+
+	// DefaultPort is the default p2p port of the network.
+	DefaultPort string
+
+	// PubKeyHashAddrID is the P2PKH address version byte.
+	PubKeyHashAddrID byte
+
+	// ScriptHashAddrID is the (deprecated) P2SH address version byte.
+	ScriptHashAddrID byte
+
+	// PrivateKeyID is the WIF private key version byte.
+	PrivateKeyID byte
+
+	// BIP276Network is the network id used in BIP276 encoded data.
+	BIP276Network int
+
+	// BIP16Height is the height from which P2SH (BIP16) is enforced.
+	BIP16Height uint32
+
+	// BIP65Height is the height from which
+	// OP_CHECKLOCKTIMEVERIFY (BIP65) is enforced.
+	BIP65Height uint32
+
+	// BIP66Height is the height from which strict
+	// DER signatures (BIP66) are enforced.
+	BIP66Height uint32
+
+	// CSVHeight is the height from which
+	// OP_CHECKSEQUENCEVERIFY (BIP112) is enforced.
+	CSVHeight uint32
+
+	// UAHFHeight is the height from which fork id
+	// signatures and strict encoding are enforced.
+	UAHFHeight uint32
+
+	// DAAHeight is the height from which low S
+	// and null fail signatures are enforced.
+	DAAHeight uint32
+
+	// GenesisActivationHeight is the height from which
+	// the genesis upgrade rules are active.
+	GenesisActivationHeight uint32
+
+	// ChronicleActivationHeight is the height from which
+	// the chronicle upgrade rules are active.
+	ChronicleActivationHeight uint32
+}
+
+// IsGenesisActive returns true if the genesis
+// upgrade is active at the given height.
+func (p *Params) IsGenesisActive(height uint32) bool {
+	return height >= p.GenesisActivationHeight
+}
+
+// IsChronicleActive returns true if the chronicle
+// upgrade is active at the given height.
+func (p *Params) IsChronicleActive(height uint32) bool {
+	return height >= p.ChronicleActivationHeight
+}
+
+// MainNet defines the parameters of the main network.
+var MainNet = Params{
+	Name:                      "mainnet",
+	Magic:                     [4]byte{0xe3, 0xe1, 0xf3, 0xe8},
+	DefaultPort:               "8333",
+	PubKeyHashAddrID:          0x00,
+	ScriptHashAddrID:          0x05,
+	PrivateKeyID:              0x80,
+	BIP276Network:             1,
+	BIP16Height:               173805,
+	BIP65Height:               388381,
+	BIP66Height:               363725,
+	CSVHeight:                 419328,
+	UAHFHeight:                478559,
+	DAAHeight:                 504032,
+	GenesisActivationHeight:   620538,
+	ChronicleActivationHeight: 943816,
+}
+
+// TestNet defines the parameters of the test network.
+var TestNet = Params{
+	Name:                      "testnet",
+	Magic:                     [4]byte{0xf4, 0xe5, 0xf3, 0xf4},
+	DefaultPort:               "18333",
+	PubKeyHashAddrID:          0x6f,
+	ScriptHashAddrID:          0xc4,
+	PrivateKeyID:              0xef,
+	BIP276Network:             2,
+	BIP16Height:               514,
+	BIP65Height:               581885,
+	BIP66Height:               330776,
+	CSVHeight:                 770112,
+	UAHFHeight:                1155876,
+	DAAHeight:                 1188698,
+	GenesisActivationHeight:   1344302,
+	ChronicleActivationHeight: 1713168,
+}
+
+// STN defines the parameters of the scaling test network.
+//
+// STN shares its address prefixes and BIP276 network with the test network.
+var STN = Params{
+	Name:                      "stn",
+	Magic:                     [4]byte{0xfb, 0xce, 0xc4, 0xf9},
+	DefaultPort:               "9333",
+	PubKeyHashAddrID:          0x6f,
+	ScriptHashAddrID:          0xc4,
+	PrivateKeyID:              0xef,
+	BIP276Network:             2,
+	BIP16Height:               0,
+	BIP65Height:               0,
+	BIP66Height:               0,
+	CSVHeight:                 0,
+	UAHFHeight:                16,
+	DAAHeight:                 2201,
+	GenesisActivationHeight:   100,
+	ChronicleActivationHeight: 3000,
+}
+
+// RegTest defines the parameters of the regression test network.
+//
+// RegTest shares its address prefixes and BIP276 network with the test network.
+var RegTest = Params{
+	Name:                      "regtest",
+	Magic:                     [4]byte{0xda, 0xb5, 0xbf, 0xfa},
+	DefaultPort:               "18444",
+	PubKeyHashAddrID:          0x6f,
+	ScriptHashAddrID:          0xc4,
+	PrivateKeyID:              0xef,
+	BIP276Network:             2,
+	BIP16Height:               0,
+	BIP65Height:               1351,
+	BIP66Height:               1251,
+	CSVHeight:                 576,
+	UAHFHeight:                0,
+	DAAHeight:                 0,
+	GenesisActivationHeight:   10000,
+	ChronicleActivationHeight: 15000,
+}
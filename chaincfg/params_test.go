@@ -0,0 +1,49 @@
+package chaincfg_test
+
+import (
+	"testing"
+
+	"github.com/libsv/go-bt/v2/chaincfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParams_IsActive(t *testing.T) {
+	tests := map[string]struct {
+		params       *chaincfg.Params
+		height       uint32
+		expGenesis   bool
+		expChronicle bool
+	}{
+		"mainnet before genesis": {
+			params: &chaincfg.MainNet,
+			height: 620537,
+		},
+		"mainnet at genesis": {
+			params:     &chaincfg.MainNet,
+			height:     620538,
+			expGenesis: true,
+		},
+		"mainnet at chronicle": {
+			params:       &chaincfg.MainNet,
+			height:       943816,
+			expGenesis:   true,
+			expChronicle: true,
+		},
+		"testnet after genesis": {
+			params:     &chaincfg.TestNet,
+			height:     1344303,
+			expGenesis: true,
+		},
+		"regtest before genesis": {
+			params: &chaincfg.RegTest,
+			height: 9999,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expGenesis, test.params.IsGenesisActive(test.height))
+			assert.Equal(t, test.expChronicle, test.params.IsChronicleActive(test.height))
+		})
+	}
+}
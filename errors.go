@@ -73,4 +73,19 @@ var (
 	ErrInvalidSellOffer      = errors.New("invalid sell offer (partially signed tx)")
 	ErrEmptyScripts          = errors.New("at least one of needed scripts is empty")
 	ErrInsufficientFees      = errors.New("fee paid not enough with new locking script")
+	ErrOrdinalSatOutOfRange  = errors.New("ordinal satoshi index out of range of utxo")
+	ErrOrdinalSatToFees      = errors.New("ordinal satoshi not transferred to any output")
+)
+
+// Sentinal errors reported by PSBT validation.
+var (
+	ErrInvalidInputCount      = errors.New("unexpected number of inputs")
+	ErrInvalidOutputCount     = errors.New("unexpected number of outputs")
+	ErrOrdinalInputMismatch   = errors.New("ordinal input doesn't match ordinal utxo")
+	ErrDummyOutputMismatch    = errors.New("dummy output doesn't pass through dummy inputs")
+	ErrOrdinalOutputMismatch  = errors.New("ordinal isn't transferred alone to the buyer output")
+	ErrPayoutsMismatch        = errors.New("payout outputs don't match expected payouts")
+	ErrInvalidPayout          = errors.New("payout amount is invalid")
+	ErrFeePaidNotEnough       = errors.New("fee paid not enough")
+	ErrInvalidUnlockingScript = errors.New("input unlocking script failed validation")
 )
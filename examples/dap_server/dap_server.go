@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter/debug/dap"
+)
+
+// Serves the Debug Adapter Protocol for script debugging, over stdio by
+// default, or over TCP if an address is provided, for example:
+//
+//	go run ./examples/dap_server -addr localhost:4711
+func main() {
+	addr := flag.String("addr", "", "TCP address to listen on, rather than stdio")
+	flag.Parse()
+
+	if *addr != "" {
+		log.Fatal(dap.ListenAndServe(*addr))
+	}
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/libsv/go-bt/v2/bscript/interpreter/trace"
+)
+
+// Replays a JSON lines execution trace against the engine, reporting the
+// first divergence from the recorded execution, for example:
+//
+//	go run ./examples/trace_replay trace.jsonl
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: trace_replay <trace.jsonl>")
+	}
+
+	f, err := os.Open(filepath.Clean(flag.Arg(0)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	tr, err := trace.ReadTrace(f)
+	_ = f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	d, err := trace.Replay(tr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if d != nil {
+		fmt.Println("diverged at", d)
+		os.Exit(1)
+	}
+
+	fmt.Printf("replayed %d steps without divergence\n", len(tr.Steps))
+}
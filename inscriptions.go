@@ -46,14 +46,8 @@ func (tx *Tx) Inscribe(ia *bscript.InscriptionArgs) error {
 
 	if ia.EnrichedArgs != nil {
 		if len(ia.EnrichedArgs.OpReturnData) > 0 {
-
-			// FIXME: import cycle
-			// // Sign with AIP
-			// _, outData, _, err := aip.SignOpReturnData(*signingKey, "BITCOIN_ECDSA", opReturn)
-			// if err != nil {
-			// 	return nil, err
-			// }
-
+			// OP_RETURN data can be built and signed with
+			// AIP beforehand using the bitcom package.
 			_ = s.AppendOpcodes(bscript.OpRETURN)
 			if err := s.AppendPushDataArray(ia.EnrichedArgs.OpReturnData); err != nil {
 				return err
@@ -22,8 +22,8 @@ import (
 func AcceptOrdinalSaleListing2Dummies(ctx context.Context, vla *ValidateListingArgs,
 	asoa *AcceptListingArgs) (*bt.Tx, error) {
 
-	if valid := vla.Validate(asoa.PSTx); !valid {
-		return nil, bt.ErrInvalidSellOffer
+	if err := vla.Validate(asoa.PSTx); err != nil {
+		return nil, &offerError{reason: err}
 	}
 	sellerOrdinalInput := asoa.PSTx.Inputs[0]
 	sellerOutput := asoa.PSTx.Outputs[0]
@@ -62,6 +62,10 @@ func AcceptOrdinalSaleListing2Dummies(ctx context.Context, vla *ValidateListingA
 
 	tx.AddOutput(sellerOutput)
 
+	if err := addPayoutOutputs(tx, vla.Payouts, sellerOutput.Satoshis); err != nil {
+		return nil, err
+	}
+
 	err = tx.Change(asoa.ChangeScript, asoa.FQ)
 	if err != nil {
 		return nil, err
@@ -105,6 +109,7 @@ type MakeBid2DArgs struct {
 	DummyOutputScript         *bscript.Script
 	ChangeScript              *bscript.Script
 	FQ                        *bt.FeeQuote
+	Payouts                   *Payouts
 }
 
 // MakeBidToBuy1SatOrdinal makes a bid offer to buy a 1 sat ordinal
@@ -114,7 +119,8 @@ type MakeBid2DArgs struct {
 // only one to go through and broadcast to the node network.
 //
 // Note: this function is meant for ordinals in 1 satoshi outputs instead
-// of ordinal ranges in 1 output (>1 satoshi outputs).
+// of ordinal ranges in 1 output (>1 satoshi outputs). For ordinal ranges
+// use MakeBidToBuyOrdinalRange.
 func MakeBidToBuy1SatOrdinal2Dummies(ctx context.Context, mba *MakeBid2DArgs) (*bt.Tx, error) {
 	if len(mba.BidderUTXOs) < 3 {
 		return nil, bt.ErrInsufficientUTXOs
@@ -132,15 +138,17 @@ func MakeBidToBuy1SatOrdinal2Dummies(ctx context.Context, mba *MakeBid2DArgs) (*
 	if err != nil {
 		return nil, err
 	}
+	ordScript, err := dummyOrdinalScript()
+	if err != nil {
+		return nil, err
+	}
+	sellerScript, err := dummySellerScript()
+	if err != nil {
+		return nil, err
+	}
 	emptyOrdInput := &bt.Input{
 		PreviousTxOutIndex: mba.OrdinalVOut,
-		PreviousTxScript: func() *bscript.Script {
-			//nolint:lll // add dummy ordinal PreviousTxScript
-			// so that the change function can estimate
-			// UnlockingScript sizes
-			s, _ := bscript.NewFromHexString("76a914c25e9a2b70ec83d7b4fbd0f36f00a86723a48e6b88ac0063036f72645118746578742f706c61696e3b636861727365743d7574662d38000d48656c6c6f2c20776f726c642168") // hello world (text/plain) test inscription
-			return s
-		}(),
+		PreviousTxScript:   ordScript,
 	}
 	err = emptyOrdInput.PreviousTxIDAdd(OrdinalTxIDBytes)
 	if err != nil {
@@ -167,13 +175,14 @@ func MakeBidToBuy1SatOrdinal2Dummies(ctx context.Context, mba *MakeBid2DArgs) (*
 	})
 
 	tx.AddOutput(&bt.Output{
-		Satoshis: mba.BidAmount,
-		LockingScript: func() *bscript.Script { // add dummy p2pkh script to calc fees accurately
-			s, _ := bscript.NewP2PKHFromAddress("1FunnyJoke111111111111111112AVXh5")
-			return s
-		}(),
+		Satoshis:      mba.BidAmount,
+		LockingScript: sellerScript,
 	})
 
+	if err := addPayoutOutputs(tx, mba.Payouts, mba.BidAmount); err != nil {
+		return nil, err
+	}
+
 	err = tx.Change(mba.ChangeScript, mba.FQ)
 	if err != nil {
 		return nil, err
@@ -216,56 +225,64 @@ type ValidateBid2DArgs struct {
 	PreviousUTXOs []*bt.UTXO // index 2 should be the listed ordinal input
 	BidAmount     uint64
 	ExpectedFQ    *bt.FeeQuote
+	Payouts       *Payouts
 }
 
-// Validate a bid to buy an ordinal
-// given specific validation parameters.
-func (vba *ValidateBid2DArgs) Validate(pstx *bt.Tx) bool {
+// Validate a bid to buy an ordinal given specific validation
+// parameters. The signatures of the bidder inputs are checked
+// by running the script interpreter against the previous UTXOs.
+//
+// An error describing why the bid is invalid is returned,
+// or nil if it's valid.
+func (vba *ValidateBid2DArgs) Validate(pstx *bt.Tx) error {
 	if pstx.InputCount() < 4 {
-		return false
+		return fmt.Errorf("%w: expected at least 4, got %d", bt.ErrInvalidInputCount, pstx.InputCount())
 	}
 	if pstx.OutputCount() < 4 {
-		return false
+		return fmt.Errorf("%w: expected at least 4, got %d", bt.ErrInvalidOutputCount, pstx.OutputCount())
 	}
 
 	// check previous utxos match inputs
 	if len(vba.PreviousUTXOs) != pstx.InputCount() {
-		return false
+		return fmt.Errorf("%w: expected %d utxos, got %d", bt.ErrUTXOInputMismatch,
+			pstx.InputCount(), len(vba.PreviousUTXOs))
 	}
 	for i := range vba.PreviousUTXOs {
 		if !bytes.Equal(pstx.Inputs[i].PreviousTxID(), vba.PreviousUTXOs[i].TxID) {
-			return false
+			return fmt.Errorf("%w: input %d", bt.ErrUTXOInputMismatch, i)
 		}
 		if uint64(pstx.Inputs[i].PreviousTxOutIndex) != uint64(vba.PreviousUTXOs[i].Vout) {
-			return false
+			return fmt.Errorf("%w: input %d", bt.ErrUTXOInputMismatch, i)
 		}
 	}
 
 	// check passthrough dummy inputs and output to avoid
 	// mismatching and losing the ordinal to another output
 	if (vba.PreviousUTXOs[0].Satoshis + vba.PreviousUTXOs[1].Satoshis) != pstx.Outputs[0].Satoshis {
-		return false
+		return bt.ErrDummyOutputMismatch
 	}
 
-	// check lou (ListedOrdinalUTXO) matches supplied pstx input index 2
-	pstxOrdinalInput := pstx.Inputs[2]
-	if !bytes.Equal(pstxOrdinalInput.PreviousTxID(), vba.PreviousUTXOs[2].TxID) {
-		return false
-	}
-	if uint64(pstxOrdinalInput.PreviousTxOutIndex) != uint64(vba.PreviousUTXOs[2].Vout) {
-		return false
+	// check the marketplace payouts for the bid amount
+	if err := checkPayoutOutputs(pstx, bidPayoutsIdx, vba.Payouts, vba.BidAmount); err != nil {
+		return err
 	}
 
-	// check enough fees paid
+	// check enough fees paid, setting the bid amount on a
+	// clone so that the pstx validated is left untouched
+	pstx = pstx.Clone()
 	pstx.Outputs[2].Satoshis = vba.BidAmount
 	enough, err := pstx.IsFeePaidEnough(vba.ExpectedFQ)
-	if err != nil || !enough {
-		return false
+	if err != nil {
+		return err
+	}
+	if !enough {
+		return bt.ErrFeePaidNotEnough
 	}
 
-	// TODO: check signatures valid
-
-	return true
+	// check signatures of all inputs but the
+	// listed ordinal input at index 2
+	utxos := append(append([]*bt.UTXO{}, vba.PreviousUTXOs[:2]...), vba.PreviousUTXOs[3:]...)
+	return verifyInputs(pstx, bidderInputIdxs(len(utxos), 2), utxos)
 }
 
 // AcceptBid2DArgs contains the arguments
@@ -284,8 +301,8 @@ type AcceptBid2DArgs struct {
 func AcceptBidToBuy1SatOrdinal2Dummies(ctx context.Context, vba *ValidateBid2DArgs,
 	aba *AcceptBid2DArgs) (*bt.Tx, error) {
 
-	if valid := vba.Validate(aba.PSTx); !valid {
-		return nil, bt.ErrInvalidSellOffer
+	if err := vba.Validate(aba.PSTx); err != nil {
+		return nil, &offerError{reason: err}
 	}
 
 	if !aba.SellerReceiveOrdinalScript.IsP2PKH() {
@@ -305,6 +322,7 @@ func AcceptBidToBuy1SatOrdinal2Dummies(ctx context.Context, vba *ValidateBid2DAr
 	if tx.Outputs[2] == nil {
 		return nil, errors.New("ordinal output expected in index 2 doesn't exist")
 	}
+	tx.Outputs[2].Satoshis = vba.BidAmount
 	tx.Outputs[2].LockingScript = aba.SellerReceiveOrdinalScript
 
 	if tx.Inputs[2] == nil {
@@ -105,7 +105,7 @@ func TestBidToBuyPSBT2DNoErrors(t *testing.T) {
 			// insert ordinal utxo at index 2
 			PreviousUTXOs: append(us[:2], append([]*bt.UTXO{ordUTXO}, us[2:]...)...),
 		}
-		assert.True(t, vba.Validate(pstx))
+		assert.NoError(t, vba.Validate(pstx))
 	})
 
 	t.Run("no errors when accepting bid", func(t *testing.T) {
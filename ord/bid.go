@@ -23,6 +23,7 @@ type MakeBidArgs struct {
 	DummyOutputScript         *bscript.Script
 	ChangeScript              *bscript.Script
 	FQ                        *bt.FeeQuote
+	Payouts                   *Payouts
 }
 
 // MakeBidToBuy1SatOrdinal makes a bid offer to buy a 1 sat ordinal
@@ -32,30 +33,25 @@ type MakeBidArgs struct {
 // only one to go through and broadcast to the node network.
 //
 // Note: this function is meant for ordinals in 1 satoshi outputs instead
-// of ordinal ranges in 1 output (>1 satoshi outputs).
+// of ordinal ranges in 1 output (>1 satoshi outputs). For ordinal ranges
+// use MakeBidToBuyOrdinalRange.
 func MakeBidToBuy1SatOrdinal(ctx context.Context, mba *MakeBidArgs) (*bt.Tx, error) {
 	if len(mba.BidderUTXOs) < 2 {
 		return nil, bt.ErrInsufficientUTXOs
 	}
 
 	// check at least 1 utxo is larger than the listed ordinal price
-	validUTXOFound := false
-	for i, u := range mba.BidderUTXOs {
-		if u.Satoshis > mba.BidAmount {
-			// Move the UTXO at index i to the beginning
-			mba.BidderUTXOs = append([]*bt.UTXO{u}, append(mba.BidderUTXOs[:i], mba.BidderUTXOs[i+1:]...)...)
-			validUTXOFound = true
-			break
-		}
-	}
-	if !validUTXOFound {
-		return nil, bt.ErrInsufficientUTXOValue
+	// and move it to the beginning
+	utxos, err := moveFirstUTXOAbove(mba.BidderUTXOs, mba.BidAmount, 0)
+	if err != nil {
+		return nil, err
 	}
+	mba.BidderUTXOs = utxos
 
 	tx := bt.NewTx()
 
 	// add dummy inputs
-	err := tx.FromUTXOs(mba.BidderUTXOs[0])
+	err = tx.FromUTXOs(mba.BidderUTXOs[0])
 	if err != nil {
 		return nil, fmt.Errorf(`failed to add inputs: %w`, err)
 	}
@@ -64,15 +60,17 @@ func MakeBidToBuy1SatOrdinal(ctx context.Context, mba *MakeBidArgs) (*bt.Tx, err
 	if err != nil {
 		return nil, err
 	}
+	ordScript, err := dummyOrdinalScript()
+	if err != nil {
+		return nil, err
+	}
+	sellerScript, err := dummySellerScript()
+	if err != nil {
+		return nil, err
+	}
 	emptyOrdInput := &bt.Input{
 		PreviousTxOutIndex: mba.OrdinalVOut,
-		PreviousTxScript: func() *bscript.Script {
-			//nolint:lll // add dummy ordinal PreviousTxScript
-			// so that the change function can estimate
-			// UnlockingScript sizes
-			s, _ := bscript.NewFromHexString("76a914c25e9a2b70ec83d7b4fbd0f36f00a86723a48e6b88ac0063036f72645118746578742f706c61696e3b636861727365743d7574662d38000d48656c6c6f2c20776f726c642168") // hello world (text/plain) test inscription
-			return s
-		}(),
+		PreviousTxScript:   ordScript,
 	}
 	err = emptyOrdInput.PreviousTxIDAdd(OrdinalTxIDBytes)
 	if err != nil {
@@ -93,11 +91,8 @@ func MakeBidToBuy1SatOrdinal(ctx context.Context, mba *MakeBidArgs) (*bt.Tx, err
 	})
 
 	tx.AddOutput(&bt.Output{
-		Satoshis: mba.BidAmount,
-		LockingScript: func() *bscript.Script { // add dummy p2pkh script to calc fees accurately
-			s, _ := bscript.NewP2PKHFromAddress("1FunnyJoke111111111111111112AVXh5")
-			return s
-		}(),
+		Satoshis:      mba.BidAmount,
+		LockingScript: sellerScript,
 	})
 
 	// add ordinal receive output
@@ -106,6 +101,10 @@ func MakeBidToBuy1SatOrdinal(ctx context.Context, mba *MakeBidArgs) (*bt.Tx, err
 		Satoshis:      1,
 	})
 
+	if err := addPayoutOutputs(tx, mba.Payouts, mba.BidAmount); err != nil {
+		return nil, err
+	}
+
 	err = tx.Change(mba.ChangeScript, mba.FQ)
 	if err != nil {
 		return nil, err
@@ -145,41 +144,64 @@ func MakeBidToBuy1SatOrdinal(ctx context.Context, mba *MakeBidArgs) (*bt.Tx, err
 // as they appear in the tx.
 type ValidateBidArgs struct {
 	OrdinalUTXO *bt.UTXO
+	BidderUTXOs []*bt.UTXO // in input order, excluding the ordinal input
 	BidAmount   uint64
 	ExpectedFQ  *bt.FeeQuote
+	Payouts     *Payouts
 }
 
-// Validate a bid to buy an ordinal
-// given specific validation parameters.
-func (vba *ValidateBidArgs) Validate(pstx *bt.Tx) bool {
+// Validate a bid to buy an ordinal given specific validation
+// parameters. The signatures of the bidder inputs are checked
+// by running the script interpreter against the bidder UTXOs.
+//
+// An error describing why the bid is invalid is returned,
+// or nil if it's valid.
+func (vba *ValidateBidArgs) Validate(pstx *bt.Tx) error {
 	if pstx.InputCount() < 3 {
-		return false
+		return fmt.Errorf("%w: expected at least 3, got %d", bt.ErrInvalidInputCount, pstx.InputCount())
 	}
 	if pstx.OutputCount() < 3 { // technically should have 4 including change
-		return false
+		return fmt.Errorf("%w: expected at least 3, got %d", bt.ErrInvalidOutputCount, pstx.OutputCount())
 	}
 
 	// check OrdinalUTXO matches supplied pstx input index 1
 	pstxOrdinalInput := pstx.Inputs[1]
+	if vba.OrdinalUTXO == nil {
+		return bt.ErrOrdinalInputMismatch
+	}
 	if !bytes.Equal(pstxOrdinalInput.PreviousTxID(), vba.OrdinalUTXO.TxID) {
-		return false
+		return bt.ErrOrdinalInputMismatch
 	}
 	if uint64(pstxOrdinalInput.PreviousTxOutIndex) != uint64(vba.OrdinalUTXO.Vout) {
-		return false
+		return bt.ErrOrdinalInputMismatch
+	}
+
+	// every input other than the ordinal must be the bidder's
+	if len(vba.BidderUTXOs) != pstx.InputCount()-1 {
+		return fmt.Errorf("%w: expected %d utxos, got %d", bt.ErrUTXOInputMismatch,
+			pstx.InputCount()-1, len(vba.BidderUTXOs))
 	}
 
-	// set the value of the output for the bid amount
+	// set the value of the output for the bid amount on a
+	// clone, so that the pstx validated is left untouched
+	pstx = pstx.Clone()
 	pstx.Outputs[1].Satoshis = vba.BidAmount
 
+	// check the marketplace payouts for the bid amount
+	if err := checkPayoutOutputs(pstx, bidPayoutsIdx, vba.Payouts, vba.BidAmount); err != nil {
+		return err
+	}
+
 	// check enough fees paid
 	enough, err := pstx.IsFeePaidEnough(vba.ExpectedFQ)
-	if err != nil || !enough {
-		return false
+	if err != nil {
+		return err
+	}
+	if !enough {
+		return bt.ErrFeePaidNotEnough
 	}
 
-	// TODO: check signatures valid
-
-	return true
+	return verifyInputs(pstx, bidderInputIdxs(len(vba.BidderUTXOs), 1), vba.BidderUTXOs)
 }
 
 // AcceptBidArgs contains the arguments
@@ -195,12 +217,13 @@ type AcceptBidArgs struct {
 // transaction bid to buy an ordinal.
 //
 func AcceptBidToBuy1SatOrdinal(ctx context.Context, vba *ValidateBidArgs, aba *AcceptBidArgs) (*bt.Tx, error) {
-	if valid := vba.Validate(aba.PSTx); !valid {
-		return nil, bt.ErrInvalidSellOffer
+	if err := vba.Validate(aba.PSTx); err != nil {
+		return nil, &offerError{reason: err}
 	}
 
 	tx := aba.PSTx.Clone()
 
+	tx.Outputs[1].Satoshis = vba.BidAmount
 	tx.Outputs[1].LockingScript = aba.SellerReceiveScript
 	// check if fees paid are still enough with new
 	// locking script
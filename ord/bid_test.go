@@ -88,8 +88,34 @@ func TestBidToBuyPSBTNoErrors(t *testing.T) {
 			BidAmount:   uint64(bidAmount),
 			ExpectedFQ:  bt.NewFeeQuote(),
 			OrdinalUTXO: ordUTXO,
+			BidderUTXOs: us,
+		}
+		assert.NoError(t, vba.Validate(pstx))
+	})
+
+	t.Run("validating leaves the bid untouched", func(t *testing.T) {
+		unset := pstx.Clone()
+		unset.Outputs[1].Satoshis = 0
+		vba := &ord.ValidateBidArgs{
+			BidAmount:   uint64(bidAmount),
+			ExpectedFQ:  bt.NewFeeQuote(),
+			OrdinalUTXO: ordUTXO,
+			BidderUTXOs: us,
+		}
+		assert.NoError(t, vba.Validate(unset))
+		assert.Zero(t, unset.Outputs[1].Satoshis)
+	})
+
+	t.Run("bid missing bidder utxos is invalid", func(t *testing.T) {
+		for _, uu := range [][]*bt.UTXO{nil, us[:1]} {
+			vba := &ord.ValidateBidArgs{
+				BidAmount:   uint64(bidAmount),
+				ExpectedFQ:  bt.NewFeeQuote(),
+				OrdinalUTXO: ordUTXO,
+				BidderUTXOs: uu,
+			}
+			assert.ErrorIs(t, vba.Validate(pstx), bt.ErrUTXOInputMismatch)
 		}
-		assert.True(t, vba.Validate(pstx))
 	})
 
 	fmt.Println(pstx.String())
@@ -105,6 +131,7 @@ func TestBidToBuyPSBTNoErrors(t *testing.T) {
 			BidAmount:   uint64(bidAmount),
 			ExpectedFQ:  bt.NewFeeQuote(),
 			OrdinalUTXO: ordUTXO,
+			BidderUTXOs: us,
 		},
 			&ord.AcceptBidArgs{
 				PSTx: pstx,
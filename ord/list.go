@@ -17,11 +17,17 @@ type ListOrdinalArgs struct {
 	SellerReceiveOutput *bt.Output
 	OrdinalUTXO         *bt.UTXO
 	OrdinalUnlocker     bt.Unlocker
+	Payouts             *Payouts
 }
 
 // ListOrdinalForSale creates a PBST (Partially Signed Bitcoin
 // Transaction) that offers a specific ordinal UTXO for sale at a
 // specific price.
+//
+// Any marketplace payouts are added as extra outputs after the seller
+// output. These are not signed by the seller, instead they are checked
+// by ValidateListingArgs and carried over to the tx accepting the listing,
+// though nothing stops the buyer from dropping them (see Payouts).
 func ListOrdinalForSale(ctx context.Context, msoa *ListOrdinalArgs) (*bt.Tx, error) {
 	tx := bt.NewTx()
 
@@ -31,6 +37,9 @@ func ListOrdinalForSale(ctx context.Context, msoa *ListOrdinalArgs) (*bt.Tx, err
 	}
 
 	tx.AddOutput(msoa.SellerReceiveOutput)
+	if err := addPayoutOutputs(tx, msoa.Payouts, msoa.SellerReceiveOutput.Satoshis); err != nil {
+		return nil, err
+	}
 
 	err = tx.FillInput(ctx, msoa.OrdinalUnlocker, bt.UnlockerParams{
 		InputIdx:     0,
@@ -47,36 +56,52 @@ func ListOrdinalForSale(ctx context.Context, msoa *ListOrdinalArgs) (*bt.Tx, err
 // validate a specific listing to sell an ordinal.
 type ValidateListingArgs struct {
 	ListedOrdinalUTXO *bt.UTXO
+	Payouts           *Payouts
 }
 
-// Validate an ordinal sale offer listing
-// given specific validation parameters.
-func (vla *ValidateListingArgs) Validate(pstx *bt.Tx) bool {
+// Validate an ordinal sale offer listing given specific
+// validation parameters. The signature of the listed
+// ordinal input is checked by running the script
+// interpreter against the listed ordinal UTXO.
+//
+// An error describing why the listing is invalid is
+// returned, or nil if it's valid.
+func (vla *ValidateListingArgs) Validate(pstx *bt.Tx) error {
 	if pstx.InputCount() != 1 {
-		return false
+		return fmt.Errorf("%w: expected 1, got %d", bt.ErrInvalidInputCount, pstx.InputCount())
+	}
+	if pstx.OutputCount() < 1 {
+		return fmt.Errorf("%w: expected at least 1, got 0", bt.ErrInvalidOutputCount)
+	}
+	// seller output followed by the marketplace payouts
+	payouts, err := vla.Payouts.Outputs(pstx.Outputs[0].Satoshis)
+	if err != nil {
+		return err
 	}
-	if pstx.OutputCount() != 1 {
-		return false
+	if n := 1 + len(payouts); pstx.OutputCount() != n {
+		return fmt.Errorf("%w: expected %d, got %d", bt.ErrInvalidOutputCount, n, pstx.OutputCount())
 	}
 
 	// check lou (ListedOrdinalUTXO) matches supplied pstx input index 0
 	pstxOrdinalInput := pstx.Inputs[0]
 	if vla.ListedOrdinalUTXO == nil {
-		return false
+		return bt.ErrOrdinalInputMismatch
 	}
 	if !bytes.Equal(pstxOrdinalInput.PreviousTxID(), vla.ListedOrdinalUTXO.TxID) {
-		return false
+		return bt.ErrOrdinalInputMismatch
 	}
 	if uint64(pstxOrdinalInput.PreviousTxOutIndex) != uint64(vla.ListedOrdinalUTXO.Vout) {
-		return false
+		return bt.ErrOrdinalInputMismatch
 	}
 
-	// no need to check output value equals the listed value
-	// since it's already signed by the input unlocking script
-
-	// TODO: check signature valid
+	// check the marketplace payouts for the listed price
+	if err := checkPayoutOutputs(pstx, 1, vla.Payouts, pstx.Outputs[0].Satoshis); err != nil {
+		return err
+	}
 
-	return true
+	// no need to check output value equals the listed value
+	// since it's signed by the input unlocking script
+	return verifyInputs(pstx, []int{0}, []*bt.UTXO{vla.ListedOrdinalUTXO})
 }
 
 // AcceptListingArgs contains the arguments
@@ -96,8 +121,8 @@ type AcceptListingArgs struct {
 // you will need to provide at least 2 UTXOs - with at least 1 being
 // larger than the listed ordinal price.
 func AcceptOrdinalSaleListing(ctx context.Context, vla *ValidateListingArgs, asoa *AcceptListingArgs) (*bt.Tx, error) {
-	if valid := vla.Validate(asoa.PSTx); !valid {
-		return nil, bt.ErrInvalidSellOffer
+	if err := vla.Validate(asoa.PSTx); err != nil {
+		return nil, &offerError{reason: err}
 	}
 	sellerOrdinalInput := asoa.PSTx.Inputs[0]
 	sellerOutput := asoa.PSTx.Outputs[0]
@@ -113,23 +138,17 @@ func AcceptOrdinalSaleListing(ctx context.Context, vla *ValidateListingArgs, aso
 	}
 
 	// check at least 1 utxo is larger than the listed ordinal price
-	validUTXOFound := false
-	for i, u := range asoa.UTXOs {
-		if u.Satoshis > sellerOutput.Satoshis {
-			// Move the UTXO at index i to the beginning
-			asoa.UTXOs = append([]*bt.UTXO{u}, append(asoa.UTXOs[:i], asoa.UTXOs[i+1:]...)...)
-			validUTXOFound = true
-			break
-		}
-	}
-	if !validUTXOFound {
-		return nil, bt.ErrInsufficientUTXOValue
+	// and move it to the beginning
+	utxos, err := moveFirstUTXOAbove(asoa.UTXOs, sellerOutput.Satoshis, 0)
+	if err != nil {
+		return nil, err
 	}
+	asoa.UTXOs = utxos
 
 	tx := bt.NewTx()
 
 	// add first input to pay for ordinal
-	err := tx.FromUTXOs(asoa.UTXOs[0])
+	err = tx.FromUTXOs(asoa.UTXOs[0])
 	if err != nil {
 		return nil, fmt.Errorf(`failed to add input: %w`, err)
 	}
@@ -156,6 +175,10 @@ func AcceptOrdinalSaleListing(ctx context.Context, vla *ValidateListingArgs, aso
 		Satoshis:      1,
 	})
 
+	if err := addPayoutOutputs(tx, vla.Payouts, sellerOutput.Satoshis); err != nil {
+		return nil, err
+	}
+
 	err = tx.Change(asoa.ChangeScript, asoa.FQ)
 	if err != nil {
 		return nil, err
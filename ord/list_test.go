@@ -55,7 +55,20 @@ func TestOfferToSellPSBTNoErrors(t *testing.T) {
 		vla := &ord.ValidateListingArgs{
 			ListedOrdinalUTXO: ordUTXO,
 		}
-		assert.True(t, vla.Validate(pstx))
+		assert.NoError(t, vla.Validate(pstx))
+	})
+
+	t.Run("tampered PSBT to make an offer to sell ordinal is invalid", func(t *testing.T) {
+		vla := &ord.ValidateListingArgs{
+			ListedOrdinalUTXO: ordUTXO,
+		}
+		tampered := pstx.Clone()
+		tampered.Outputs[0].Satoshis = 1
+		assert.ErrorIs(t, vla.Validate(tampered), bt.ErrInvalidUnlockingScript)
+
+		_, err := ord.AcceptOrdinalSaleListing(context.Background(), vla, &ord.AcceptListingArgs{PSTx: tampered})
+		assert.ErrorIs(t, err, bt.ErrInvalidSellOffer)
+		assert.ErrorIs(t, err, bt.ErrInvalidUnlockingScript)
 	})
 
 	us := []*bt.UTXO{
@@ -0,0 +1,142 @@
+package ord
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// BasisPointsDenominator is the amount of basis points making up
+// the whole price (100 basis points = 1%).
+const BasisPointsDenominator = 10000
+
+// bidPayoutsIdx is the index of the first payout output of a bid,
+// after the dummy, bid amount and buyer ordinal outputs.
+const bidPayoutsIdx = 3
+
+// Payout contains the details of an extra output paid
+// when an ordinal is traded, for example to a marketplace.
+//
+// The amount paid is a fixed amount of satoshis plus
+// a share of the price in basis points (100 = 1%).
+type Payout struct {
+	LockingScript *bscript.Script
+	Satoshis      uint64
+	BasisPoints   uint64
+}
+
+// Amount returns the amount of satoshis paid
+// out for an ordinal traded at the given price.
+//
+// An error is returned if BasisPoints is more than the whole
+// price or if the amount doesn't fit in a uint64.
+func (p *Payout) Amount(price uint64) (uint64, error) {
+	if p.BasisPoints > BasisPointsDenominator {
+		return 0, fmt.Errorf("%w: %d basis points is more than %d", bt.ErrInvalidPayout,
+			p.BasisPoints, BasisPointsDenominator)
+	}
+
+	// split the price around the denominator so that
+	// multiplying by the basis points can't overflow
+	share := price/BasisPointsDenominator*p.BasisPoints +
+		price%BasisPointsDenominator*p.BasisPoints/BasisPointsDenominator
+	if p.Satoshis > math.MaxUint64-share {
+		return 0, fmt.Errorf("%w: amount overflows", bt.ErrInvalidPayout)
+	}
+
+	return p.Satoshis + share, nil
+}
+
+// Payouts contains the extra outputs expected by the
+// protocol of a marketplace when trading an ordinal.
+//
+// The outputs are paid by the buyer on top of the price
+// and appear in the tx in the following order: marketplace
+// fee, creator royalties and referral payouts.
+//
+// Payouts are advisory. The signatures of a listing or bid
+// only commit to the outputs at the index of the signed inputs
+// (SIGHASH_SINGLE), so the payout outputs aren't covered and
+// whoever completes the tx can drop or change them. Validate
+// only checks that they're present in the partially signed tx,
+// a marketplace relaying the completed tx has to check them
+// again before broadcasting it.
+type Payouts struct {
+	MarketplaceFee *Payout
+	Royalties      []*Payout
+	Referrals      []*Payout
+}
+
+// Outputs returns the payout outputs for an ordinal traded
+// at the given price. Payouts with an amount of 0 satoshis
+// are skipped.
+func (p *Payouts) Outputs(price uint64) ([]*bt.Output, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	pp := make([]*Payout, 0, 1+len(p.Royalties)+len(p.Referrals))
+	if p.MarketplaceFee != nil {
+		pp = append(pp, p.MarketplaceFee)
+	}
+	pp = append(pp, p.Royalties...)
+	pp = append(pp, p.Referrals...)
+
+	outputs := make([]*bt.Output, 0, len(pp))
+	for _, payout := range pp {
+		amount, err := payout.Amount(price)
+		if err != nil {
+			return nil, err
+		}
+		if amount == 0 {
+			continue
+		}
+		outputs = append(outputs, &bt.Output{
+			LockingScript: payout.LockingScript,
+			Satoshis:      amount,
+		})
+	}
+
+	return outputs, nil
+}
+
+// addPayoutOutputs adds the payout outputs for an
+// ordinal traded at the given price to the tx.
+func addPayoutOutputs(tx *bt.Tx, p *Payouts, price uint64) error {
+	outputs, err := p.Outputs(price)
+	if err != nil {
+		return err
+	}
+	for _, o := range outputs {
+		tx.AddOutput(o)
+	}
+
+	return nil
+}
+
+// checkPayoutOutputs checks that the tx outputs starting from index
+// startIdx match the payout outputs for the given price.
+func checkPayoutOutputs(tx *bt.Tx, startIdx int, p *Payouts, price uint64) error {
+	expected, err := p.Outputs(price)
+	if err != nil {
+		return err
+	}
+	if tx.OutputCount() < startIdx+len(expected) {
+		return bt.ErrPayoutsMismatch
+	}
+
+	for i, o := range expected {
+		txo := tx.Outputs[startIdx+i]
+		if txo.Satoshis != o.Satoshis {
+			return bt.ErrPayoutsMismatch
+		}
+		if txo.LockingScript == nil || o.LockingScript == nil ||
+			!txo.LockingScript.Equals(o.LockingScript) {
+			return bt.ErrPayoutsMismatch
+		}
+	}
+
+	return nil
+}
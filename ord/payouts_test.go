@@ -0,0 +1,293 @@
+package ord_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/libsv/go-bk/wif"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/ord"
+	"github.com/libsv/go-bt/v2/unlocker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayoutsOutputs(t *testing.T) {
+	marketS, _ := bscript.NewP2PKHFromAddress("1C3V9TTJefP8Hft96sVf54mQyDJh8Ze4w4")
+	creatorS, _ := bscript.NewP2PKHFromAddress("1HebepswCi6huw1KJ7LvkrgemAV63TyVUs")
+	referrerS, _ := bscript.NewP2PKHFromAddress("19NfKd8aTwvb5ngfP29RxgfQzZt8KAYtQo")
+
+	tests := map[string]struct {
+		payouts    *ord.Payouts
+		price      uint64
+		expOutputs []*bt.Output
+		expErr     error
+	}{
+		"nil payouts": {
+			payouts:    nil,
+			price:      1000,
+			expOutputs: nil,
+		},
+		"fixed marketplace fee": {
+			payouts: &ord.Payouts{
+				MarketplaceFee: &ord.Payout{LockingScript: marketS, Satoshis: 50},
+			},
+			price: 1000,
+			expOutputs: []*bt.Output{
+				{LockingScript: marketS, Satoshis: 50},
+			},
+		},
+		"marketplace fee, royalty and referral in order": {
+			payouts: &ord.Payouts{
+				MarketplaceFee: &ord.Payout{LockingScript: marketS, Satoshis: 10, BasisPoints: 100},
+				Royalties:      []*ord.Payout{{LockingScript: creatorS, BasisPoints: 500}},
+				Referrals:      []*ord.Payout{{LockingScript: referrerS, BasisPoints: 25}},
+			},
+			price: 10000,
+			expOutputs: []*bt.Output{
+				{LockingScript: marketS, Satoshis: 110},
+				{LockingScript: creatorS, Satoshis: 500},
+				{LockingScript: referrerS, Satoshis: 25},
+			},
+		},
+		"zero amount payouts are skipped": {
+			payouts: &ord.Payouts{
+				Royalties: []*ord.Payout{{LockingScript: creatorS, BasisPoints: 50}},
+				Referrals: []*ord.Payout{{LockingScript: referrerS, BasisPoints: 250}},
+			},
+			price: 100,
+			expOutputs: []*bt.Output{
+				{LockingScript: referrerS, Satoshis: 2},
+			},
+		},
+		"large price doesn't overflow": {
+			payouts: &ord.Payouts{
+				MarketplaceFee: &ord.Payout{LockingScript: marketS, BasisPoints: 5000},
+			},
+			price: math.MaxUint64,
+			expOutputs: []*bt.Output{
+				{LockingScript: marketS, Satoshis: math.MaxUint64 / 2},
+			},
+		},
+		"basis points above the whole price are rejected": {
+			payouts: &ord.Payouts{
+				Royalties: []*ord.Payout{{LockingScript: creatorS, BasisPoints: 10001}},
+			},
+			price:  1000,
+			expErr: bt.ErrInvalidPayout,
+		},
+		"amount overflowing is rejected": {
+			payouts: &ord.Payouts{
+				MarketplaceFee: &ord.Payout{LockingScript: marketS, Satoshis: math.MaxUint64, BasisPoints: 1},
+			},
+			price:  10000,
+			expErr: bt.ErrInvalidPayout,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			outputs, err := test.payouts.Outputs(test.price)
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expOutputs, outputs)
+		})
+	}
+}
+
+func TestListingWithPayouts(t *testing.T) {
+	ordWif, _ := wif.DecodeWIF("L42PyNwEKE4XRaa8PzPh7JZurSAWJmx49nbVfaXYuiQg3RCubwn7") // 1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ
+	ordPrefixAddr, _ := bscript.NewAddressFromPublicKeyString(hex.EncodeToString(ordWif.SerialisePubKey()), true)
+	ordPrefixScript, _ := bscript.NewP2PKHFromAddress(ordPrefixAddr.AddressString)
+
+	ordUnlockerGetter := unlocker.Getter{PrivateKey: ordWif.PrivKey}
+	ordUnlocker, _ := ordUnlockerGetter.Unlocker(context.Background(), ordPrefixScript)
+
+	marketS, _ := bscript.NewP2PKHFromAddress("1C3V9TTJefP8Hft96sVf54mQyDJh8Ze4w4")
+	creatorS, _ := bscript.NewP2PKHFromAddress("1HebepswCi6huw1KJ7LvkrgemAV63TyVUs")
+	payouts := &ord.Payouts{
+		MarketplaceFee: &ord.Payout{LockingScript: marketS, BasisPoints: 200},
+		Royalties:      []*ord.Payout{{LockingScript: creatorS, BasisPoints: 500}},
+	}
+
+	ordUTXO := &bt.UTXO{
+		TxID: func() []byte {
+			t, _ := hex.DecodeString("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a")
+			return t
+		}(),
+		Vout:          uint32(0),
+		LockingScript: ordPrefixScript,
+		Satoshis:      1,
+	}
+
+	pstx, err := ord.ListOrdinalForSale(context.Background(), &ord.ListOrdinalArgs{
+		SellerReceiveOutput: &bt.Output{
+			Satoshis: 1000,
+			LockingScript: func() *bscript.Script {
+				s, _ := bscript.NewP2PKHFromAddress("1C3V9TTJefP8Hft96sVf54mQyDJh8Ze4w4") // L1JWiLZtCkkqin41XtQ2Jxo1XGxj1R4ydT2zmxPiaeQfuyUK631D
+				return s
+			}(),
+		},
+		OrdinalUTXO:     ordUTXO,
+		OrdinalUnlocker: ordUnlocker,
+		Payouts:         payouts,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, pstx.OutputCount())
+
+	t.Run("listing with matching payouts is valid", func(t *testing.T) {
+		vla := &ord.ValidateListingArgs{ListedOrdinalUTXO: ordUTXO, Payouts: payouts}
+		assert.NoError(t, vla.Validate(pstx))
+	})
+
+	t.Run("listing without payouts is invalid", func(t *testing.T) {
+		vla := &ord.ValidateListingArgs{ListedOrdinalUTXO: ordUTXO}
+		assert.ErrorIs(t, vla.Validate(pstx), bt.ErrInvalidOutputCount)
+	})
+
+	t.Run("listing with lower royalty is invalid", func(t *testing.T) {
+		vla := &ord.ValidateListingArgs{ListedOrdinalUTXO: ordUTXO, Payouts: &ord.Payouts{
+			MarketplaceFee: &ord.Payout{LockingScript: marketS, BasisPoints: 200},
+			Royalties:      []*ord.Payout{{LockingScript: creatorS, BasisPoints: 600}},
+		}}
+		assert.ErrorIs(t, vla.Validate(pstx), bt.ErrPayoutsMismatch)
+	})
+
+	t.Run("accepted listing pays out", func(t *testing.T) {
+		us := []*bt.UTXO{
+			{
+				TxID: func() []byte {
+					t, _ := hex.DecodeString("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a")
+					return t
+				}(),
+				Vout:          uint32(1),
+				LockingScript: ordPrefixScript,
+				Satoshis:      1953,
+				Unlocker:      &ordUnlocker,
+			},
+			{
+				TxID: func() []byte {
+					t, _ := hex.DecodeString("fcc55cd1a4275e5750070381028d3e3edf99b238bdc56199ff8bdc17dfb599d1")
+					return t
+				}(),
+				Vout:          uint32(3),
+				LockingScript: ordPrefixScript,
+				Satoshis:      27601,
+				Unlocker:      &ordUnlocker,
+			},
+		}
+		s, _ := bscript.NewP2PKHFromAddress("19NfKd8aTwvb5ngfP29RxgfQzZt8KAYtQo") // L5W2nyKUCsDStVUBwZj2Q3Ph5vcae4bgdzprZDYqDpvZA8AFguFH
+
+		tx, err := ord.AcceptOrdinalSaleListing(context.Background(), &ord.ValidateListingArgs{
+			ListedOrdinalUTXO: ordUTXO,
+			Payouts:           payouts,
+		},
+			&ord.AcceptListingArgs{
+				PSTx:                      pstx,
+				UTXOs:                     us,
+				BuyerReceiveOrdinalScript: s,
+				DummyOutputScript:         s,
+				ChangeScript:              s,
+				FQ:                        bt.NewFeeQuote(),
+			})
+		require.NoError(t, err)
+		assert.Equal(t, 6, tx.OutputCount())
+		expOutputs, err := payouts.Outputs(1000)
+		require.NoError(t, err)
+		assert.Equal(t, expOutputs, tx.Outputs[3:5])
+	})
+}
+
+func TestBidWithPayouts(t *testing.T) {
+	fundingWif, _ := wif.DecodeWIF("L42PyNwEKE4XRaa8PzPh7JZurSAWJmx49nbVfaXYuiQg3RCubwn7") // 1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ
+	fundingAddr, _ := bscript.NewAddressFromPublicKeyString(hex.EncodeToString(fundingWif.SerialisePubKey()), true)
+	fundingScript, _ := bscript.NewP2PKHFromAddress(fundingAddr.AddressString)
+	fundingUnlockerGetter := unlocker.Getter{PrivateKey: fundingWif.PrivKey}
+	fundingUnlocker, _ := fundingUnlockerGetter.Unlocker(context.Background(), fundingScript)
+
+	referrerS, _ := bscript.NewP2PKHFromAddress("19NfKd8aTwvb5ngfP29RxgfQzZt8KAYtQo")
+	payouts := &ord.Payouts{
+		MarketplaceFee: &ord.Payout{LockingScript: fundingScript, Satoshis: 20},
+		Referrals:      []*ord.Payout{{LockingScript: referrerS, BasisPoints: 100}},
+	}
+
+	us := []*bt.UTXO{
+		{
+			TxID: func() []byte {
+				t, _ := hex.DecodeString("e3e0c0b46826ae1cd8932daf70b280d686104cdd5c685dbe6bed823e437f9040")
+				return t
+			}(),
+			Vout:          uint32(0),
+			LockingScript: fundingScript,
+			Satoshis:      900,
+			Unlocker:      &fundingUnlocker,
+		},
+		{
+			TxID: func() []byte {
+				t, _ := hex.DecodeString("44ab22c6996ce2dee4829fa171dd2543f16bd35b7373aa446b3060bdbf43b588")
+				return t
+			}(),
+			Vout:          uint32(0),
+			LockingScript: fundingScript,
+			Satoshis:      500,
+			Unlocker:      &fundingUnlocker,
+		},
+	}
+
+	ordUTXO := &bt.UTXO{
+		TxID: func() []byte {
+			t, _ := hex.DecodeString("75e24ffd0161f094a5e419dba42684c69faeacbeb805a1d9afdb29f6f4ac81ad")
+			return t
+		}(),
+		Vout:          uint32(0),
+		LockingScript: fundingScript,
+		Satoshis:      1,
+	}
+
+	pstx, err := ord.MakeBidToBuy1SatOrdinal(context.Background(), &ord.MakeBidArgs{
+		BidAmount:                 500,
+		OrdinalTxID:               ordUTXO.TxIDStr(),
+		OrdinalVOut:               ordUTXO.Vout,
+		BidderUTXOs:               us,
+		BuyerReceiveOrdinalScript: fundingScript,
+		DummyOutputScript:         fundingScript,
+		ChangeScript:              fundingScript,
+		FQ:                        bt.NewFeeQuote(),
+		Payouts:                   payouts,
+	})
+	require.NoError(t, err)
+	expOutputs, err := payouts.Outputs(500)
+	require.NoError(t, err)
+	assert.Equal(t, expOutputs, pstx.Outputs[3:5])
+
+	t.Run("bid with matching payouts is valid", func(t *testing.T) {
+		vba := &ord.ValidateBidArgs{
+			OrdinalUTXO: ordUTXO,
+			BidderUTXOs: us,
+			BidAmount:   500,
+			ExpectedFQ:  bt.NewFeeQuote(),
+			Payouts:     payouts,
+		}
+		assert.NoError(t, vba.Validate(pstx))
+	})
+
+	t.Run("bid missing an expected payout is invalid", func(t *testing.T) {
+		vba := &ord.ValidateBidArgs{
+			OrdinalUTXO: ordUTXO,
+			BidderUTXOs: us,
+			BidAmount:   500,
+			ExpectedFQ:  bt.NewFeeQuote(),
+			Payouts: &ord.Payouts{
+				MarketplaceFee: payouts.MarketplaceFee,
+				Referrals:      append(payouts.Referrals, &ord.Payout{LockingScript: fundingScript, Satoshis: 1}),
+			},
+		}
+		assert.ErrorIs(t, vba.Validate(pstx), bt.ErrPayoutsMismatch)
+	})
+}
@@ -0,0 +1,484 @@
+package ord
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
+)
+
+// The functions in this file deal with ordinals that are not alone
+// in a 1 satoshi output but sit somewhere inside a larger satoshi
+// range (>1 satoshi outputs).
+//
+// Ordinals go from inputs to outputs in FIFO order:
+// [a b] [c] [d e f] → [? ? ? ?] [? ?]
+// [a b] [c] [d e f] → [a b c d] [e f]
+//
+// To carve out a specific satoshi from the range, a padding output is
+// created before the seller output, which directly precedes the 1 satoshi
+// buyer output, such that the traded satoshi ends up alone in the buyer
+// output. The satoshis of the range before the traded satoshi count towards
+// the price and so end up in the seller output (or, for the ones exceeding
+// the price, in the first padding output), while the satoshis after it are
+// passed on to the buyer in a second padding output.
+//
+// For more info check the Ordinals Theory Handbook (https://docs.ordinals.com/faq.html).
+
+// SatOutput returns the index of the output that the satoshi at index
+// satIdx of the input at index inputIdx is transferred to, and the index
+// of the satoshi inside that output, following the ordinal FIFO
+// accounting scheme.
+//
+// All inputs up to and including inputIdx need PreviousTxSatoshis set.
+func SatOutput(tx *bt.Tx, inputIdx int, satIdx uint64) (int, uint64, error) {
+	if inputIdx < 0 || inputIdx >= tx.InputCount() {
+		return 0, 0, bt.ErrInputNoExist
+	}
+	if satIdx >= tx.Inputs[inputIdx].PreviousTxSatoshis {
+		return 0, 0, bt.ErrOrdinalSatOutOfRange
+	}
+
+	var pos uint64
+	for _, in := range tx.Inputs[:inputIdx] {
+		if in.PreviousTxSatoshis == 0 {
+			return 0, 0, bt.ErrInputSatsZero
+		}
+		pos += in.PreviousTxSatoshis
+	}
+	pos += satIdx
+
+	for i, o := range tx.Outputs {
+		if pos < o.Satoshis {
+			return i, pos, nil
+		}
+		pos -= o.Satoshis
+	}
+
+	return 0, 0, bt.ErrOrdinalSatToFees
+}
+
+// AcceptListingRangeArgs contains the arguments
+// needed to accept an offer to sell an ordinal
+// which is part of a satoshi range.
+type AcceptListingRangeArgs struct {
+	PSTx                      *bt.Tx
+	OrdinalSatIdx             uint64 // index of the ordinal inside the listed utxo
+	UTXOs                     []*bt.UTXO
+	BuyerReceiveOrdinalScript *bscript.Script
+	PaddingOutputScript       *bscript.Script
+	ChangeScript              *bscript.Script
+	FQ                        *bt.FeeQuote
+}
+
+// AcceptOrdinalRangeSaleListing accepts a partially signed Bitcoin
+// transaction offer to sell an ordinal which sits at index OrdinalSatIdx
+// inside the listed UTXO. The listing itself is created with
+// ListOrdinalForSale as normal since it does not depend on the amount
+// of satoshis in the listed UTXO.
+//
+// The whole listed range is bought, with the chosen ordinal carved out
+// into the 1 satoshi buyer receive output. The range satoshis before the
+// ordinal count towards the listed price, ending up in the seller output,
+// and the ones after it are passed on to a padding output locked with
+// PaddingOutputScript. When
+// accepting the offer, you will need to provide at least 2 UTXOs - with
+// at least 1 being large enough to pay the listed price.
+func AcceptOrdinalRangeSaleListing(ctx context.Context, vla *ValidateListingArgs,
+	asoa *AcceptListingRangeArgs) (*bt.Tx, error) {
+
+	if err := vla.Validate(asoa.PSTx); err != nil {
+		return nil, &offerError{reason: err}
+	}
+	sellerOrdinalInput := asoa.PSTx.Inputs[0]
+	sellerOutput := asoa.PSTx.Outputs[0]
+
+	if asoa.OrdinalSatIdx >= vla.ListedOrdinalUTXO.Satoshis {
+		return nil, bt.ErrOrdinalSatOutOfRange
+	}
+
+	if len(asoa.UTXOs) < 2 {
+		return nil, bt.ErrInsufficientUTXOs
+	}
+
+	if asoa.BuyerReceiveOrdinalScript == nil ||
+		asoa.PaddingOutputScript == nil ||
+		asoa.ChangeScript == nil {
+		return nil, bt.ErrEmptyScripts
+	}
+
+	// the satoshis before the ordinal in the listed range
+	// count towards covering the listed price
+	utxos, err := moveFirstUTXOAbove(asoa.UTXOs, sellerOutput.Satoshis, asoa.OrdinalSatIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := bt.NewTx()
+
+	// add first input to pay for ordinal
+	if err = tx.FromUTXOs(utxos[0]); err != nil {
+		return nil, fmt.Errorf(`failed to add input: %w`, err)
+	}
+
+	ordInput := *sellerOrdinalInput // shallow copy so that the listing isn't changed
+	ordInput.PreviousTxSatoshis = vla.ListedOrdinalUTXO.Satoshis
+	ordInput.PreviousTxScript = vla.ListedOrdinalUTXO.LockingScript
+	tx.Inputs = append(tx.Inputs, &ordInput)
+
+	// add input(s) to pay for tx fees
+	if err = tx.FromUTXOs(utxos[1:]...); err != nil {
+		return nil, fmt.Errorf(`failed to add inputs: %w`, err)
+	}
+
+	addRangeOutputs(tx, &rangeOutputs{
+		paymentSats:         utxos[0].Satoshis,
+		price:               sellerOutput.Satoshis,
+		rangeSats:           vla.ListedOrdinalUTXO.Satoshis,
+		satIdx:              asoa.OrdinalSatIdx,
+		sellerOutput:        sellerOutput,
+		buyerOrdinalScript:  asoa.BuyerReceiveOrdinalScript,
+		paddingOutputScript: asoa.PaddingOutputScript,
+	})
+
+	if err := addPayoutOutputs(tx, vla.Payouts, sellerOutput.Satoshis); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Change(asoa.ChangeScript, asoa.FQ); err != nil {
+		return nil, err
+	}
+
+	if err = fillUTXOInputs(ctx, tx, utxos, 1, sighash.AllForkID); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// MakeBidRangeArgs contains the arguments
+// needed to make a bid to buy an ordinal
+// which is part of a satoshi range.
+type MakeBidRangeArgs struct {
+	BidAmount                 uint64
+	OrdinalTxID               string
+	OrdinalVOut               uint32
+	OrdinalSatoshis           uint64 // amount of satoshis in the ordinal utxo
+	OrdinalSatIdx             uint64 // index of the ordinal inside the ordinal utxo
+	BidderUTXOs               []*bt.UTXO
+	BuyerReceiveOrdinalScript *bscript.Script
+	PaddingOutputScript       *bscript.Script
+	ChangeScript              *bscript.Script
+	FQ                        *bt.FeeQuote
+	Payouts                   *Payouts
+}
+
+// MakeBidToBuyOrdinalRange makes a bid offer to buy an ordinal which sits
+// at index OrdinalSatIdx inside a UTXO of OrdinalSatoshis satoshis. The bid
+// is for the whole range, with the chosen ordinal carved out into the
+// 1 satoshi buyer receive output. The range satoshis before the ordinal
+// count towards the bid amount, ending up in the seller output, and the
+// ones after it are passed on to a padding output locked with
+// PaddingOutputScript.
+//
+// This tx will be partially signed and will need to be completed by the
+// seller if they accept the bid using AcceptBidToBuyOrdinalRange.
+func MakeBidToBuyOrdinalRange(ctx context.Context, mba *MakeBidRangeArgs) (*bt.Tx, error) {
+	if mba.OrdinalSatIdx >= mba.OrdinalSatoshis {
+		return nil, bt.ErrOrdinalSatOutOfRange
+	}
+
+	if len(mba.BidderUTXOs) < 2 {
+		return nil, bt.ErrInsufficientUTXOs
+	}
+
+	if mba.BuyerReceiveOrdinalScript == nil ||
+		mba.PaddingOutputScript == nil ||
+		mba.ChangeScript == nil {
+		return nil, bt.ErrEmptyScripts
+	}
+
+	utxos, err := moveFirstUTXOAbove(mba.BidderUTXOs, mba.BidAmount, mba.OrdinalSatIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := bt.NewTx()
+
+	// add first input to pay for ordinal
+	if err = tx.FromUTXOs(utxos[0]); err != nil {
+		return nil, fmt.Errorf(`failed to add input: %w`, err)
+	}
+
+	OrdinalTxIDBytes, err := hex.DecodeString(mba.OrdinalTxID)
+	if err != nil {
+		return nil, err
+	}
+	ordScript, err := dummyOrdinalScript()
+	if err != nil {
+		return nil, err
+	}
+	sellerScript, err := dummySellerScript()
+	if err != nil {
+		return nil, err
+	}
+	emptyOrdInput := &bt.Input{
+		PreviousTxOutIndex: mba.OrdinalVOut,
+		PreviousTxSatoshis: mba.OrdinalSatoshis,
+		PreviousTxScript:   ordScript,
+	}
+	if err = emptyOrdInput.PreviousTxIDAdd(OrdinalTxIDBytes); err != nil {
+		return nil, fmt.Errorf(`failed to add ordinal input: %w`, err)
+	}
+	tx.Inputs = append(tx.Inputs, emptyOrdInput)
+
+	// add payment input(s)
+	if err = tx.FromUTXOs(utxos[1:]...); err != nil {
+		return nil, fmt.Errorf(`failed to add inputs: %w`, err)
+	}
+
+	addRangeOutputs(tx, &rangeOutputs{
+		paymentSats: utxos[0].Satoshis,
+		price:       mba.BidAmount,
+		rangeSats:   mba.OrdinalSatoshis,
+		satIdx:      mba.OrdinalSatIdx,
+		sellerOutput: &bt.Output{
+			Satoshis:      mba.BidAmount,
+			LockingScript: sellerScript,
+		},
+		buyerOrdinalScript:  mba.BuyerReceiveOrdinalScript,
+		paddingOutputScript: mba.PaddingOutputScript,
+	})
+
+	if err := addPayoutOutputs(tx, mba.Payouts, mba.BidAmount); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Change(mba.ChangeScript, mba.FQ); err != nil {
+		return nil, err
+	}
+
+	if err = fillUTXOInputs(ctx, tx, utxos, 1, sighash.SingleForkID); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// ValidateBidRangeArgs are the arguments needed to
+// validate a specific bid to buy an ordinal which
+// is part of a satoshi range.
+type ValidateBidRangeArgs struct {
+	OrdinalUTXO   *bt.UTXO
+	OrdinalSatIdx uint64
+	BidderUTXOs   []*bt.UTXO // in input order, excluding the ordinal input
+	BidAmount     uint64
+	ExpectedFQ    *bt.FeeQuote
+	Payouts       *Payouts
+}
+
+// Validate a bid to buy an ordinal range given specific
+// validation parameters. On top of the checks done for
+// 1 satoshi ordinal bids, the ordinal FIFO accounting is
+// followed to check that the ordinal ends up alone in the
+// buyer output.
+//
+// An error describing why the bid is invalid is returned,
+// or nil if it's valid.
+func (vba *ValidateBidRangeArgs) Validate(pstx *bt.Tx) error {
+	if pstx.InputCount() < 3 {
+		return fmt.Errorf("%w: expected at least 3, got %d", bt.ErrInvalidInputCount, pstx.InputCount())
+	}
+	if pstx.OutputCount() < 3 {
+		return fmt.Errorf("%w: expected at least 3, got %d", bt.ErrInvalidOutputCount, pstx.OutputCount())
+	}
+	if vba.OrdinalUTXO == nil {
+		return bt.ErrOrdinalInputMismatch
+	}
+	if vba.OrdinalSatIdx >= vba.OrdinalUTXO.Satoshis {
+		return bt.ErrOrdinalSatOutOfRange
+	}
+
+	// check OrdinalUTXO matches supplied pstx input index 1
+	pstxOrdinalInput := pstx.Inputs[1]
+	if !bytes.Equal(pstxOrdinalInput.PreviousTxID(), vba.OrdinalUTXO.TxID) {
+		return bt.ErrOrdinalInputMismatch
+	}
+	if uint64(pstxOrdinalInput.PreviousTxOutIndex) != uint64(vba.OrdinalUTXO.Vout) {
+		return bt.ErrOrdinalInputMismatch
+	}
+
+	// set the value of the inputs and output for the supplied utxos and
+	// the bid amount on a clone, so that the pstx validated is left untouched
+	if len(vba.BidderUTXOs) != pstx.InputCount()-1 {
+		return fmt.Errorf("%w: expected %d utxos, got %d", bt.ErrUTXOInputMismatch,
+			pstx.InputCount()-1, len(vba.BidderUTXOs))
+	}
+	pstx = pstx.Clone()
+	vba.fill(pstx)
+
+	// check the ordinal ends up alone in the buyer output
+	if pstx.Outputs[2].Satoshis != 1 {
+		return bt.ErrOrdinalOutputMismatch
+	}
+	outIdx, _, err := SatOutput(pstx, 1, vba.OrdinalSatIdx)
+	if err != nil {
+		return fmt.Errorf("%w: %s", bt.ErrOrdinalOutputMismatch, err)
+	}
+	if outIdx != 2 {
+		return fmt.Errorf("%w: transferred to output %d", bt.ErrOrdinalOutputMismatch, outIdx)
+	}
+
+	// check the marketplace payouts for the bid amount
+	// which come after the padding output (if any)
+	payoutsIdx := bidPayoutsIdx
+	if vba.OrdinalSatIdx < vba.OrdinalUTXO.Satoshis-1 {
+		payoutsIdx++
+	}
+	if err := checkPayoutOutputs(pstx, payoutsIdx, vba.Payouts, vba.BidAmount); err != nil {
+		return err
+	}
+
+	// check enough fees paid
+	enough, err := pstx.IsFeePaidEnough(vba.ExpectedFQ)
+	if err != nil {
+		return err
+	}
+	if !enough {
+		return bt.ErrFeePaidNotEnough
+	}
+
+	return verifyInputs(pstx, bidderInputIdxs(len(vba.BidderUTXOs), 1), vba.BidderUTXOs)
+}
+
+// fill sets the previous satoshis of the inputs of tx from
+// the utxos, and the bid amount of its seller output.
+func (vba *ValidateBidRangeArgs) fill(tx *bt.Tx) {
+	for i, idx := range bidderInputIdxs(len(vba.BidderUTXOs), 1) {
+		tx.Inputs[idx].PreviousTxSatoshis = vba.BidderUTXOs[i].Satoshis
+	}
+	tx.Inputs[1].PreviousTxSatoshis = vba.OrdinalUTXO.Satoshis
+	tx.Outputs[1].Satoshis = vba.BidAmount
+}
+
+// AcceptBidToBuyOrdinalRange accepts a partially signed Bitcoin
+// transaction bid to buy an ordinal which is part of a satoshi range.
+func AcceptBidToBuyOrdinalRange(ctx context.Context, vba *ValidateBidRangeArgs, aba *AcceptBidArgs) (*bt.Tx, error) {
+	if err := vba.Validate(aba.PSTx); err != nil {
+		return nil, &offerError{reason: err}
+	}
+
+	tx := aba.PSTx.Clone()
+	vba.fill(tx)
+
+	tx.Outputs[1].LockingScript = aba.SellerReceiveScript
+	// check if fees paid are still enough with new
+	// locking script
+	enough, err := tx.IsFeePaidEnough(vba.ExpectedFQ)
+	if err != nil || !enough {
+		return nil, bt.ErrInsufficientFees
+	}
+
+	tx.Inputs[1].PreviousTxScript = vba.OrdinalUTXO.LockingScript
+	tx.Inputs[1].PreviousTxSatoshis = vba.OrdinalUTXO.Satoshis
+	err = tx.FillInput(ctx, aba.OrdinalUnlocker, bt.UnlockerParams{InputIdx: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// rangeOutputs contains the values needed
+// to build the outputs carving out an
+// ordinal from a satoshi range.
+type rangeOutputs struct {
+	paymentSats         uint64
+	price               uint64
+	rangeSats           uint64
+	satIdx              uint64
+	sellerOutput        *bt.Output
+	buyerOrdinalScript  *bscript.Script
+	paddingOutputScript *bscript.Script
+}
+
+// addRangeOutputs adds the outputs needed for the ordinal at index
+// ro.satIdx of the ordinal input (index 1) to be transferred to the buyer
+// output (index 2) given that the payment input is at index 0:
+//
+// [payment] [range] → [padding] [seller] [ordinal] [padding]
+//
+// The first padding output takes ro.paymentSats+ro.satIdx-ro.price
+// satoshis, so that the seller output, which directly precedes the ordinal
+// output, ends with the last range satoshi before the ordinal. The range
+// satoshis before the ordinal therefore go to the seller output, or to the
+// first padding output for the ones exceeding the price, and the second
+// padding output takes the range satoshis after the ordinal.
+func addRangeOutputs(tx *bt.Tx, ro *rangeOutputs) {
+	tx.AddOutput(&bt.Output{
+		LockingScript: ro.paddingOutputScript,
+		Satoshis:      ro.paymentSats + ro.satIdx - ro.price,
+	})
+
+	tx.AddOutput(ro.sellerOutput)
+
+	tx.AddOutput(&bt.Output{
+		LockingScript: ro.buyerOrdinalScript,
+		Satoshis:      1,
+	})
+
+	if after := ro.rangeSats - ro.satIdx - 1; after > 0 {
+		tx.AddOutput(&bt.Output{
+			LockingScript: ro.paddingOutputScript,
+			Satoshis:      after,
+		})
+	}
+}
+
+// moveFirstUTXOAbove returns the utxos with the first utxo which, together
+// with the extra satoshis, is larger than the amount moved to the beginning.
+func moveFirstUTXOAbove(utxos []*bt.UTXO, amount, extra uint64) ([]*bt.UTXO, error) {
+	for i, u := range utxos {
+		if u.Satoshis+extra > amount {
+			us := make([]*bt.UTXO, 0, len(utxos))
+			us = append(us, u)
+			us = append(us, utxos[:i]...)
+			return append(us, utxos[i+1:]...), nil
+		}
+	}
+
+	return nil, bt.ErrInsufficientUTXOValue
+}
+
+// fillUTXOInputs signs the inputs of the tx spending the utxos
+// provided, skipping the input at index skipIdx (ordinal input).
+func fillUTXOInputs(ctx context.Context, tx *bt.Tx, utxos []*bt.UTXO, skipIdx int, shf sighash.Flag) error {
+	for i, u := range utxos {
+		j := i
+		if i >= skipIdx {
+			j++
+		}
+
+		if j >= tx.InputCount() {
+			return fmt.Errorf("input expected at index %d doesn't exist", j)
+		}
+		if !(bytes.Equal(u.TxID, tx.Inputs[j].PreviousTxID())) {
+			return bt.ErrUTXOInputMismatch
+		}
+		if u.Unlocker == nil || *u.Unlocker == nil {
+			return fmt.Errorf("UTXO unlocker at index %d not found", i)
+		}
+		if err := tx.FillInput(ctx, *u.Unlocker, bt.UnlockerParams{
+			InputIdx:     uint32(j),
+			SigHashFlags: shf,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
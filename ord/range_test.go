@@ -0,0 +1,356 @@
+package ord_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/libsv/go-bk/wif"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/ord"
+	"github.com/libsv/go-bt/v2/unlocker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSatOutput(t *testing.T) {
+	tx := bt.NewTx()
+	assert.NoError(t, tx.From("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a", 0, "76a914c25e9a2b70ec83d7b4fbd0f36f00a86723a48e6b88ac", 2))
+	assert.NoError(t, tx.From("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a", 1, "76a914c25e9a2b70ec83d7b4fbd0f36f00a86723a48e6b88ac", 1))
+	assert.NoError(t, tx.From("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a", 2, "76a914c25e9a2b70ec83d7b4fbd0f36f00a86723a48e6b88ac", 3))
+	assert.NoError(t, tx.PayToAddress("1C3V9TTJefP8Hft96sVf54mQyDJh8Ze4w4", 4))
+	assert.NoError(t, tx.PayToAddress("1C3V9TTJefP8Hft96sVf54mQyDJh8Ze4w4", 1))
+
+	// [a b] [c] [d e f] → [a b c d] [e] (f to fees)
+	tests := map[string]struct {
+		inputIdx  int
+		satIdx    uint64
+		expOutIdx int
+		expSatIdx uint64
+		expErr    error
+	}{
+		"first sat of first input": {
+			inputIdx: 0, satIdx: 0, expOutIdx: 0, expSatIdx: 0,
+		},
+		"only sat of second input": {
+			inputIdx: 1, satIdx: 0, expOutIdx: 0, expSatIdx: 2,
+		},
+		"first sat of third input": {
+			inputIdx: 2, satIdx: 0, expOutIdx: 0, expSatIdx: 3,
+		},
+		"second sat of third input": {
+			inputIdx: 2, satIdx: 1, expOutIdx: 1, expSatIdx: 0,
+		},
+		"last sat of third input goes to fees": {
+			inputIdx: 2, satIdx: 2, expErr: bt.ErrOrdinalSatToFees,
+		},
+		"sat out of range of input": {
+			inputIdx: 1, satIdx: 1, expErr: bt.ErrOrdinalSatOutOfRange,
+		},
+		"input doesn't exist": {
+			inputIdx: 3, satIdx: 0, expErr: bt.ErrInputNoExist,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			outIdx, satIdx, err := ord.SatOutput(tx, test.inputIdx, test.satIdx)
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expOutIdx, outIdx)
+			assert.Equal(t, test.expSatIdx, satIdx)
+		})
+	}
+}
+
+func TestOfferToSellOrdinalRange(t *testing.T) {
+	ordWif, _ := wif.DecodeWIF("L42PyNwEKE4XRaa8PzPh7JZurSAWJmx49nbVfaXYuiQg3RCubwn7") // 1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ
+	ordPrefixAddr, _ := bscript.NewAddressFromPublicKeyString(hex.EncodeToString(ordWif.SerialisePubKey()), true)
+	ordPrefixScript, _ := bscript.NewP2PKHFromAddress(ordPrefixAddr.AddressString)
+
+	ordUnlockerGetter := unlocker.Getter{PrivateKey: ordWif.PrivKey}
+	ordUnlocker, _ := ordUnlockerGetter.Unlocker(context.Background(), ordPrefixScript)
+
+	ordUTXO := &bt.UTXO{
+		TxID: func() []byte {
+			t, _ := hex.DecodeString("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a")
+			return t
+		}(),
+		Vout:          uint32(0),
+		LockingScript: ordPrefixScript,
+		Satoshis:      10,
+	}
+
+	pstx, err := ord.ListOrdinalForSale(context.Background(), &ord.ListOrdinalArgs{
+		SellerReceiveOutput: &bt.Output{
+			Satoshis: 1000,
+			LockingScript: func() *bscript.Script {
+				s, _ := bscript.NewP2PKHFromAddress("1C3V9TTJefP8Hft96sVf54mQyDJh8Ze4w4") // L1JWiLZtCkkqin41XtQ2Jxo1XGxj1R4ydT2zmxPiaeQfuyUK631D
+				return s
+			}(),
+		},
+		OrdinalUTXO:     ordUTXO,
+		OrdinalUnlocker: ordUnlocker,
+	})
+	require.NoError(t, err)
+
+	us := []*bt.UTXO{
+		{
+			TxID: func() []byte {
+				t, _ := hex.DecodeString("fcc55cd1a4275e5750070381028d3e3edf99b238bdc56199ff8bdc17dfb599d1")
+				return t
+			}(),
+			Vout:          uint32(3),
+			LockingScript: ordPrefixScript,
+			Satoshis:      500,
+			Unlocker:      &ordUnlocker,
+		},
+		{
+			TxID: func() []byte {
+				t, _ := hex.DecodeString("8f027fb1361ae46ac165e1d90e5436ed9c11d4eeaa60669ab90386a3abd9ce6a")
+				return t
+			}(),
+			Vout:          uint32(1),
+			LockingScript: ordPrefixScript,
+			Satoshis:      1200,
+			Unlocker:      &ordUnlocker,
+		},
+	}
+	buyerOrdS, _ := bscript.NewP2PKHFromAddress("1HebepswCi6huw1KJ7LvkrgemAV63TyVUs") // KwQq67d4Jds3wxs3kQHB8PPwaoaBQfNKkzAacZeMesb7zXojVYpj
+	paddingS, _ := bscript.NewP2PKHFromAddress("19NfKd8aTwvb5ngfP29RxgfQzZt8KAYtQo")  // L5W2nyKUCsDStVUBwZj2Q3Ph5vcae4bgdzprZDYqDpvZA8AFguFH
+
+	tests := map[string]struct {
+		satIdx         uint64
+		expOutputCount int
+		expErr         error
+	}{
+		"first sat of range": {
+			satIdx:         0,
+			expOutputCount: 5,
+		},
+		"middle sat of range": {
+			satIdx:         4,
+			expOutputCount: 5,
+		},
+		"last sat of range has no padding after it": {
+			satIdx:         9,
+			expOutputCount: 4,
+		},
+		"sat out of range": {
+			satIdx: 10,
+			expErr: bt.ErrOrdinalSatOutOfRange,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tx, err := ord.AcceptOrdinalRangeSaleListing(context.Background(), &ord.ValidateListingArgs{
+				ListedOrdinalUTXO: ordUTXO,
+			},
+				&ord.AcceptListingRangeArgs{
+					PSTx:                      pstx,
+					OrdinalSatIdx:             test.satIdx,
+					UTXOs:                     us,
+					BuyerReceiveOrdinalScript: buyerOrdS,
+					PaddingOutputScript:       paddingS,
+					ChangeScript:              paddingS,
+					FQ:                        bt.NewFeeQuote(),
+				})
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expOutputCount, tx.OutputCount())
+			assert.Equal(t, pstx.Outputs[0], tx.Outputs[1])
+
+			outIdx, satIdx, err := ord.SatOutput(tx, 1, test.satIdx)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, outIdx)
+			assert.Equal(t, uint64(0), satIdx)
+			assert.Equal(t, uint64(1), tx.Outputs[2].Satoshis)
+			assert.Equal(t, buyerOrdS, tx.Outputs[2].LockingScript)
+
+			// the range sats before the ordinal end up at the end of
+			// the seller output, the ones after it in the padding output
+			for i := uint64(0); i < ordUTXO.Satoshis; i++ {
+				outIdx, satIdx, err := ord.SatOutput(tx, 1, i)
+				require.NoError(t, err)
+				switch {
+				case i < test.satIdx:
+					assert.Equal(t, 1, outIdx)
+					assert.Equal(t, tx.Outputs[1].Satoshis-test.satIdx+i, satIdx)
+				case i > test.satIdx:
+					assert.Equal(t, 3, outIdx)
+					assert.Equal(t, i-test.satIdx-1, satIdx)
+				}
+			}
+
+			for i, in := range tx.Inputs {
+				assert.NoError(t, interpreter.NewEngine().Execute(
+					interpreter.WithTx(tx, i, &bt.Output{
+						LockingScript: in.PreviousTxScript,
+						Satoshis:      in.PreviousTxSatoshis,
+					}),
+					interpreter.WithForkID(),
+					interpreter.WithAfterGenesis(),
+				))
+			}
+		})
+	}
+}
+
+func TestBidToBuyOrdinalRange(t *testing.T) {
+	fundingWif, _ := wif.DecodeWIF("L42PyNwEKE4XRaa8PzPh7JZurSAWJmx49nbVfaXYuiQg3RCubwn7") // 1JijRHzVfub38S2hizxkxEcVKQwuCTZmxJ
+	fundingAddr, _ := bscript.NewAddressFromPublicKeyString(hex.EncodeToString(fundingWif.SerialisePubKey()), true)
+	fundingScript, _ := bscript.NewP2PKHFromAddress(fundingAddr.AddressString)
+	fundingUnlockerGetter := unlocker.Getter{PrivateKey: fundingWif.PrivKey}
+	fundingUnlocker, _ := fundingUnlockerGetter.Unlocker(context.Background(), fundingScript)
+
+	ordWif, _ := wif.DecodeWIF("KwQq67d4Jds3wxs3kQHB8PPwaoaBQfNKkzAacZeMesb7zXojVYpj") // 1HebepswCi6huw1KJ7LvkrgemAV63TyVUs
+	ordPrefixAddr, _ := bscript.NewAddressFromPublicKeyString(hex.EncodeToString(ordWif.SerialisePubKey()), true)
+	ordPrefixScript, _ := bscript.NewP2PKHFromAddress(ordPrefixAddr.AddressString)
+	ordUnlockerGetter := unlocker.Getter{PrivateKey: ordWif.PrivKey}
+	ordUnlocker, _ := ordUnlockerGetter.Unlocker(context.Background(), ordPrefixScript)
+
+	bidAmount := uint64(500)
+	ordSatIdx := uint64(3)
+
+	us := []*bt.UTXO{
+		{
+			TxID: func() []byte {
+				t, _ := hex.DecodeString("e3e0c0b46826ae1cd8932daf70b280d686104cdd5c685dbe6bed823e437f9040")
+				return t
+			}(),
+			Vout:          uint32(0),
+			LockingScript: fundingScript,
+			Satoshis:      900,
+			Unlocker:      &fundingUnlocker,
+		},
+		{
+			TxID: func() []byte {
+				t, _ := hex.DecodeString("44ab22c6996ce2dee4829fa171dd2543f16bd35b7373aa446b3060bdbf43b588")
+				return t
+			}(),
+			Vout:          uint32(0),
+			LockingScript: fundingScript,
+			Satoshis:      500,
+			Unlocker:      &fundingUnlocker,
+		},
+	}
+
+	ordUTXO := &bt.UTXO{
+		TxID: func() []byte {
+			t, _ := hex.DecodeString("75e24ffd0161f094a5e419dba42684c69faeacbeb805a1d9afdb29f6f4ac81ad")
+			return t
+		}(),
+		Vout:          uint32(0),
+		LockingScript: ordPrefixScript,
+		Satoshis:      5,
+	}
+
+	pstx, err := ord.MakeBidToBuyOrdinalRange(context.Background(), &ord.MakeBidRangeArgs{
+		BidAmount:       bidAmount,
+		OrdinalTxID:     ordUTXO.TxIDStr(),
+		OrdinalVOut:     ordUTXO.Vout,
+		OrdinalSatoshis: ordUTXO.Satoshis,
+		OrdinalSatIdx:   ordSatIdx,
+		BidderUTXOs:     us,
+		BuyerReceiveOrdinalScript: func() *bscript.Script {
+			s, _ := bscript.NewP2PKHFromAddress("12R2qFEoUtWwwVecgrkxwMZNnMq6GB8pQW") // L3kLQ9rpDBLgbh3GfPSbXDGwxgmK2Dcb6Qrp4JZRRcne8FMDZWDc
+			return s
+		}(),
+		PaddingOutputScript: fundingScript,
+		ChangeScript:        fundingScript,
+		FQ:                  bt.NewFeeQuote(),
+	})
+	require.NoError(t, err)
+
+	t.Run("ordinal ends up in buyer output", func(t *testing.T) {
+		outIdx, satIdx, err := ord.SatOutput(pstx, 1, ordSatIdx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, outIdx)
+		assert.Equal(t, uint64(0), satIdx)
+
+		for i := uint64(0); i < ordSatIdx; i++ {
+			outIdx, satIdx, err := ord.SatOutput(pstx, 1, i)
+			require.NoError(t, err)
+			assert.Equal(t, 1, outIdx, "range sats before the ordinal go to the seller output")
+			assert.Equal(t, bidAmount-ordSatIdx+i, satIdx)
+		}
+	})
+
+	t.Run("validate bid to buy ordinal range", func(t *testing.T) {
+		vba := &ord.ValidateBidRangeArgs{
+			OrdinalUTXO:   ordUTXO,
+			OrdinalSatIdx: ordSatIdx,
+			BidderUTXOs:   us,
+			BidAmount:     bidAmount,
+			ExpectedFQ:    bt.NewFeeQuote(),
+		}
+		assert.NoError(t, vba.Validate(pstx))
+	})
+
+	t.Run("validating leaves the bid untouched", func(t *testing.T) {
+		// parsed from bytes, so without the previous satoshis
+		parsed, err := bt.NewTxFromBytes(pstx.Bytes())
+		require.NoError(t, err)
+		vba := &ord.ValidateBidRangeArgs{
+			OrdinalUTXO:   ordUTXO,
+			OrdinalSatIdx: ordSatIdx,
+			BidderUTXOs:   us,
+			BidAmount:     bidAmount,
+			ExpectedFQ:    bt.NewFeeQuote(),
+		}
+		assert.NoError(t, vba.Validate(parsed))
+		for _, in := range parsed.Inputs {
+			assert.Zero(t, in.PreviousTxSatoshis)
+		}
+	})
+
+	t.Run("bid for another sat of the range is invalid", func(t *testing.T) {
+		vba := &ord.ValidateBidRangeArgs{
+			OrdinalUTXO:   ordUTXO,
+			OrdinalSatIdx: ordSatIdx - 1,
+			BidderUTXOs:   us,
+			BidAmount:     bidAmount,
+			ExpectedFQ:    bt.NewFeeQuote(),
+		}
+		assert.ErrorIs(t, vba.Validate(pstx), bt.ErrOrdinalOutputMismatch)
+	})
+
+	t.Run("no errors when accepting bid", func(t *testing.T) {
+		tx, err := ord.AcceptBidToBuyOrdinalRange(context.Background(), &ord.ValidateBidRangeArgs{
+			OrdinalUTXO:   ordUTXO,
+			OrdinalSatIdx: ordSatIdx,
+			BidderUTXOs:   us,
+			BidAmount:     bidAmount,
+			ExpectedFQ:    bt.NewFeeQuote(),
+		},
+			&ord.AcceptBidArgs{
+				PSTx: pstx,
+				SellerReceiveScript: func() *bscript.Script {
+					s, _ := bscript.NewP2PKHFromAddress("1C3V9TTJefP8Hft96sVf54mQyDJh8Ze4w4") // L1JWiLZtCkkqin41XtQ2Jxo1XGxj1R4ydT2zmxPiaeQfuyUK631D
+					return s
+				}(),
+				OrdinalUnlocker: ordUnlocker,
+			})
+		require.NoError(t, err)
+
+		for i, in := range tx.Inputs {
+			assert.NoError(t, interpreter.NewEngine().Execute(
+				interpreter.WithTx(tx, i, &bt.Output{
+					LockingScript: in.PreviousTxScript,
+					Satoshis:      in.PreviousTxSatoshis,
+				}),
+				interpreter.WithForkID(),
+				interpreter.WithAfterGenesis(),
+			))
+		}
+	})
+}
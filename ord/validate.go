@@ -0,0 +1,89 @@
+package ord
+
+import (
+	"fmt"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// offerError is returned when accepting an invalid listing
+// or bid. It matches bt.ErrInvalidSellOffer with errors.Is
+// while still unwrapping to the reason the offer is invalid.
+type offerError struct {
+	reason error
+}
+
+func (e *offerError) Error() string {
+	return fmt.Sprintf("%s: %s", bt.ErrInvalidSellOffer, e.reason)
+}
+
+func (e *offerError) Is(target error) bool {
+	return target == bt.ErrInvalidSellOffer
+}
+
+func (e *offerError) Unwrap() error {
+	return e.reason
+}
+
+// verifyInputs runs the script interpreter against the inputs of the
+// tx at the given indices, spending the utxos at the same position.
+//
+// The interpreter is run against a clone of the tx since it sets the
+// previous tx scripts and satoshis of the inputs it executes.
+func verifyInputs(tx *bt.Tx, inputIdxs []int, utxos []*bt.UTXO) error {
+	if len(inputIdxs) != len(utxos) {
+		return fmt.Errorf("%w: expected %d utxos, got %d", bt.ErrUTXOInputMismatch, len(inputIdxs), len(utxos))
+	}
+
+	txc := tx.Clone()
+	for i, idx := range inputIdxs {
+		u := utxos[i]
+		if u == nil || u.LockingScript == nil {
+			return fmt.Errorf("%w: input %d", bt.ErrEmptyPreviousTxScript, idx)
+		}
+		if err := interpreter.NewEngine().Execute(
+			interpreter.WithTx(txc, idx, &bt.Output{
+				LockingScript: u.LockingScript,
+				Satoshis:      u.Satoshis,
+			}),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		); err != nil {
+			return fmt.Errorf("%w: input %d: %s", bt.ErrInvalidUnlockingScript, idx, err)
+		}
+	}
+
+	return nil
+}
+
+// bidderInputIdxs returns the indices of the inputs signed by
+// the bidder given the amount of bidder utxos, skipping the
+// ordinal input at index ordIdx.
+func bidderInputIdxs(count, ordIdx int) []int {
+	idxs := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		j := i
+		if i >= ordIdx {
+			j++
+		}
+		idxs = append(idxs, j)
+	}
+
+	return idxs
+}
+
+// dummyOrdinalScript returns a hello world (text/plain) test inscription,
+// used as the PreviousTxScript of the ordinal input of a bid so that the
+// change function can estimate UnlockingScript sizes.
+func dummyOrdinalScript() (*bscript.Script, error) {
+	//nolint:lll // test inscription
+	return bscript.NewFromHexString("76a914c25e9a2b70ec83d7b4fbd0f36f00a86723a48e6b88ac0063036f72645118746578742f706c61696e3b636861727365743d7574662d38000d48656c6c6f2c20776f726c642168")
+}
+
+// dummySellerScript returns a p2pkh script standing in for the seller
+// output of a bid, so that fees are calculated accurately.
+func dummySellerScript() (*bscript.Script, error) {
+	return bscript.NewP2PKHFromAddress("1FunnyJoke111111111111111112AVXh5")
+}